@@ -17,6 +17,8 @@ package backend
 
 import (
 	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/backend/metrics"
+	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/logger"
 	"github.com/rs/zerolog"
 )
@@ -28,11 +30,13 @@ const (
 	UNKNOWN ID = iota
 	GROTH16
 	PLONK
+	PIANO
+	GPIANO
 )
 
 // Implemented return the list of proof systems implemented in gnark
 func Implemented() []ID {
-	return []ID{GROTH16, PLONK}
+	return []ID{GROTH16, PLONK, PIANO, GPIANO}
 }
 
 // String returns the string representation of a proof system
@@ -42,6 +46,10 @@ func (id ID) String() string {
 		return "groth16"
 	case PLONK:
 		return "plonk"
+	case PIANO:
+		return "piano"
+	case GPIANO:
+		return "gpiano"
 	default:
 		return "unknown"
 	}
@@ -57,6 +65,8 @@ type ProverConfig struct {
 	Force         bool                      // defaults to false
 	HintFunctions map[hint.ID]hint.Function // defaults to all built-in hint functions
 	CircuitLogger zerolog.Logger            // defaults to gnark.Logger
+	ProverMetrics func(*metrics.Report)     // piano, gpiano: defaults to nil
+	Solution      witness.Vector            // defaults to nil: the solver computes it
 }
 
 // NewProverConfig returns a default ProverConfig with given prover options opts
@@ -75,6 +85,18 @@ func NewProverConfig(opts ...ProverOption) (ProverConfig, error) {
 	return opt, nil
 }
 
+// CheckHints returns a *hint.MissingError naming the hints of the manifest which are neither
+// registered nor given with WithHints in opts. The solver only fails on a missing hint once it
+// runs: a prover decoding a constraint system should call this right after ReadFrom, with the
+// options it will prove with.
+func CheckHints(m hint.Manifest, opts ...ProverOption) error {
+	opt, err := NewProverConfig(opts...)
+	if err != nil {
+		return err
+	}
+	return m.Check(opt.HintFunctions)
+}
+
 // IgnoreSolverError is a prover option that indicates that the Prove algorithm
 // should complete even if constraint system is not solved. In that case, Prove
 // will output an invalid Proof, but will execute all algorithms which is useful
@@ -114,3 +136,24 @@ func WithCircuitLogger(l zerolog.Logger) ProverOption {
 		return nil
 	}
 }
+
+// WithProverMetrics is a prover option that makes the distributed provers (piano, gpiano) record
+// their rounds, and call fn with the report at the end of Prove. On the master, the report has the
+// record of every party. The option must be given to all the parties.
+func WithProverMetrics(fn func(*metrics.Report)) ProverOption {
+	return func(opt *ProverConfig) error {
+		opt.ProverMetrics = fn
+		return nil
+	}
+}
+
+// WithSolution is a prover option that gives the values of all the wires of the circuit
+// [public | secret | internal], computed beforehand (see package backend/solution). The prover
+// checks that they extend the witness and satisfy the constraints, and uses them instead of running
+// the solver; the hints need not be registered.
+func WithSolution(v witness.Vector) ProverOption {
+	return func(opt *ProverConfig) error {
+		opt.Solution = v
+		return nil
+	}
+}
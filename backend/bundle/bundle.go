@@ -0,0 +1,131 @@
+// Package bundle serializes a compiled circuit together with a header listing what is needed
+// to prove it: the curve, the backend and the manifest of its hints (see hint.Manifest). A prover
+// service reads the header first and rejects a circuit whose hints it doesn't know before
+// decoding the constraint system, instead of failing once the solver runs.
+//
+// Binary protocol
+//
+//	Bundle  ->  [uint32(len(header)) | header (JSON) | constraint system]
+//
+// where the constraint system is encoded with its WriteTo method.
+package bundle
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+)
+
+// ErrHintsMismatch is returned by Read when the hints of the constraint system are not the ones
+// of the header of the bundle.
+var ErrHintsMismatch = errors.New("the hints of the constraint system don't match the header of the bundle")
+
+// maxHeaderSize bounds the header of a bundle, which is read before any check
+const maxHeaderSize = 1 << 24
+
+// Header describes the circuit of a bundle.
+type Header struct {
+	Curve   ecc.ID        `json:"curve"`
+	Backend backend.ID    `json:"backend"`
+	Hints   hint.Manifest `json:"hints"`
+}
+
+// Write writes ccs, compiled for backend b, and its header to w.
+func Write(w io.Writer, ccs frontend.CompiledConstraintSystem, b backend.ID) (int64, error) {
+	if b == backend.UNKNOWN {
+		return 0, errors.New("unknown backend")
+	}
+	header, err := json.Marshal(Header{Curve: ccs.CurveID(), Backend: b, Hints: ccs.HintManifest()})
+	if err != nil {
+		return 0, err
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(header)))
+	n, err := w.Write(size[:])
+	written := int64(n)
+	if err != nil {
+		return written, err
+	}
+	n, err = w.Write(header)
+	written += int64(n)
+	if err != nil {
+		return written, err
+	}
+	m, err := ccs.WriteTo(w)
+	return written + m, err
+}
+
+// ReadHeader reads the header of a bundle from r, which is then positioned on the constraint
+// system.
+func ReadHeader(r io.Reader) (*Header, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxHeaderSize {
+		return nil, fmt.Errorf("bundle header too large: %d bytes", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	var h Header
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("invalid bundle header: %w", err)
+	}
+	return &h, nil
+}
+
+// Read reads a bundle from r. The hints of the circuit are checked with backend.CheckHints
+// against the registered hints and the ones given in opts before the constraint system is
+// decoded; a *hint.MissingError is returned if some of them are unknown. Once decoded, the
+// constraint system must need exactly the hints of the header, or ErrHintsMismatch is returned.
+func Read(r io.Reader, opts ...backend.ProverOption) (frontend.CompiledConstraintSystem, *Header, error) {
+	h, err := ReadHeader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := backend.CheckHints(h.Hints, opts...); err != nil {
+		return nil, h, err
+	}
+
+	supported := false
+	for _, c := range gnark.Curves() {
+		supported = supported || c == h.Curve
+	}
+	if !supported {
+		return nil, h, fmt.Errorf("unsupported curve %s", h.Curve)
+	}
+
+	var ccs frontend.CompiledConstraintSystem
+	switch h.Backend {
+	case backend.GROTH16:
+		ccs = groth16.NewCS(h.Curve)
+	case backend.PLONK, backend.PIANO, backend.GPIANO:
+		// the three of them prove a SparseR1CS
+		ccs = plonk.NewCS(h.Curve)
+	default:
+		return nil, h, fmt.Errorf("unsupported backend %s", h.Backend)
+	}
+	if _, err := ccs.ReadFrom(r); err != nil {
+		return nil, h, err
+	}
+	if ccs.CurveID() != h.Curve {
+		return nil, h, fmt.Errorf("the constraint system is on %s, the header says %s", ccs.CurveID(), h.Curve)
+	}
+	if !ccs.HintManifest().Equal(h.Hints) {
+		return nil, h, ErrHintsMismatch
+	}
+	return ccs, h, nil
+}
@@ -0,0 +1,140 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	hint.RegisterVersioned(square, "bundle/square", 1)
+}
+
+// square is registered with a stable identifier
+func square(_ ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].Mul(inputs[0], inputs[0])
+	return nil
+}
+
+// double is not registered, the prover must give it
+func double(_ ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].Lsh(inputs[0], 1)
+	return nil
+}
+
+type hintCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *hintCircuit) Define(api frontend.API) error {
+	sq, err := api.Compiler().NewHint(square, 1, c.X)
+	if err != nil {
+		return err
+	}
+	d, err := api.Compiler().NewHint(double, 1, c.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(sq[0], api.Mul(c.X, c.X))
+	api.AssertIsEqual(d[0], api.Add(c.X, c.X))
+	api.AssertIsEqual(api.Add(sq[0], d[0]), c.Y)
+	return nil
+}
+
+func TestBundle(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal("bundle/square@v1", hint.Name(square))
+	w, err := frontend.NewWitness(&hintCircuit{X: 3, Y: 15}, ecc.BN254)
+	assert.NoError(err)
+
+	for _, tc := range []struct {
+		builder frontend.NewBuilder
+		backend backend.ID
+	}{{r1cs.NewBuilder, backend.GROTH16}, {scs.NewBuilder, backend.PLONK}} {
+		ccs, err := frontend.Compile(ecc.BN254, tc.builder, &hintCircuit{})
+		assert.NoError(err)
+
+		m := ccs.HintManifest()
+		assert.Len(m.Hints, 2)
+		assert.Equal("bundle/square@v1", m.Hints[0].Name)
+		assert.Equal([]string{"github.com/consensys/gnark/backend/bundle"}, m.Packages())
+
+		var buf bytes.Buffer
+		_, err = Write(&buf, ccs, tc.backend)
+		assert.NoError(err)
+
+		// double is neither registered nor given
+		_, h, err := Read(bytes.NewReader(buf.Bytes()))
+		var missing *hint.MissingError
+		assert.True(errors.As(err, &missing), "%v", err)
+		assert.Equal([]string{hint.Name(double)}, missing.Names)
+		assert.Equal(tc.backend, h.Backend)
+		assert.Error(backend.CheckHints(m))
+
+		read, h, err := Read(bytes.NewReader(buf.Bytes()), backend.WithHints(double))
+		assert.NoError(err)
+		assert.Equal(Header{Curve: ecc.BN254, Backend: tc.backend, Hints: m}, *h)
+		assert.Equal(ccs.GetNbConstraints(), read.GetNbConstraints())
+		assert.NoError(read.IsSolved(w, backend.WithHints(double)))
+	}
+}
+
+func TestReadHintsMismatch(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &hintCircuit{})
+	assert.NoError(err)
+	var buf bytes.Buffer
+	_, err = Write(&buf, ccs, backend.PLONK)
+	assert.NoError(err)
+
+	for name, tamper := range map[string]func(*Header){
+		// the prover doesn't need double any more, according to the header
+		"missing hint": func(h *Header) {
+			h.Hints.Hints = h.Hints.Hints[:1]
+		},
+		// the header asks for a hint the circuit doesn't use
+		"extra hint": func(h *Header) {
+			h.Hints.Hints = append(h.Hints.Hints, hint.Entry{ID: hint.UUID(hint.IsZero), Name: hint.Name(hint.IsZero)})
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := rewriteHeader(t, buf.Bytes(), tamper)
+			_, _, err := Read(bytes.NewReader(data), backend.WithHints(double))
+			require.ErrorIs(t, err, ErrHintsMismatch)
+		})
+	}
+}
+
+// rewriteHeader returns the bundle data with its header modified by tamper.
+func rewriteHeader(t *testing.T, data []byte, tamper func(*Header)) []byte {
+	r := bytes.NewReader(data)
+	h, err := ReadHeader(r)
+	require.NoError(t, err)
+	tamper(h)
+	header, err := json.Marshal(h)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(header)))
+	buf.Write(size[:])
+	buf.Write(header)
+	_, err = io.Copy(&buf, r)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
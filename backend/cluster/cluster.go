@@ -0,0 +1,258 @@
+// Package cluster describes the machines running the distributed backends (piano, gpiano), and
+// sets up the MPI world of the process from this description.
+//
+// A configuration is a YAML or JSON file:
+//
+//	hosts:
+//	  - {rank: 0, address: 10.0.0.1, port: 9998}
+//	  - {rank: 1, address: 10.0.0.2, port: 9999}
+//	ssh:
+//	  user: pianist
+//	  keyFile: /home/pianist/.ssh/id_rsa
+//	workingDir: /home/pianist/run
+//
+// The party of rank 0 is the master: it starts the other parties over SSH, with the same command
+// line, and listens on the port of each of them for its connection. The other parties receive
+// their rank, the working directory and the world from the master: their own configuration is
+// only validated.
+//
+// The examples, the tests of the distributed backends and pianist run read the configuration
+// file named by the environment variable PIANIST_CLUSTER (see FromEnv).
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sunblaze-ucb/simpleMPI/mpi"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the description of a cluster.
+type Config struct {
+	Hosts []Host `json:"hosts" yaml:"hosts"`
+	SSH   SSH    `json:"ssh" yaml:"ssh"`
+
+	// WorkingDir is the directory the other parties run in. Defaults to the working directory
+	// of the master, which is left unchanged.
+	WorkingDir string `json:"workingDir,omitempty" yaml:"workingDir,omitempty"`
+}
+
+// Host is a party of the cluster.
+type Host struct {
+	Rank    uint64 `json:"rank" yaml:"rank"`
+	Address string `json:"address" yaml:"address"`
+	Port    uint16 `json:"port" yaml:"port"`
+}
+
+// SSH is the account the master uses to start the other parties.
+type SSH struct {
+	User    string `json:"user" yaml:"user"`
+	KeyFile string `json:"keyFile" yaml:"keyFile"`
+}
+
+// Local returns the configuration of n parties on localhost, listening on the ports following
+// port, and started with the default SSH key of the current user.
+func Local(n int, port uint16) *Config {
+	c := &Config{SSH: SSH{User: os.Getenv("USER")}}
+	if home, err := os.UserHomeDir(); err == nil {
+		c.SSH.KeyFile = filepath.Join(home, ".ssh", "id_rsa")
+	}
+	for i := 0; i < n; i++ {
+		c.Hosts = append(c.Hosts, Host{Rank: uint64(i), Address: "localhost", Port: port + uint16(i)})
+	}
+	return c
+}
+
+// EnvVar is the environment variable read by FromEnv: the path of a configuration file.
+const EnvVar = "PIANIST_CLUSTER"
+
+// ErrNoConfig is returned by FromEnv when PIANIST_CLUSTER is not set. The tests of the
+// distributed backends then run on in-process worlds.
+var ErrNoConfig = errors.New("cluster: no configuration, " + EnvVar + " is not set")
+
+// FromEnv loads the configuration file named by the environment variable PIANIST_CLUSTER, and
+// returns ErrNoConfig if it is not set.
+func FromEnv() (*Config, error) {
+	path := os.Getenv(EnvVar)
+	if path == "" {
+		return nil, ErrNoConfig
+	}
+	return Load(path)
+}
+
+// Load reads and validates the configuration at path, in JSON if the name of the file ends with
+// .json and in YAML if it ends with .yaml or .yml.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path) //#nosec G304 -- the path is given by the user
+	if err != nil {
+		return nil, err
+	}
+
+	c := new(Config)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(c)
+	default:
+		return nil, fmt.Errorf("cluster: %s: unknown format, expected .json, .yaml or .yml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cluster: %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("%w (in %s)", err, path)
+	}
+	return c, nil
+}
+
+// Validate checks that the ranks of the hosts are 0, 1, ..., len(c.Hosts)-1, that their
+// addresses are distinct, and that the SSH account is set when there are several parties.
+func (c *Config) Validate() error {
+	n := len(c.Hosts)
+	if n == 0 {
+		return errors.New("cluster: no host")
+	}
+
+	byRank := make([]int, n)
+	for i := range byRank {
+		byRank[i] = -1
+	}
+	addresses := make(map[string]int, n)
+	for i, h := range c.Hosts {
+		if h.Rank >= uint64(n) {
+			return fmt.Errorf("cluster: host %d: rank %d, the ranks of %d hosts are 0 to %d", i, h.Rank, n, n-1)
+		}
+		if j := byRank[h.Rank]; j != -1 {
+			return fmt.Errorf("cluster: hosts %d and %d have the same rank %d", j, i, h.Rank)
+		}
+		byRank[h.Rank] = i
+		if h.Address == "" {
+			return fmt.Errorf("cluster: host %d: missing address", i)
+		}
+		if strings.ContainsAny(h.Address, ": \t") {
+			return fmt.Errorf("cluster: host %d: invalid address %q, the port is given apart", i, h.Address)
+		}
+		if h.Port == 0 {
+			return fmt.Errorf("cluster: host %d: missing port", i)
+		}
+		addr := fmt.Sprintf("%s:%d", h.Address, h.Port)
+		if j, ok := addresses[addr]; ok {
+			return fmt.Errorf("cluster: hosts %d and %d have the same address %s", j, i, addr)
+		}
+		addresses[addr] = i
+	}
+
+	if n > 1 {
+		if c.SSH.User == "" {
+			return errors.New("cluster: missing SSH user, required to start the other parties")
+		}
+		if c.SSH.KeyFile == "" {
+			return errors.New("cluster: missing SSH key file, required to start the other parties")
+		}
+	}
+	if c.WorkingDir != "" {
+		if fi, err := os.Stat(c.WorkingDir); err != nil {
+			return fmt.Errorf("cluster: working directory: %w", err)
+		} else if !fi.IsDir() {
+			return fmt.Errorf("cluster: working directory %s is not a directory", c.WorkingDir)
+		}
+	}
+	return nil
+}
+
+// Size returns the number of parties.
+func (c *Config) Size() uint64 {
+	return uint64(len(c.Hosts))
+}
+
+// WriteIPFile writes the hosts, ordered by rank, in the ip file format of simpleMPI.
+func (c *Config) WriteIPFile(path string) error {
+	hosts := make([]Host, len(c.Hosts))
+	copy(hosts, c.Hosts)
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Rank < hosts[j].Rank })
+
+	var buf bytes.Buffer
+	for _, h := range hosts {
+		fmt.Fprintf(&buf, "%s:%d\n", h.Address, h.Port)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0600)
+}
+
+var initLock sync.Mutex
+
+// Init sets up the MPI world of the process: the master starts and connects to the other parties,
+// which connect to the master. It does nothing if the world is already set up, provided it has
+// c.Size() parties.
+//
+// simpleMPI moves the other parties to the working directory of the master: the master enters
+// c.WorkingDir only while it starts them, and then returns to its own working directory.
+func (c *Config) Init() (err error) {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	initLock.Lock()
+	defer initLock.Unlock()
+
+	if mpi.WorldSize != 0 {
+		if mpi.WorldSize != c.Size() {
+			return fmt.Errorf("cluster: the MPI world of the process has %d parties, the configuration %d", mpi.WorldSize, c.Size())
+		}
+		return nil
+	}
+
+	f, err := os.CreateTemp("", "pianist-ip-*.txt")
+	if err != nil {
+		return err
+	}
+	ipFile := f.Name()
+	defer os.Remove(ipFile)
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := c.WriteIPFile(ipFile); err != nil {
+		return err
+	}
+	if c.WorkingDir != "" && !isSlave() {
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("cluster: %w", err)
+		}
+		if err := os.Chdir(c.WorkingDir); err != nil {
+			return fmt.Errorf("cluster: %w", err)
+		}
+		defer func() {
+			if errChdir := os.Chdir(wd); errChdir != nil && err == nil {
+				err = fmt.Errorf("cluster: %w", errChdir)
+			}
+		}()
+	}
+
+	// simpleMPI panics on errors
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cluster: setting up the MPI world: %v", r)
+		}
+	}()
+	mpi.WorldInit(ipFile, c.SSH.KeyFile, c.SSH.User)
+	return nil
+}
+
+// isSlave reports whether the process was started by a master: simpleMPI appends the address
+// of the master, the port of the party and "Slave" to the command line.
+func isSlave() bool {
+	return strings.EqualFold(os.Args[len(os.Args)-1], "slave")
+}
@@ -0,0 +1,143 @@
+package cluster
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark/internal/mpisim"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	assert := require.New(t)
+
+	yamlPath := writeConfig(t, "cluster.yaml", `
+hosts:
+  - {rank: 1, address: 10.0.0.2, port: 9999}
+  - {rank: 0, address: 10.0.0.1, port: 9998}
+ssh:
+  user: pianist
+  keyFile: /home/pianist/.ssh/id_rsa
+`)
+	jsonPath := writeConfig(t, "cluster.json", `{
+	"hosts": [
+		{"rank": 1, "address": "10.0.0.2", "port": 9999},
+		{"rank": 0, "address": "10.0.0.1", "port": 9998}
+	],
+	"ssh": {"user": "pianist", "keyFile": "/home/pianist/.ssh/id_rsa"}
+}`)
+
+	fromYAML, err := Load(yamlPath)
+	assert.NoError(err)
+	fromJSON, err := Load(jsonPath)
+	assert.NoError(err)
+	assert.Equal(fromYAML, fromJSON)
+	assert.Equal(uint64(2), fromYAML.Size())
+
+	// the ip file is ordered by rank
+	ipFile := filepath.Join(t.TempDir(), "ip.txt")
+	assert.NoError(fromYAML.WriteIPFile(ipFile))
+	data, err := os.ReadFile(ipFile)
+	assert.NoError(err)
+	assert.Equal("10.0.0.1:9998\n10.0.0.2:9999\n", string(data))
+}
+
+func TestLoadErrors(t *testing.T) {
+	const ssh = "ssh: {user: pianist, keyFile: key}\n"
+	for _, tc := range []struct {
+		name, content, err string
+	}{
+		{"cluster.txt", "", "unknown format"},
+		{"cluster.yaml", "hosts: []\n", "no host"},
+		{"cluster.yaml", "hosts:\n  - {rank: 0, address: a, port: 1, ip: b}\n", "field ip not found"},
+		{"cluster.json", `{"hosts": [{"rank": 0, "address": "a", "port": 1}], "nodes": 2}`, "unknown field"},
+		{"cluster.yaml", "hosts:\n  - {rank: 0, address: a, port: 1}\n  - {rank: 2, address: b, port: 2}\n" + ssh, "host 1: rank 2"},
+		{"cluster.yaml", "hosts:\n  - {rank: 0, address: a, port: 1}\n  - {address: b, port: 2}\n" + ssh, "hosts 0 and 1 have the same rank 0"},
+		{"cluster.yaml", "hosts:\n  - {rank: 0, port: 1}\n", "host 0: missing address"},
+		{"cluster.yaml", "hosts:\n  - {rank: 0, address: 'a:1', port: 1}\n", "invalid address"},
+		{"cluster.yaml", "hosts:\n  - {rank: 0, address: a}\n", "host 0: missing port"},
+		{"cluster.yaml", "hosts:\n  - {rank: 0, address: a, port: 70000}\n", "cannot unmarshal"},
+		{"cluster.yaml", "hosts:\n  - {rank: 0, address: a, port: 1}\n  - {rank: 1, address: a, port: 1}\n" + ssh, "same address a:1"},
+		{"cluster.yaml", "hosts:\n  - {rank: 0, address: a, port: 1}\n  - {rank: 1, address: b, port: 2}\n", "missing SSH user"},
+		{"cluster.yaml", "hosts:\n  - {rank: 0, address: a, port: 1}\nworkingDir: /does/not/exist\n", "working directory"},
+	} {
+		_, err := Load(writeConfig(t, tc.name, tc.content))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("%q: expected an error containing %q, got %v", tc.content, tc.err, err)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	assert := require.New(t)
+
+	t.Setenv(EnvVar, "")
+	_, err := FromEnv()
+	assert.ErrorIs(err, ErrNoConfig)
+
+	t.Setenv(EnvVar, writeConfig(t, "cluster.yaml", "hosts:\n  - {rank: 0, address: 10.0.0.1, port: 9998}\n"))
+	c, err := FromEnv()
+	assert.NoError(err)
+	assert.Equal(uint64(1), c.Size())
+
+	t.Setenv(EnvVar, filepath.Join(t.TempDir(), "missing.yaml"))
+	_, err = FromEnv()
+	assert.Error(err)
+	assert.False(errors.Is(err, ErrNoConfig))
+}
+
+func TestInit(t *testing.T) {
+	assert := require.New(t)
+
+	// a single party doesn't start any other one, and the master stays in its directory
+	wd, err := os.Getwd()
+	assert.NoError(err)
+	c := Local(1, 9998)
+	c.WorkingDir = t.TempDir()
+	assert.NoError(c.Init())
+	after, err := os.Getwd()
+	assert.NoError(err)
+	assert.Equal(wd, after, "Init must not change the working directory of the master")
+	assert.NoError(Local(1, 9998).Init(), "the world is already set up")
+	assert.Error(Local(2, 9998).Init(), "the world of the process has a single party")
+}
+
+func TestCounter(t *testing.T) {
+	assert := require.New(t)
+
+	world, err := mpisim.NewWorld(2, mpisim.WithFaults(mpisim.Fault{Kind: mpisim.Drop, From: 1, To: 0, Index: 1}), mpisim.WithTimeout(50*time.Millisecond))
+	assert.NoError(err)
+	counters := [2]*Counter{NewCounter(world.Party(0)), NewCounter(world.Party(1))}
+	errs := world.Run(func(p *mpisim.Party) error {
+		tr := counters[p.Rank()]
+		if p.Rank() != 0 {
+			if err := tr.SendBytes(make([]byte, 5), 0); err != nil {
+				return err
+			}
+			// dropped
+			return tr.SendBytes(make([]byte, 7), 0)
+		}
+		if _, err := tr.ReceiveBytes(5, 1); err != nil {
+			return err
+		}
+		_, err := tr.ReceiveBytes(7, 1)
+		return err
+	})
+	assert.ErrorIs(errs[0], mpisim.ErrTimeout)
+
+	// the dropped message counts as sent, not as received
+	assert.Equal(uint64(12), counters[1].BytesSent())
+	assert.Zero(counters[1].BytesReceived())
+	assert.Zero(counters[0].BytesSent())
+	assert.Equal(uint64(5), counters[0].BytesReceived())
+}
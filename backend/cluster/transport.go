@@ -0,0 +1,89 @@
+package cluster
+
+import (
+	"sync/atomic"
+
+	"github.com/sunblaze-ucb/simpleMPI/mpi"
+)
+
+// Transport exchanges the messages of the parties of a distributed backend, in the star topology
+// of simpleMPI: the master (rank 0) exchanges with every other party, and the other parties only
+// with the master, the rank they give being ignored.
+//
+// The provers, and their distributed KZG commitments, exchange their messages through the
+// transport of their party: MPI for the world of the process, or a party of an in-process world
+// (internal/mpisim) in tests.
+type Transport interface {
+	// Rank returns the rank of the party, 0 being the master.
+	Rank() uint64
+	// Size returns the number of parties.
+	Size() uint64
+	// SendBytes sends buf to the party of the given rank.
+	SendBytes(buf []byte, rank uint64) error
+	// ReceiveBytes receives size bytes from the party of the given rank.
+	ReceiveBytes(size, rank uint64) ([]byte, error)
+}
+
+// MPI returns the transport of the simpleMPI world of the process, set up by Config.Init.
+func MPI() Transport {
+	return mpiTransport{}
+}
+
+type mpiTransport struct{}
+
+func (mpiTransport) Rank() uint64 {
+	return mpi.SelfRank
+}
+
+func (mpiTransport) Size() uint64 {
+	return mpi.WorldSize
+}
+
+func (mpiTransport) SendBytes(buf []byte, rank uint64) error {
+	return mpi.SendBytes(buf, rank)
+}
+
+func (mpiTransport) ReceiveBytes(size, rank uint64) ([]byte, error) {
+	return mpi.ReceiveBytes(size, rank)
+}
+
+// Counter is a Transport counting the bytes its party sends and receives through the transport it
+// wraps. The counters only grow with the messages exchanged successfully.
+type Counter struct {
+	Transport
+	sent, received uint64 // accessed atomically
+}
+
+// NewCounter returns a Counter of the bytes exchanged through tr.
+func NewCounter(tr Transport) *Counter {
+	return &Counter{Transport: tr}
+}
+
+// SendBytes sends buf to the party of the given rank, and counts its bytes.
+func (c *Counter) SendBytes(buf []byte, rank uint64) error {
+	if err := c.Transport.SendBytes(buf, rank); err != nil {
+		return err
+	}
+	atomic.AddUint64(&c.sent, uint64(len(buf)))
+	return nil
+}
+
+// ReceiveBytes receives size bytes from the party of the given rank, and counts them.
+func (c *Counter) ReceiveBytes(size, rank uint64) ([]byte, error) {
+	buf, err := c.Transport.ReceiveBytes(size, rank)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddUint64(&c.received, uint64(len(buf)))
+	return buf, nil
+}
+
+// BytesSent returns the number of bytes sent so far.
+func (c *Counter) BytesSent() uint64 {
+	return atomic.LoadUint64(&c.sent)
+}
+
+// BytesReceived returns the number of bytes received so far.
+func (c *Counter) BytesReceived() uint64 {
+	return atomic.LoadUint64(&c.received)
+}
@@ -0,0 +1,620 @@
+// Package export describes compiled constraint systems for people and tools, as JSON (the
+// selectors, wires, hints and debug info of each constraint) or as a Graphviz DOT graph of the
+// dependencies between the wires.
+//
+// With WithParties, the constraints of a SparseR1CS are placed on the parties as by the gpiano
+// prover, and the wires read on several parties (so by copy constraints crossing machines) are
+// listed. The parties of piano all prove the whole sub-circuit: no copy constraint crosses them.
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/schema"
+
+	cs_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/cs"
+	cs_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/cs"
+	cs_bls24315 "github.com/consensys/gnark/internal/backend/bls24-315/cs"
+	cs_bn254 "github.com/consensys/gnark/internal/backend/bn254/cs"
+	cs_bw6633 "github.com/consensys/gnark/internal/backend/bw6-633/cs"
+	cs_bw6761 "github.com/consensys/gnark/internal/backend/bw6-761/cs"
+)
+
+var errUnsupported = errors.New("unsupported constraint system")
+
+// System describes a compiled constraint system.
+type System struct {
+	Curve string `json:"curve"`
+	Kind  string `json:"kind"` // "r1cs" or "sparse_r1cs"
+
+	NbPublic   int `json:"nbPublic"`
+	NbSecret   int `json:"nbSecret"`
+	NbInternal int `json:"nbInternal"`
+
+	Wires       []Wire       `json:"wires"`
+	Hints       []Hint       `json:"hints,omitempty"`
+	Constraints []Constraint `json:"constraints"`
+
+	// set with WithParties
+	Parties       []Party        `json:"parties,omitempty"`
+	CrossingWires []CrossingWire `json:"crossingWires,omitempty"`
+}
+
+// Wire is a wire of the circuit: [public | secret | internal].
+type Wire struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name,omitempty"` // name of the input in the circuit
+	Visibility string `json:"visibility"`
+	Hint       *int   `json:"hint,omitempty"` // index of the hint computing the wire in System.Hints
+}
+
+// Hint is a hint of the circuit, computing its outputs from its inputs when solving.
+type Hint struct {
+	Name    string   `json:"name"`
+	Inputs  []string `json:"inputs"`
+	Outputs []int    `json:"outputs"`
+
+	wires []int // wires of the inputs
+	at    int   // constraint with which the solver runs the hint
+}
+
+// Constraint is a constraint of the circuit, with the selectors and wires of a SparseR1CS
+//
+//	qL·l + qR·r + qM·l·r + qO·o + qK = 0
+//
+// or the linear expressions of an R1CS
+//
+//	L·R = O
+type Constraint struct {
+	ID int `json:"id"`
+
+	Selectors *Selectors `json:"selectors,omitempty"`
+	Wires     []int      `json:"wires,omitempty"` // l, r, o
+
+	L []Term `json:"l,omitempty"`
+	R []Term `json:"r,omitempty"`
+	O []Term `json:"o,omitempty"`
+
+	// wire computed by the solver with the constraint, if any
+	Solves *int `json:"solves,omitempty"`
+
+	// set with WithParties
+	Row   *int `json:"row,omitempty"`
+	Party *int `json:"party,omitempty"`
+
+	// debug info of the API call which added the constraint, with the wires named
+	Debug string `json:"debug,omitempty"`
+}
+
+// Selectors are the coefficients of a constraint of a SparseR1CS.
+type Selectors struct {
+	QL string `json:"qL"`
+	QR string `json:"qR"`
+	QM string `json:"qM"`
+	QO string `json:"qO"`
+	QK string `json:"qK"`
+}
+
+// Term is a wire and its coefficient in a linear expression.
+type Term struct {
+	Wire  int    `json:"wire"`
+	Coeff string `json:"coeff"`
+}
+
+// Party describes the rows a party of gpiano proves.
+type Party struct {
+	ID            int `json:"id"`
+	FirstRow      int `json:"firstRow"`
+	NbRows        int `json:"nbRows"`
+	NbConstraints int `json:"nbConstraints"` // rows which are not placeholders or padding
+	NbCrossing    int `json:"nbCrossing"`    // wires of the party also read by other parties
+}
+
+// CrossingWire is a wire read on several parties: its copy constraint crosses them.
+type CrossingWire struct {
+	Wire    int   `json:"wire"`
+	Parties []int `json:"parties"`
+}
+
+// Option configures New.
+type Option func(*config) error
+
+type config struct {
+	nbParties int
+}
+
+// WithParties places the rows of a SparseR1CS, [ placeholders | constraints ], on nbParties
+// parties as the gpiano prover does: in blocks of a power of 2 rows, or as placed with
+// frontend.Compiler.OnParty.
+func WithParties(nbParties int) Option {
+	return func(c *config) error {
+		if nbParties <= 0 || nbParties&(nbParties-1) != 0 {
+			return fmt.Errorf("the number of parties must be a power of 2, got %d", nbParties)
+		}
+		c.nbParties = nbParties
+		return nil
+	}
+}
+
+// New describes ccs.
+func New(ccs frontend.CompiledConstraintSystem, opts ...Option) (*System, error) {
+	var cfg config
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	r1cs, spr, coeffs, err := unwrap(ccs)
+	if err != nil {
+		return nil, err
+	}
+	var cs *compiled.ConstraintSystem
+	if r1cs != nil {
+		cs = &r1cs.ConstraintSystem
+	} else {
+		cs = &spr.ConstraintSystem
+	}
+
+	s := &System{
+		Curve:      ccs.CurveID().String(),
+		NbPublic:   cs.NbPublicVariables,
+		NbSecret:   cs.NbSecretVariables,
+		NbInternal: cs.NbInternalVariables,
+	}
+	d := describer{cs: cs, s: s, coeffs: coeffs}
+	d.wires()
+	d.hints()
+
+	if r1cs != nil {
+		if cfg.nbParties != 0 {
+			return nil, fmt.Errorf("%w: the rows of a %T are not defined", errUnsupported, ccs)
+		}
+		s.Kind = "r1cs"
+		d.r1cs(r1cs)
+	} else {
+		s.Kind = "sparse_r1cs"
+		d.sparseR1CS(spr)
+		if cfg.nbParties != 0 {
+			if err := d.parties(spr, cfg.nbParties); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// WriteJSON writes s as indented JSON.
+func (s *System) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// WriteDOT writes the dependency graph of the wires of s in the Graphviz DOT language: an edge
+// goes from each wire a constraint reads to the wire it solves, and from the inputs of a hint to
+// its outputs (dashed). With parties, the internal wires are grouped by the party solving them
+// and the edges between parties are red.
+func (s *System) WriteDOT(w io.Writer) error {
+	var sbb strings.Builder
+	sbb.WriteString("digraph circuit {\n\trankdir=LR;\n\tnode [shape=box];\n")
+
+	// party solving each wire, -1 for the inputs
+	party := make([]int, len(s.Wires))
+	for i := range party {
+		party[i] = -1
+	}
+	if s.Parties != nil {
+		for _, c := range s.Constraints {
+			if c.Solves != nil {
+				party[*c.Solves] = *c.Party
+			}
+		}
+		for _, h := range s.Hints {
+			if h.at == -1 {
+				continue
+			}
+			for _, o := range h.Outputs {
+				party[o] = *s.Constraints[h.at].Party
+			}
+		}
+	}
+
+	node := func(wire Wire) {
+		label := wire.Name
+		if label == "" {
+			label = fmt.Sprintf("w%d", wire.ID)
+		}
+		fmt.Fprintf(&sbb, "\tw%d [label=%q];\n", wire.ID, label+" ("+wire.Visibility+")")
+	}
+	for _, wire := range s.Wires {
+		if party[wire.ID] == -1 {
+			node(wire)
+		}
+	}
+	for _, p := range s.Parties {
+		fmt.Fprintf(&sbb, "\tsubgraph cluster_party%d {\n\t\tlabel=\"party %d\";\n", p.ID, p.ID)
+		for _, wire := range s.Wires {
+			if party[wire.ID] == p.ID {
+				sbb.WriteByte('\t')
+				node(wire)
+			}
+		}
+		sbb.WriteString("\t}\n")
+	}
+
+	edge := func(from, to int, attrs string) {
+		if party[from] != -1 && party[from] != party[to] {
+			attrs += ", color=red"
+		}
+		fmt.Fprintf(&sbb, "\tw%d -> w%d [%s];\n", from, to, attrs)
+	}
+	for _, c := range s.Constraints {
+		if c.Solves == nil {
+			continue
+		}
+		for _, wire := range c.reads() {
+			if wire != *c.Solves {
+				edge(wire, *c.Solves, fmt.Sprintf("label=\"c%d\"", c.ID))
+			}
+		}
+	}
+	for _, h := range s.Hints {
+		for _, in := range h.wires {
+			for _, o := range h.Outputs {
+				edge(in, o, fmt.Sprintf("label=%q, style=dashed", h.Name))
+			}
+		}
+	}
+
+	sbb.WriteString("}\n")
+	_, err := io.WriteString(w, sbb.String())
+	return err
+}
+
+// reads returns the wires c reads
+func (c *Constraint) reads() []int {
+	if c.Selectors != nil {
+		var res []int
+		for i, q := range []string{c.Selectors.QL, c.Selectors.QR, c.Selectors.QO} {
+			if q != "0" || (i < 2 && c.Selectors.QM != "0") {
+				res = append(res, c.Wires[i])
+			}
+		}
+		return res
+	}
+	var res []int
+	for _, l := range [][]Term{c.L, c.R, c.O} {
+		for _, t := range l {
+			res = append(res, t.Wire)
+		}
+	}
+	return res
+}
+
+type describer struct {
+	cs     *compiled.ConstraintSystem
+	s      *System
+	coeffs []string
+
+	hintOf map[*compiled.Hint]int // index of the hints in s.Hints
+}
+
+func (d *describer) wires() {
+	nbInputs := d.cs.NbPublicVariables + d.cs.NbSecretVariables
+	d.s.Wires = make([]Wire, nbInputs+d.cs.NbInternalVariables)
+	for i := range d.s.Wires {
+		w := &d.s.Wires[i]
+		w.ID = i
+		switch {
+		case i < d.cs.NbPublicVariables:
+			w.Name = d.cs.Public[i]
+			w.Visibility = schema.Public.String()
+		case i < nbInputs:
+			w.Name = d.cs.Secret[i-d.cs.NbPublicVariables]
+			w.Visibility = schema.Secret.String()
+		default:
+			w.Visibility = schema.Internal.String()
+		}
+	}
+}
+
+func (d *describer) hints() {
+	d.hintOf = make(map[*compiled.Hint]int)
+	for w := range d.s.Wires {
+		h, ok := d.cs.MHints[w]
+		if !ok {
+			continue
+		}
+		i, ok := d.hintOf[h]
+		if !ok {
+			i = len(d.s.Hints)
+			d.hintOf[h] = i
+			hint := Hint{Name: d.cs.MHintsDependencies[h.ID], Outputs: h.Wires, at: -1}
+			for _, in := range h.Inputs {
+				switch t := in.(type) {
+				case compiled.LinearExpression:
+					hint.Inputs = append(hint.Inputs, d.expression(t))
+					for _, tt := range t {
+						hint.wires = append(hint.wires, tt.WireID())
+					}
+				case compiled.Term:
+					hint.Inputs = append(hint.Inputs, d.expression(compiled.LinearExpression{t}))
+					hint.wires = append(hint.wires, t.WireID())
+				default:
+					hint.Inputs = append(hint.Inputs, fmt.Sprint(in))
+				}
+			}
+			d.s.Hints = append(d.s.Hints, hint)
+		}
+		idx := i
+		d.s.Wires[w].Hint = &idx
+	}
+}
+
+// name returns the name of the wire, or w<ID> for the internal wires
+func (d *describer) name(wireID int) string {
+	if n := d.s.Wires[wireID].Name; n != "" {
+		return n
+	}
+	return fmt.Sprintf("w%d", wireID)
+}
+
+func (d *describer) term(t compiled.Term) string {
+	switch t.CoeffID() {
+	case compiled.CoeffIdOne:
+		return d.name(t.WireID())
+	case compiled.CoeffIdMinusOne:
+		return "-" + d.name(t.WireID())
+	default:
+		return d.coeffs[t.CoeffID()] + "*" + d.name(t.WireID())
+	}
+}
+
+func (d *describer) expression(l compiled.LinearExpression) string {
+	terms := make([]string, len(l))
+	for i, t := range l {
+		terms[i] = d.term(t)
+	}
+	return strings.Join(terms, " + ")
+}
+
+// debug resolves the debug info entry with the names of the wires instead of their values (see
+// the solver's logs)
+func (d *describer) debug(cID int) string {
+	dID, ok := d.cs.MDebug[cID]
+	if !ok {
+		return ""
+	}
+	entry := d.cs.DebugInfo[dID]
+	var toResolve []interface{}
+	var eval []string
+	isEval := false
+	for _, t := range entry.ToResolve {
+		if t == compiled.TermDelimitor {
+			if isEval {
+				toResolve = append(toResolve, "("+strings.Join(eval, " + ")+")")
+				eval = eval[:0]
+			}
+			isEval = !isEval
+			continue
+		}
+		coeffID, vID, visibility := t.Unpack()
+		if visibility == schema.Virtual {
+			// a constant
+			if isEval {
+				eval = append(eval, d.coeffs[coeffID])
+			} else {
+				toResolve = append(toResolve, d.coeffs[coeffID])
+			}
+			continue
+		}
+		if isEval {
+			eval = append(eval, d.term(t))
+			continue
+		}
+		if !(coeffID == compiled.CoeffIdMinusOne || coeffID == compiled.CoeffIdOne) {
+			toResolve = append(toResolve, d.coeffs[coeffID])
+		}
+		toResolve = append(toResolve, d.name(vID))
+	}
+	return fmt.Sprintf(entry.Format, toResolve...)
+}
+
+// solver follows the order in which the solver computes the wires
+type solver struct {
+	d      *describer
+	solved []bool
+}
+
+func (d *describer) newSolver() *solver {
+	s := &solver{d: d, solved: make([]bool, len(d.s.Wires))}
+	for i := 0; i < d.cs.NbPublicVariables+d.cs.NbSecretVariables; i++ {
+		s.solved[i] = true
+	}
+	return s
+}
+
+// solve marks the wires read by the constraint cID as solved, and returns the one it computes, if
+// any
+func (s *solver) solve(cID int, wires []int) *int {
+	var res *int
+	for _, w := range wires {
+		if s.solved[w] {
+			continue
+		}
+		if h, ok := s.d.cs.MHints[w]; ok {
+			for _, hw := range h.Wires {
+				s.solved[hw] = true
+			}
+			s.d.s.Hints[s.d.hintOf[h]].at = cID
+			continue
+		}
+		s.solved[w] = true
+		wire := w
+		res = &wire
+	}
+	return res
+}
+
+func (d *describer) sparseR1CS(spr *compiled.SparseR1CS) {
+	solver := d.newSolver()
+	d.s.Constraints = make([]Constraint, len(spr.Constraints))
+	for i, c := range spr.Constraints {
+		var qM string
+		if c.M[0].CoeffID() == compiled.CoeffIdZero || c.M[1].CoeffID() == compiled.CoeffIdZero {
+			qM = "0"
+		} else {
+			qM = d.coeffs[c.M[0].CoeffID()] + "*" + d.coeffs[c.M[1].CoeffID()]
+		}
+		d.s.Constraints[i] = Constraint{
+			ID: i,
+			Selectors: &Selectors{
+				QL: d.coeffs[c.L.CoeffID()],
+				QR: d.coeffs[c.R.CoeffID()],
+				QM: qM,
+				QO: d.coeffs[c.O.CoeffID()],
+				QK: d.coeffs[c.K],
+			},
+			Wires: []int{c.L.WireID(), c.R.WireID(), c.O.WireID()},
+			Debug: d.debug(i),
+		}
+		d.s.Constraints[i].Solves = solver.solve(i, d.s.Constraints[i].reads())
+	}
+}
+
+func (d *describer) r1cs(r1cs *compiled.R1CS) {
+	solver := d.newSolver()
+	d.s.Constraints = make([]Constraint, len(r1cs.Constraints))
+	terms := func(l compiled.LinearExpression) []Term {
+		res := make([]Term, len(l))
+		for i, t := range l {
+			res[i] = Term{Wire: t.WireID(), Coeff: d.coeffs[t.CoeffID()]}
+		}
+		return res
+	}
+	for i, c := range r1cs.Constraints {
+		d.s.Constraints[i] = Constraint{
+			ID:    i,
+			L:     terms(c.L),
+			R:     terms(c.R),
+			O:     terms(c.O),
+			Debug: d.debug(i),
+		}
+		d.s.Constraints[i].Solves = solver.solve(i, d.s.Constraints[i].reads())
+	}
+}
+
+// parties places the rows on nbParties parties as gpiano does, and lists the wires read on
+// several of them. As in the permutation of the prover, all the wires of a row are read, even
+// with a zero selector.
+func (d *describer) parties(spr *compiled.SparseR1CS, nbParties int) error {
+	nbRows := spr.NbPublicVariables + len(spr.Constraints)
+	n := spr.NbRowsPerParty()
+	if spr.NbParties == 0 {
+		n = int(ecc.NextPowerOfTwo(uint64((nbRows + nbParties - 1) / nbParties)))
+	} else if spr.NbParties != nbParties {
+		return fmt.Errorf("the constraints are placed on %d parties, got %d", spr.NbParties, nbParties)
+	}
+	if n < spr.NbPublicVariables {
+		return errors.New("public variables not in a single sub-circuit")
+	}
+
+	d.s.Parties = make([]Party, nbParties)
+	for p := range d.s.Parties {
+		d.s.Parties[p] = Party{ID: p, FirstRow: p * n, NbRows: n}
+	}
+
+	// parties reading each wire, in increasing order
+	readBy := make([][]int, len(d.s.Wires))
+	read := func(wire, party int) {
+		if l := len(readBy[wire]); l == 0 || readBy[wire][l-1] != party {
+			readBy[wire] = append(readBy[wire], party)
+		}
+	}
+	for i := 0; i < spr.NbPublicVariables; i++ {
+		read(i, 0) // placeholder
+	}
+	for i := range d.s.Constraints {
+		c := &d.s.Constraints[i]
+		row := spr.NbPublicVariables + i
+		party := row / n
+		c.Row, c.Party = &row, &party
+		if !isPadding(spr.Constraints[i]) {
+			d.s.Parties[party].NbConstraints++
+		}
+		for _, w := range c.Wires {
+			read(w, party)
+		}
+	}
+
+	for w, parties := range readBy {
+		if len(parties) < 2 {
+			continue
+		}
+		d.s.CrossingWires = append(d.s.CrossingWires, CrossingWire{Wire: w, Parties: parties})
+		for _, p := range parties {
+			d.s.Parties[p].NbCrossing++
+		}
+	}
+	return nil
+}
+
+// isPadding reports whether c is an empty constraint, as the ones padding the parties
+func isPadding(c compiled.SparseR1C) bool {
+	return c.L.CoeffID() == compiled.CoeffIdZero && c.R.CoeffID() == compiled.CoeffIdZero &&
+		c.O.CoeffID() == compiled.CoeffIdZero && c.K == compiled.CoeffIdZero &&
+		(c.M[0].CoeffID() == compiled.CoeffIdZero || c.M[1].CoeffID() == compiled.CoeffIdZero)
+}
+
+// unwrap returns the curve independent constraint system of ccs, either an R1CS or a SparseR1CS,
+// and the values of its coefficients
+func unwrap(ccs frontend.CompiledConstraintSystem) (*compiled.R1CS, *compiled.SparseR1CS, []string, error) {
+	switch tccs := ccs.(type) {
+	case *cs_bn254.R1CS:
+		return &tccs.R1CS, nil, toStrings(tccs.Coefficients), nil
+	case *cs_bn254.SparseR1CS:
+		return nil, &tccs.SparseR1CS, toStrings(tccs.Coefficients), nil
+	case *cs_bls12381.R1CS:
+		return &tccs.R1CS, nil, toStrings(tccs.Coefficients), nil
+	case *cs_bls12381.SparseR1CS:
+		return nil, &tccs.SparseR1CS, toStrings(tccs.Coefficients), nil
+	case *cs_bls12377.R1CS:
+		return &tccs.R1CS, nil, toStrings(tccs.Coefficients), nil
+	case *cs_bls12377.SparseR1CS:
+		return nil, &tccs.SparseR1CS, toStrings(tccs.Coefficients), nil
+	case *cs_bw6761.R1CS:
+		return &tccs.R1CS, nil, toStrings(tccs.Coefficients), nil
+	case *cs_bw6761.SparseR1CS:
+		return nil, &tccs.SparseR1CS, toStrings(tccs.Coefficients), nil
+	case *cs_bw6633.R1CS:
+		return &tccs.R1CS, nil, toStrings(tccs.Coefficients), nil
+	case *cs_bw6633.SparseR1CS:
+		return nil, &tccs.SparseR1CS, toStrings(tccs.Coefficients), nil
+	case *cs_bls24315.R1CS:
+		return &tccs.R1CS, nil, toStrings(tccs.Coefficients), nil
+	case *cs_bls24315.SparseR1CS:
+		return nil, &tccs.SparseR1CS, toStrings(tccs.Coefficients), nil
+	default:
+		return nil, nil, nil, fmt.Errorf("%w: %T", errUnsupported, ccs)
+	}
+}
+
+func toStrings[E any, P interface {
+	*E
+	String() string
+}](coeffs []E) []string {
+	res := make([]string, len(coeffs))
+	for i := range coeffs {
+		res[i] = P(&coeffs[i]).String()
+	}
+	return res
+}
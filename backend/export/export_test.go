@@ -0,0 +1,94 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/stretchr/testify/require"
+)
+
+type cubic struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// Define declares x**3 + x + 5 == y
+func (c *cubic) Define(api frontend.API) error {
+	x3 := api.Mul(c.X, c.X, c.X)
+	api.AssertIsEqual(c.Y, api.Add(x3, c.X, 5))
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	assert := require.New(t)
+
+	for _, builder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254, builder, &cubic{})
+		assert.NoError(err)
+
+		s, err := New(ccs)
+		assert.NoError(err)
+		assert.Equal(ccs.GetNbConstraints(), len(s.Constraints))
+		visibility := make(map[string]string)
+		for _, w := range s.Wires {
+			visibility[w.Name] = w.Visibility
+		}
+		assert.Equal("public", visibility["Y"])
+		assert.Equal("secret", visibility["X"])
+
+		last := s.Constraints[len(s.Constraints)-1]
+		assert.True(strings.HasPrefix(last.Debug, "[assertIsEqual] Y"), last.Debug)
+
+		var buf bytes.Buffer
+		assert.NoError(s.WriteJSON(&buf))
+		var decoded System
+		assert.NoError(json.Unmarshal(buf.Bytes(), &decoded))
+		assert.Equal(s.Kind, decoded.Kind)
+		assert.Equal(s.Wires, decoded.Wires)
+		assert.Equal(len(s.Constraints), len(decoded.Constraints))
+
+		buf.Reset()
+		assert.NoError(s.WriteDOT(&buf))
+		assert.True(strings.HasPrefix(buf.String(), "digraph circuit {"))
+	}
+}
+
+func TestExportParties(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &cubic{})
+	assert.NoError(err)
+	_, err = New(ccs, WithParties(2))
+	assert.Error(err, "no rows in a R1CS")
+
+	ccs, err = frontend.Compile(ecc.BN254, scs.NewBuilder, &cubic{})
+	assert.NoError(err)
+	_, err = New(ccs, WithParties(3))
+	assert.Error(err)
+
+	// [ Y | x² | x³ | x + 5 ] [ + x³ | == Y ], the internal wires are x², x³, x + 5 and y
+	s, err := New(ccs, WithParties(2))
+	assert.NoError(err)
+	assert.Equal([]Party{
+		{ID: 0, FirstRow: 0, NbRows: 4, NbConstraints: 3, NbCrossing: s.Parties[0].NbCrossing},
+		{ID: 1, FirstRow: 4, NbRows: 4, NbConstraints: 2, NbCrossing: s.Parties[1].NbCrossing},
+	}, s.Parties)
+	assert.Equal(1, *s.Constraints[3].Party)
+
+	crossing := make(map[int][]int)
+	for _, c := range s.CrossingWires {
+		crossing[c.Wire] = c.Parties
+	}
+	assert.Equal([]int{0, 1}, crossing[3], "x³ is read on both parties")
+
+	var buf bytes.Buffer
+	assert.NoError(s.WriteDOT(&buf))
+	assert.Contains(buf.String(), "cluster_party1")
+	assert.Contains(buf.String(), "color=red")
+}
@@ -3,9 +3,10 @@ package gpiano
 import (
 	"io"
 
-	"github.com/consensys/gnark-crypto/dkzg"
 	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/kzg"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/cluster"
 	"github.com/consensys/gnark/frontend"
 
 	"github.com/consensys/gnark/backend/witness"
@@ -30,7 +31,7 @@ type Proof interface {
 type ProvingKey interface {
 	io.WriterTo
 	io.ReaderFrom
-	InitKZG(srs dkzg.SRS) error
+	InitKZG(srs kzg.SRS) error
 	VerifyingKey() interface{}
 }
 
@@ -40,20 +41,31 @@ type ProvingKey interface {
 type VerifyingKey interface {
 	io.WriterTo
 	io.ReaderFrom
-	InitKZG(srs dkzg.SRS) error
+	InitKZG(srs kzg.SRS) error
 	NbPublicWitness() int // number of elements expected in the public witness
 }
 
 // Setup prepares the public data associated to a circuit + public inputs.
-func Setup(ccs frontend.CompiledConstraintSystem, publicWitness *witness.Witness) (ProvingKey, VerifyingKey, error) {
+//
+// It first sets up the MPI world of the process from the cluster configuration c, if needed
+// (see cluster.Config.Init).
+func Setup(ccs frontend.CompiledConstraintSystem, c *cluster.Config, publicWitness *witness.Witness) (ProvingKey, VerifyingKey, error) {
+	if err := c.Init(); err != nil {
+		return nil, nil, err
+	}
+	return SetupWithTransport(ccs, cluster.MPI(), publicWitness)
+}
 
+// SetupWithTransport is Setup, the party exchanging with the others through tr instead of the MPI
+// world of the process. It is called on every party.
+func SetupWithTransport(ccs frontend.CompiledConstraintSystem, tr cluster.Transport, publicWitness *witness.Witness) (ProvingKey, VerifyingKey, error) {
 	switch tccs := ccs.(type) {
 	case *cs_bn254.SparseR1CS:
 		w, ok := publicWitness.Vector.(*witness_bn254.Witness)
 		if !ok {
 			return nil, nil, witness.ErrInvalidWitness
 		}
-		return gpiano_bn254.Setup(tccs, *w)
+		return gpiano_bn254.Setup(tccs, tr, *w)
 	default:
 		panic("unimplemented")
 	}
@@ -65,8 +77,19 @@ func Setup(ccs frontend.CompiledConstraintSystem, publicWitness *witness.Witness
 // 	will executes all the prover computations, even if the witness is invalid
 //  will produce an invalid proof
 //	internally, the solution vector to the SparseR1CS will be filled with random values which may impact benchmarking
-func Prove(ccs frontend.CompiledConstraintSystem, pk ProvingKey, fullWitness *witness.Witness, opts ...backend.ProverOption) (Proof, error) {
+//
+// The parties are the ones of the cluster configuration c, given to Setup.
+func Prove(ccs frontend.CompiledConstraintSystem, c *cluster.Config, pk ProvingKey, fullWitness *witness.Witness, opts ...backend.ProverOption) (Proof, error) {
+	if err := c.Init(); err != nil {
+		return nil, err
+	}
+	return ProveWithTransport(ccs, cluster.MPI(), pk, fullWitness, opts...)
+}
 
+// ProveWithTransport is Prove, the party exchanging with the others through tr instead of the
+// MPI world of the process. It is called on every party, with the proving key of its Setup: the
+// proof is the one of the master.
+func ProveWithTransport(ccs frontend.CompiledConstraintSystem, tr cluster.Transport, pk ProvingKey, fullWitness *witness.Witness, opts ...backend.ProverOption) (Proof, error) {
 	// apply options
 	opt, err := backend.NewProverConfig(opts...)
 	if err != nil {
@@ -79,7 +102,7 @@ func Prove(ccs frontend.CompiledConstraintSystem, pk ProvingKey, fullWitness *wi
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
-		return gpiano_bn254.Prove(tccs, pk.(*gpiano_bn254.ProvingKey), *w, opt)
+		return gpiano_bn254.Prove(tccs, tr, pk.(*gpiano_bn254.ProvingKey), *w, opt)
 
 	default:
 		panic("unimplemented")
@@ -85,7 +85,8 @@ type ID uint32
 //	b[0] and b[1].
 type Function func(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error
 
-// UUID is a reference function for computing the hint ID based on a function name
+// UUID is a reference function for computing the hint ID based on a function name, or on its
+// stable identifier if it was registered with RegisterVersioned
 func UUID(fn Function) ID {
 	hf := fnv.New32a()
 	name := Name(fn)
@@ -97,7 +98,15 @@ func UUID(fn Function) ID {
 	return ID(hf.Sum32())
 }
 
+// Name returns the stable identifier of the hint if it was registered with RegisterVersioned,
+// and the name of the Go function otherwise.
 func Name(fn Function) string {
 	fnptr := reflect.ValueOf(fn).Pointer()
+	identifiersM.RLock()
+	id, ok := identifiers[fnptr]
+	identifiersM.RUnlock()
+	if ok {
+		return id
+	}
 	return runtime.FuncForPC(fnptr).Name()
 }
@@ -0,0 +1,111 @@
+package hint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ManifestVersion is the version of the encoding of Manifest.
+const ManifestVersion = 1
+
+// Manifest lists the hints needed to solve a compiled circuit. It is small and serializable
+// (in JSON), so that a prover can check that it knows all the hints of a circuit before
+// decoding or solving it.
+type Manifest struct {
+	Version int     `json:"version"`
+	Hints   []Entry `json:"hints"`
+}
+
+// Entry identifies a hint of a Manifest.
+type Entry struct {
+	ID   ID     `json:"id"`
+	Name string `json:"name"`
+}
+
+// NewManifest returns the manifest of the hints of a circuit, given as the hint dependencies
+// of a compiled constraint system (ID -> name). The hints are sorted by name.
+func NewManifest(dependencies map[ID]string) Manifest {
+	m := Manifest{Version: ManifestVersion, Hints: make([]Entry, 0, len(dependencies))}
+	for id, name := range dependencies {
+		m.Hints = append(m.Hints, Entry{ID: id, Name: name})
+	}
+	sort.Slice(m.Hints, func(i, j int) bool {
+		if m.Hints[i].Name != m.Hints[j].Name {
+			return m.Hints[i].Name < m.Hints[j].Name
+		}
+		return m.Hints[i].ID < m.Hints[j].ID
+	})
+	return m
+}
+
+// Equal reports whether m and other list the same hints, in the same version of the encoding.
+func (m Manifest) Equal(other Manifest) bool {
+	if m.Version != other.Version || len(m.Hints) != len(other.Hints) {
+		return false
+	}
+	for i := range m.Hints {
+		if m.Hints[i] != other.Hints[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Missing returns the names of the hints of the manifest which are not in functions, sorted.
+func (m Manifest) Missing(functions map[ID]Function) []string {
+	var res []string
+	for _, e := range m.Hints {
+		if _, ok := functions[e.ID]; !ok {
+			res = append(res, e.Name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Check returns a *MissingError if some hints of the manifest are not in functions.
+func (m Manifest) Check(functions map[ID]Function) error {
+	if m.Version != ManifestVersion {
+		return fmt.Errorf("unsupported hint manifest version %d", m.Version)
+	}
+	if missing := m.Missing(functions); len(missing) > 0 {
+		return &MissingError{Names: missing}
+	}
+	return nil
+}
+
+// Packages returns the Go packages defining the hints of the manifest (for the std gadgets,
+// the gadgets the circuit uses), sorted. The hints registered with RegisterVersioned under a
+// name without a package are not included.
+func (m Manifest) Packages() []string {
+	seen := make(map[string]struct{})
+	var res []string
+	for _, e := range m.Hints {
+		name := e.Name
+		if i := strings.LastIndexByte(name, '@'); i >= 0 {
+			name = name[:i]
+		}
+		i := strings.LastIndexByte(name, '/')
+		j := strings.IndexByte(name[i+1:], '.')
+		if j < 0 {
+			continue
+		}
+		pkg := name[:i+1+j]
+		if _, ok := seen[pkg]; !ok {
+			seen[pkg] = struct{}{}
+			res = append(res, pkg)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// MissingError is returned when some hints needed to solve a circuit are not available.
+type MissingError struct {
+	Names []string
+}
+
+func (e *MissingError) Error() string {
+	return fmt.Sprintf("missing hint(s): %s; register them (std.RegisterHints for the std gadgets) or give them with backend.WithHints", strings.Join(e.Names, ", "))
+}
@@ -1,6 +1,8 @@
 package hint
 
 import (
+	"fmt"
+	"reflect"
 	"sync"
 
 	"github.com/consensys/gnark/logger"
@@ -9,6 +11,10 @@ import (
 var registry = make(map[ID]Function)
 var registryM sync.RWMutex
 
+// stable identifiers of the hints registered with RegisterVersioned, by function pointer
+var identifiers = make(map[uintptr]string)
+var identifiersM sync.RWMutex
+
 // Register registers an hint function in the global registry.
 func Register(hintFn Function) {
 	registryM.Lock()
@@ -23,6 +29,25 @@ func Register(hintFn Function) {
 	registry[key] = hintFn
 }
 
+// RegisterVersioned registers an hint function in the global registry under the stable
+// identifier name@vversion (see Identifier), which then replaces the name of the Go function
+// in Name and UUID. The ID recorded in compiled circuits doesn't change when the function is
+// renamed or moved; the version must be bumped when its outputs change, so that circuits
+// compiled with the previous version fail to find it instead of being solved with the new one.
+//
+// It must be called before compiling the circuits using the hint, typically in an init function.
+func RegisterVersioned(hintFn Function, name string, version uint) {
+	identifiersM.Lock()
+	identifiers[reflect.ValueOf(hintFn).Pointer()] = Identifier(name, version)
+	identifiersM.Unlock()
+	Register(hintFn)
+}
+
+// Identifier returns the stable identifier of version version of the hint name.
+func Identifier(name string, version uint) string {
+	return fmt.Sprintf("%s@v%d", name, version)
+}
+
 // GetRegistered returns all registered hint functions.
 func GetRegistered() []Function {
 	registryM.RLock()
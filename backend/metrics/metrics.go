@@ -0,0 +1,233 @@
+// Package metrics records the rounds of the distributed provers (piano, gpiano) on every party,
+// and gathers them on the master in a Report.
+//
+// A Report is returned to the hook given with backend.WithProverMetrics. It is encoded in JSON
+// with encoding/json; the durations are in nanoseconds.
+package metrics
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	rtmetrics "runtime/metrics"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark/backend/cluster"
+)
+
+// Rounds of the distributed provers, in order. The master alone runs QuotientY and FinalOpening.
+const (
+	Solve           = "solve"
+	CommitLRO       = "commit-lro"
+	ComputeZ        = "z"
+	QuotientX       = "quotient-x"
+	PartialOpenings = "partial-openings"
+	QuotientY       = "quotient-y"
+	FinalOpening    = "final-opening"
+)
+
+// Round is a round of the prover on a party. The bytes are the ones exchanged with the other
+// parties.
+type Round struct {
+	Name          string        `json:"name"`
+	Duration      time.Duration `json:"durationNs"`
+	BytesSent     uint64        `json:"bytesSent"`
+	BytesReceived uint64        `json:"bytesReceived"`
+}
+
+// Party is the record of the prover on a party.
+type Party struct {
+	Rank          uint64        `json:"rank"`
+	Rounds        []Round       `json:"rounds"`
+	Duration      time.Duration `json:"durationNs"`
+	BytesSent     uint64        `json:"bytesSent"`
+	BytesReceived uint64        `json:"bytesReceived"`
+
+	// PeakMemory is the largest size of the heap objects sampled while proving, in bytes.
+	PeakMemory uint64 `json:"peakMemory"`
+}
+
+// Round returns the round of the given name, if the party ran it.
+func (p *Party) Round(name string) (Round, bool) {
+	for _, r := range p.Rounds {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Round{}, false
+}
+
+// Report is the record of a proof. On the master, Parties has a record for every party, ordered
+// by rank; on the other parties, it only has their own.
+type Report struct {
+	Backend       string  `json:"backend"`
+	NbConstraints int     `json:"nbConstraints"`
+	Parties       []Party `json:"parties"`
+}
+
+// Straggler returns the rank of the party which spent the most time in the given round, and this
+// time. ok is false if no party ran the round.
+func (r *Report) Straggler(round string) (rank uint64, d time.Duration, ok bool) {
+	for _, p := range r.Parties {
+		if pr, found := p.Round(round); found && (!ok || pr.Duration > d) {
+			rank, d, ok = p.Rank, pr.Duration, true
+		}
+	}
+	return
+}
+
+// sampleInterval is the interval between two samples of the size of the heap.
+const sampleInterval = 10 * time.Millisecond
+
+const heapObjects = "/memory/classes/heap/objects:bytes"
+
+// Recorder records the rounds of the prover of the party. A nil Recorder records nothing, so
+// that provers can call it unconditionally.
+type Recorder struct {
+	party Party
+	start time.Time
+	tr    *cluster.Counter
+
+	round              string
+	roundStart         time.Time
+	roundSent, roundRx uint64
+	sent, rx           uint64 // counters of tr at the start
+
+	lock sync.Mutex // protects party.PeakMemory
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRecorder returns a Recorder of the party of tr, which starts sampling the size of the heap.
+// The bytes exchanged are the ones counted by tr: the prover must exchange through it.
+func NewRecorder(tr *cluster.Counter) *Recorder {
+	r := &Recorder{
+		party: Party{Rank: tr.Rank()},
+		start: time.Now(),
+		tr:    tr,
+		sent:  tr.BytesSent(),
+		rx:    tr.BytesReceived(),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	r.sample()
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(sampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.sample()
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+	return r
+}
+
+func (r *Recorder) sample() {
+	s := []rtmetrics.Sample{{Name: heapObjects}}
+	rtmetrics.Read(s)
+	if s[0].Value.Kind() != rtmetrics.KindUint64 {
+		return
+	}
+	r.lock.Lock()
+	if v := s[0].Value.Uint64(); v > r.party.PeakMemory {
+		r.party.PeakMemory = v
+	}
+	r.lock.Unlock()
+}
+
+// Start ends the current round, if any, and starts the given one.
+func (r *Recorder) Start(round string) {
+	if r == nil {
+		return
+	}
+	r.End()
+	r.round = round
+	r.roundStart = time.Now()
+	r.roundSent, r.roundRx = r.tr.BytesSent(), r.tr.BytesReceived()
+}
+
+// End ends the current round, if any.
+func (r *Recorder) End() {
+	if r == nil || r.round == "" {
+		return
+	}
+	r.sample()
+	r.party.Rounds = append(r.party.Rounds, Round{
+		Name:          r.round,
+		Duration:      time.Since(r.roundStart),
+		BytesSent:     r.tr.BytesSent() - r.roundSent,
+		BytesReceived: r.tr.BytesReceived() - r.roundRx,
+	})
+	r.round = ""
+}
+
+// Stop ends the current round, stops the sampling and returns the record of the party. It may be
+// called several times, for instance deferred in case of error.
+func (r *Recorder) Stop() Party {
+	if r == nil {
+		return Party{}
+	}
+	select {
+	case <-r.stop:
+		return r.party
+	default:
+	}
+	r.End()
+	close(r.stop)
+	<-r.done
+
+	r.party.Duration = time.Since(r.start)
+	r.party.BytesSent = r.tr.BytesSent() - r.sent
+	r.party.BytesReceived = r.tr.BytesReceived() - r.rx
+	return r.party
+}
+
+// Send sends the record of the party to the master. The other parties call Send at the point of
+// the protocol the master calls Receive.
+func Send(tr cluster.Transport, p Party) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(data)))
+	if err := tr.SendBytes(size[:], 0); err != nil {
+		return err
+	}
+	return tr.SendBytes(data, 0)
+}
+
+// Receive receives the records sent by the other parties, on the master.
+func Receive(tr cluster.Transport) ([]Party, error) {
+	parties := make([]Party, 0, tr.Size())
+	for i := uint64(1); i < tr.Size(); i++ {
+		size, err := tr.ReceiveBytes(8, i)
+		if err != nil {
+			return nil, err
+		}
+		data, err := tr.ReceiveBytes(binary.LittleEndian.Uint64(size), i)
+		if err != nil {
+			return nil, err
+		}
+		var p Party
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("metrics of party %d: %w", i, err)
+		}
+		parties = append(parties, p)
+	}
+	return parties, nil
+}
+
+// NewReport returns the report of a proof made by the given parties.
+func NewReport(backend string, nbConstraints int, parties ...Party) *Report {
+	r := &Report{Backend: backend, NbConstraints: nbConstraints, Parties: parties}
+	sort.Slice(r.Parties, func(i, j int) bool { return r.Parties[i].Rank < r.Parties[j].Rank })
+	return r
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark/backend/cluster"
+	"github.com/consensys/gnark/internal/mpisim"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	assert := require.New(t)
+
+	// a nil recorder records nothing
+	var rec *Recorder
+	rec.Start(Solve)
+	rec.End()
+	assert.Equal(Party{}, rec.Stop())
+
+	// the master sends 10 bytes in CommitLRO and receives 3 in ComputeZ
+	world, err := mpisim.NewWorld(2)
+	assert.NoError(err)
+	var p, again Party
+	errs := world.Run(func(party *mpisim.Party) error {
+		if party.Rank() != 0 {
+			if _, err := party.ReceiveBytes(10, 0); err != nil {
+				return err
+			}
+			return party.SendBytes(make([]byte, 3), 0)
+		}
+		tr := cluster.NewCounter(party)
+		rec := NewRecorder(tr)
+		rec.Start(Solve)
+		time.Sleep(2 * time.Millisecond)
+		rec.Start(CommitLRO)
+		if err := tr.SendBytes(make([]byte, 10), 1); err != nil {
+			return err
+		}
+		rec.End()
+		rec.Start(ComputeZ)
+		if _, err := tr.ReceiveBytes(3, 1); err != nil {
+			return err
+		}
+		p, again = rec.Stop(), rec.Stop()
+		return nil
+	})
+	for _, err := range errs {
+		assert.NoError(err)
+	}
+	assert.Equal(p, again, "Stop may be called several times")
+
+	assert.Len(p.Rounds, 3)
+	for i, name := range []string{Solve, CommitLRO, ComputeZ} {
+		assert.Equal(name, p.Rounds[i].Name)
+	}
+	assert.Equal(uint64(10), p.Rounds[1].BytesSent)
+	assert.Zero(p.Rounds[1].BytesReceived)
+	assert.Zero(p.Rounds[2].BytesSent)
+	assert.Equal(uint64(3), p.Rounds[2].BytesReceived)
+	assert.Equal(uint64(10), p.BytesSent)
+	assert.Equal(uint64(3), p.BytesReceived)
+	assert.GreaterOrEqual(p.Rounds[0].Duration, 2*time.Millisecond)
+	assert.GreaterOrEqual(p.Duration, p.Rounds[0].Duration+p.Rounds[1].Duration+p.Rounds[2].Duration)
+	assert.NotZero(p.PeakMemory)
+
+	_, ok := p.Round(QuotientY)
+	assert.False(ok)
+}
+
+func TestReport(t *testing.T) {
+	assert := require.New(t)
+
+	parties := []Party{
+		{Rank: 2, Rounds: []Round{{Name: Solve, Duration: 3}, {Name: CommitLRO, Duration: 1}}},
+		{Rank: 0, Rounds: []Round{{Name: Solve, Duration: 1}, {Name: CommitLRO, Duration: 4}, {Name: QuotientY, Duration: 2}}},
+		{Rank: 1, Rounds: []Round{{Name: Solve, Duration: 2}, {Name: CommitLRO, Duration: 1}}},
+	}
+	report := NewReport("piano", 42, parties...)
+	for i, p := range report.Parties {
+		assert.Equal(uint64(i), p.Rank, "parties are ordered by rank")
+	}
+
+	for round, rank := range map[string]uint64{Solve: 2, CommitLRO: 0, QuotientY: 0} {
+		straggler, _, ok := report.Straggler(round)
+		assert.True(ok)
+		assert.Equal(rank, straggler, round)
+	}
+	_, _, ok := report.Straggler(FinalOpening)
+	assert.False(ok)
+
+	data, err := json.Marshal(report)
+	assert.NoError(err)
+	var decoded Report
+	assert.NoError(json.Unmarshal(data, &decoded))
+	assert.Equal(*report, decoded)
+}
+
+func TestSendReceive(t *testing.T) {
+	assert := require.New(t)
+
+	world, err := mpisim.NewWorld(4)
+	assert.NoError(err)
+	var received []Party
+	errs := world.Run(func(p *mpisim.Party) error {
+		if p.Rank() != 0 {
+			return Send(p, Party{Rank: p.Rank(), Rounds: []Round{{Name: Solve, Duration: time.Duration(p.Rank())}}})
+		}
+		var err error
+		received, err = Receive(p)
+		return err
+	})
+	for _, err := range errs {
+		assert.NoError(err)
+	}
+	assert.Len(received, 3)
+	for i, p := range received {
+		assert.Equal(uint64(i+1), p.Rank)
+		assert.Equal([]Round{{Name: Solve, Duration: time.Duration(i + 1)}}, p.Rounds)
+	}
+
+	// the master fails on the corrupted metrics of a party
+	world, err = mpisim.NewWorld(2, mpisim.WithFaults(mpisim.Fault{Kind: mpisim.Corrupt, From: 1, To: 0, Index: 1}))
+	assert.NoError(err)
+	errs = world.Run(func(p *mpisim.Party) error {
+		if p.Rank() != 0 {
+			return Send(p, Party{Rank: 1})
+		}
+		_, err := Receive(p)
+		return err
+	})
+	assert.NoError(errs[1])
+	assert.Error(errs[0])
+}
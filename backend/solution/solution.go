@@ -0,0 +1,475 @@
+// Package solution runs the solver of a compiled circuit alone, and exports the assignment of all
+// its wires. A prover given the solution with backend.WithSolution(s.Vector) checks it against the
+// constraints instead of running the solver.
+//
+// Binary protocol
+//
+//	Solution  ->  [uint32(nbPublic) | uint32(nbSecret) | uint32(nbInternal) | vector]
+//
+// where vector is encoded as a witness (see package witness), with the values of the wires in the
+// order of the solver: [public | secret | internal].
+package solution
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	fr_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	fr_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	fr_bls24315 "github.com/consensys/gnark-crypto/ecc/bls24-315/fr"
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	fr_bw6633 "github.com/consensys/gnark-crypto/ecc/bw6-633/fr"
+	fr_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+
+	cs_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/cs"
+	cs_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/cs"
+	cs_bls24315 "github.com/consensys/gnark/internal/backend/bls24-315/cs"
+	cs_bn254 "github.com/consensys/gnark/internal/backend/bn254/cs"
+	cs_bw6633 "github.com/consensys/gnark/internal/backend/bw6-633/cs"
+	cs_bw6761 "github.com/consensys/gnark/internal/backend/bw6-761/cs"
+
+	witness_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/witness"
+	witness_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/witness"
+	witness_bls24315 "github.com/consensys/gnark/internal/backend/bls24-315/witness"
+	witness_bn254 "github.com/consensys/gnark/internal/backend/bn254/witness"
+	witness_bw6633 "github.com/consensys/gnark/internal/backend/bw6-633/witness"
+	witness_bw6761 "github.com/consensys/gnark/internal/backend/bw6-761/witness"
+)
+
+var errUnsupported = errors.New("unsupported constraint system")
+
+// Solution is the assignment of the wires of a circuit.
+type Solution struct {
+	CurveID                        ecc.ID
+	NbPublic, NbSecret, NbInternal int
+
+	// Vector holds the values of the wires: [public | secret | internal]
+	Vector witness.Vector
+}
+
+// Solve runs the solver of ccs on the full witness, and returns the values of all the wires.
+func Solve(ccs frontend.CompiledConstraintSystem, fullWitness *witness.Witness, opts ...backend.ProverOption) (*Solution, error) {
+	opt, err := backend.NewProverConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	internal, secret, public := ccs.GetNbVariables()
+	s := &Solution{CurveID: ccs.CurveID(), NbPublic: public, NbSecret: secret, NbInternal: internal}
+
+	switch tccs := ccs.(type) {
+	case *cs_bn254.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bn254.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		n := len(tccs.Constraints)
+		v, err := tccs.Solve(*w, make(witness_bn254.Witness, n), make(witness_bn254.Witness, n), make(witness_bn254.Witness, n), opt)
+		if err != nil {
+			return nil, err
+		}
+		s.Vector = (*witness_bn254.Witness)(&v)
+	case *cs_bn254.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bn254.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		v, err := tccs.Solve(*w, opt)
+		if err != nil {
+			return nil, err
+		}
+		s.Vector = (*witness_bn254.Witness)(&v)
+	case *cs_bls12381.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12381.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		n := len(tccs.Constraints)
+		v, err := tccs.Solve(*w, make(witness_bls12381.Witness, n), make(witness_bls12381.Witness, n), make(witness_bls12381.Witness, n), opt)
+		if err != nil {
+			return nil, err
+		}
+		s.Vector = (*witness_bls12381.Witness)(&v)
+	case *cs_bls12381.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12381.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		v, err := tccs.Solve(*w, opt)
+		if err != nil {
+			return nil, err
+		}
+		s.Vector = (*witness_bls12381.Witness)(&v)
+	case *cs_bls12377.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12377.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		n := len(tccs.Constraints)
+		v, err := tccs.Solve(*w, make(witness_bls12377.Witness, n), make(witness_bls12377.Witness, n), make(witness_bls12377.Witness, n), opt)
+		if err != nil {
+			return nil, err
+		}
+		s.Vector = (*witness_bls12377.Witness)(&v)
+	case *cs_bls12377.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12377.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		v, err := tccs.Solve(*w, opt)
+		if err != nil {
+			return nil, err
+		}
+		s.Vector = (*witness_bls12377.Witness)(&v)
+	case *cs_bw6761.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6761.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		n := len(tccs.Constraints)
+		v, err := tccs.Solve(*w, make(witness_bw6761.Witness, n), make(witness_bw6761.Witness, n), make(witness_bw6761.Witness, n), opt)
+		if err != nil {
+			return nil, err
+		}
+		s.Vector = (*witness_bw6761.Witness)(&v)
+	case *cs_bw6761.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6761.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		v, err := tccs.Solve(*w, opt)
+		if err != nil {
+			return nil, err
+		}
+		s.Vector = (*witness_bw6761.Witness)(&v)
+	case *cs_bw6633.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6633.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		n := len(tccs.Constraints)
+		v, err := tccs.Solve(*w, make(witness_bw6633.Witness, n), make(witness_bw6633.Witness, n), make(witness_bw6633.Witness, n), opt)
+		if err != nil {
+			return nil, err
+		}
+		s.Vector = (*witness_bw6633.Witness)(&v)
+	case *cs_bw6633.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6633.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		v, err := tccs.Solve(*w, opt)
+		if err != nil {
+			return nil, err
+		}
+		s.Vector = (*witness_bw6633.Witness)(&v)
+	case *cs_bls24315.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls24315.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		n := len(tccs.Constraints)
+		v, err := tccs.Solve(*w, make(witness_bls24315.Witness, n), make(witness_bls24315.Witness, n), make(witness_bls24315.Witness, n), opt)
+		if err != nil {
+			return nil, err
+		}
+		s.Vector = (*witness_bls24315.Witness)(&v)
+	case *cs_bls24315.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bls24315.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		v, err := tccs.Solve(*w, opt)
+		if err != nil {
+			return nil, err
+		}
+		s.Vector = (*witness_bls24315.Witness)(&v)
+	default:
+		return nil, errUnsupported
+	}
+	return s, nil
+}
+
+// SolveBatch runs the solver of ccs on several full witnesses, such as the instances of a
+// data-parallel circuit (see frontend.CompileReplicated). The preprocessing of the constraint
+// system is shared between the instances, which are solved in parallel. Only PLONK constraint
+// systems are supported.
+//
+// It returns the solutions of the instances; errs[i] is the error of instance i, in which case
+// solutions[i] is nil. err is only set if the options or the constraint system are not supported.
+func SolveBatch(ccs frontend.CompiledConstraintSystem, witnesses []*witness.Witness, opts ...backend.ProverOption) (solutions []*Solution, errs []error, err error) {
+	opt, err := backend.NewProverConfig(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	invalid := make([]bool, len(witnesses))
+	vectors := make([]witness.Vector, len(witnesses))
+	errs = make([]error, len(witnesses))
+	switch tccs := ccs.(type) {
+	case *cs_bn254.SparseR1CS:
+		ws := make([][]fr_bn254.Element, len(witnesses))
+		for i := range witnesses {
+			if w, ok := witnesses[i].Vector.(*witness_bn254.Witness); ok {
+				ws[i] = *w
+			} else {
+				invalid[i] = true
+			}
+		}
+		values, solveErrs := tccs.SolveBatch(ws, opt)
+		for i := range values {
+			errs[i] = solveErrs[i]
+			if errs[i] == nil {
+				vectors[i] = (*witness_bn254.Witness)(&values[i])
+			}
+		}
+	case *cs_bls12381.SparseR1CS:
+		ws := make([][]fr_bls12381.Element, len(witnesses))
+		for i := range witnesses {
+			if w, ok := witnesses[i].Vector.(*witness_bls12381.Witness); ok {
+				ws[i] = *w
+			} else {
+				invalid[i] = true
+			}
+		}
+		values, solveErrs := tccs.SolveBatch(ws, opt)
+		for i := range values {
+			errs[i] = solveErrs[i]
+			if errs[i] == nil {
+				vectors[i] = (*witness_bls12381.Witness)(&values[i])
+			}
+		}
+	case *cs_bls12377.SparseR1CS:
+		ws := make([][]fr_bls12377.Element, len(witnesses))
+		for i := range witnesses {
+			if w, ok := witnesses[i].Vector.(*witness_bls12377.Witness); ok {
+				ws[i] = *w
+			} else {
+				invalid[i] = true
+			}
+		}
+		values, solveErrs := tccs.SolveBatch(ws, opt)
+		for i := range values {
+			errs[i] = solveErrs[i]
+			if errs[i] == nil {
+				vectors[i] = (*witness_bls12377.Witness)(&values[i])
+			}
+		}
+	case *cs_bw6761.SparseR1CS:
+		ws := make([][]fr_bw6761.Element, len(witnesses))
+		for i := range witnesses {
+			if w, ok := witnesses[i].Vector.(*witness_bw6761.Witness); ok {
+				ws[i] = *w
+			} else {
+				invalid[i] = true
+			}
+		}
+		values, solveErrs := tccs.SolveBatch(ws, opt)
+		for i := range values {
+			errs[i] = solveErrs[i]
+			if errs[i] == nil {
+				vectors[i] = (*witness_bw6761.Witness)(&values[i])
+			}
+		}
+	case *cs_bw6633.SparseR1CS:
+		ws := make([][]fr_bw6633.Element, len(witnesses))
+		for i := range witnesses {
+			if w, ok := witnesses[i].Vector.(*witness_bw6633.Witness); ok {
+				ws[i] = *w
+			} else {
+				invalid[i] = true
+			}
+		}
+		values, solveErrs := tccs.SolveBatch(ws, opt)
+		for i := range values {
+			errs[i] = solveErrs[i]
+			if errs[i] == nil {
+				vectors[i] = (*witness_bw6633.Witness)(&values[i])
+			}
+		}
+	case *cs_bls24315.SparseR1CS:
+		ws := make([][]fr_bls24315.Element, len(witnesses))
+		for i := range witnesses {
+			if w, ok := witnesses[i].Vector.(*witness_bls24315.Witness); ok {
+				ws[i] = *w
+			} else {
+				invalid[i] = true
+			}
+		}
+		values, solveErrs := tccs.SolveBatch(ws, opt)
+		for i := range values {
+			errs[i] = solveErrs[i]
+			if errs[i] == nil {
+				vectors[i] = (*witness_bls24315.Witness)(&values[i])
+			}
+		}
+	default:
+		return nil, nil, errUnsupported
+	}
+
+	internal, secret, public := ccs.GetNbVariables()
+	solutions = make([]*Solution, len(witnesses))
+	for i := range witnesses {
+		if invalid[i] {
+			errs[i] = witness.ErrInvalidWitness
+			continue
+		}
+		if errs[i] == nil {
+			solutions[i] = &Solution{CurveID: ccs.CurveID(), NbPublic: public, NbSecret: secret, NbInternal: internal, Vector: vectors[i]}
+		}
+	}
+	return solutions, errs, nil
+}
+
+// WriteTo writes the binary encoding of the solution to w.
+func (s *Solution) WriteTo(w io.Writer) (int64, error) {
+	if s.Vector == nil {
+		return 0, errors.New("empty solution")
+	}
+	var header [12]byte
+	binary.BigEndian.PutUint32(header[0:4], uint32(s.NbPublic))
+	binary.BigEndian.PutUint32(header[4:8], uint32(s.NbSecret))
+	binary.BigEndian.PutUint32(header[8:12], uint32(s.NbInternal))
+	n, err := w.Write(header[:])
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := s.Vector.WriteTo(w)
+	return int64(n) + m, err
+}
+
+// ReadFrom reads a solution encoded with WriteTo. s.CurveID must be set.
+func (s *Solution) ReadFrom(r io.Reader) (int64, error) {
+	var header [12]byte
+	n, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return int64(n), err
+	}
+	s.NbPublic = int(binary.BigEndian.Uint32(header[0:4]))
+	s.NbSecret = int(binary.BigEndian.Uint32(header[4:8]))
+	s.NbInternal = int(binary.BigEndian.Uint32(header[8:12]))
+
+	w, err := witness.New(s.CurveID, nil)
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := w.Vector.ReadFrom(r)
+	if err != nil {
+		return int64(n) + m, err
+	}
+	if nbWires := s.NbPublic + s.NbSecret + s.NbInternal; w.Vector.Len() != nbWires {
+		return int64(n) + m, fmt.Errorf("invalid solution: got %d wires, expected %d", w.Vector.Len(), nbWires)
+	}
+	s.Vector = w.Vector
+	return int64(n) + m, nil
+}
+
+// LRO returns the values of the left, right and output wires of the rows of party rank of
+// nbParties, for ccs, a SparseR1CS, in the layout the piano and gpiano provers commit to. On
+// padding and placeholder rows, the unused wires take the value of the first wire.
+//
+// The rows of ccs are the public inputs (as placeholder rows), then the constraints. With
+// nbParties = 1, they are the layout of piano, where every party runs LRO on the solution of its
+// own sub-circuit: all the rows, then padding up to a power of 2. With nbParties > 1, they are the
+// layout of gpiano, where the parties share the rows of a single circuit: the party gets the
+// rows [rank·n, (rank+1)·n), n being the size of the domain of a party, then padding. Only the
+// party of rank 0 holds the placeholders.
+func LRO(ccs frontend.CompiledConstraintSystem, s *Solution, rank, nbParties int) (l, r, o witness.Vector, err error) {
+	spr, err := sparseR1CS(ccs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if nbWires := spr.NbPublicVariables + spr.NbSecretVariables + spr.NbInternalVariables; s.Vector.Len() != nbWires {
+		return nil, nil, nil, fmt.Errorf("invalid solution: got %d wires, expected %d", s.Vector.Len(), nbWires)
+	}
+	if nbParties < 1 || rank < 0 || rank >= nbParties {
+		return nil, nil, nil, fmt.Errorf("invalid party %d of %d", rank, nbParties)
+	}
+
+	// the size of the domain of a party, as in the setup of gpiano
+	nbRows := spr.NbPublicVariables + len(spr.Constraints)
+	sizeSystem := (nbRows + nbParties - 1) / nbParties
+	if spr.NbParties != 0 && nbParties > 1 {
+		if spr.NbParties != nbParties {
+			return nil, nil, nil, fmt.Errorf("the constraints are placed on %d parties, got %d", spr.NbParties, nbParties)
+		}
+		sizeSystem = spr.NbRowsPerParty()
+	}
+	if sizeSystem < spr.NbPublicVariables {
+		return nil, nil, nil, errors.New("public variables not in a single sub-circuit")
+	}
+	n := int(ecc.NextPowerOfTwo(uint64(sizeSystem)))
+
+	var buf bytes.Buffer
+	if _, err := s.Vector.WriteTo(&buf); err != nil {
+		return nil, nil, nil, err
+	}
+	values := buf.Bytes()[4:]
+	size := len(values) / s.Vector.Len()
+
+	wires := func(wire func(c compiled.SparseR1C) int, placeholder bool) (witness.Vector, error) {
+		data := make([]byte, 4+n*size)
+		binary.BigEndian.PutUint32(data[:4], uint32(n))
+		row := data[4:]
+		set := func(i, wireID int) {
+			copy(row[i*size:(i+1)*size], values[wireID*size:(wireID+1)*size])
+		}
+		for i := 0; i < n; i++ {
+			// global row of the i-th row of the party
+			switch g := rank*n + i; {
+			case g < spr.NbPublicVariables && placeholder:
+				set(i, g)
+			case spr.NbPublicVariables <= g && g < nbRows:
+				set(i, wire(spr.Constraints[g-spr.NbPublicVariables]))
+			default:
+				set(i, 0)
+			}
+		}
+
+		w, err := witness.New(s.CurveID, nil)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Vector.ReadFrom(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		return w.Vector, nil
+	}
+
+	if l, err = wires(func(c compiled.SparseR1C) int { return c.L.WireID() }, true); err != nil {
+		return nil, nil, nil, err
+	}
+	if r, err = wires(func(c compiled.SparseR1C) int { return c.R.WireID() }, false); err != nil {
+		return nil, nil, nil, err
+	}
+	if o, err = wires(func(c compiled.SparseR1C) int { return c.O.WireID() }, false); err != nil {
+		return nil, nil, nil, err
+	}
+	return l, r, o, nil
+}
+
+func sparseR1CS(ccs frontend.CompiledConstraintSystem) (*compiled.SparseR1CS, error) {
+	switch tccs := ccs.(type) {
+	case *cs_bn254.SparseR1CS:
+		return &tccs.SparseR1CS, nil
+	case *cs_bls12381.SparseR1CS:
+		return &tccs.SparseR1CS, nil
+	case *cs_bls12377.SparseR1CS:
+		return &tccs.SparseR1CS, nil
+	case *cs_bw6761.SparseR1CS:
+		return &tccs.SparseR1CS, nil
+	case *cs_bw6633.SparseR1CS:
+		return &tccs.SparseR1CS, nil
+	case *cs_bls24315.SparseR1CS:
+		return &tccs.SparseR1CS, nil
+	default:
+		return nil, fmt.Errorf("%w: the rows of a %T are not defined", errUnsupported, ccs)
+	}
+}
@@ -0,0 +1,263 @@
+package solution
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	cs_bn254 "github.com/consensys/gnark/internal/backend/bn254/cs"
+	witness_bn254 "github.com/consensys/gnark/internal/backend/bn254/witness"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+type cubic struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// Define declares x**3 + x + 5 == y
+func (c *cubic) Define(api frontend.API) error {
+	x3 := api.Mul(c.X, c.X, c.X)
+	api.AssertIsEqual(c.Y, api.Add(x3, c.X, 5))
+	return nil
+}
+
+func TestSolve(t *testing.T) {
+	assert := require.New(t)
+
+	w, err := frontend.NewWitness(&cubic{X: 3, Y: 35}, ecc.BN254)
+	assert.NoError(err)
+
+	for _, builder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254, builder, &cubic{})
+		assert.NoError(err)
+
+		s, err := Solve(ccs, w)
+		assert.NoError(err)
+		internal, secret, public := ccs.GetNbVariables()
+		assert.Equal(Solution{CurveID: ecc.BN254, NbPublic: public, NbSecret: secret, NbInternal: internal, Vector: s.Vector}, *s)
+		assert.Equal(public+secret+internal, s.Vector.Len())
+
+		// the inputs come first: [one | Y | X] for r1cs, [Y | X] for scs
+		values := *s.Vector.(*witness_bn254.Witness)
+		assert.Equal(uint64(35), values[public-1].Uint64())
+		assert.Equal(uint64(3), values[public].Uint64())
+
+		var buf bytes.Buffer
+		_, err = s.WriteTo(&buf)
+		assert.NoError(err)
+		read := Solution{CurveID: ecc.BN254}
+		_, err = read.ReadFrom(&buf)
+		assert.NoError(err)
+		assert.Equal(*s, read)
+	}
+
+	// unsatisfied
+	bad, err := frontend.NewWitness(&cubic{X: 3, Y: 36}, ecc.BN254)
+	assert.NoError(err)
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &cubic{})
+	assert.NoError(err)
+	_, err = Solve(ccs, bad)
+	assert.Error(err)
+}
+
+func TestWithSolution(t *testing.T) {
+	assert := require.New(t)
+
+	w, err := frontend.NewWitness(&cubic{X: 3, Y: 35}, ecc.BN254)
+	assert.NoError(err)
+
+	for _, builder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254, builder, &cubic{})
+		assert.NoError(err)
+		s, err := Solve(ccs, w)
+		assert.NoError(err)
+		assert.NoError(ccs.IsSolved(w, backend.WithSolution(s.Vector)))
+
+		// an internal wire is wrong
+		values := *s.Vector.(*witness_bn254.Witness)
+		tampered := make(witness_bn254.Witness, len(values))
+		copy(tampered, values)
+		tampered[len(tampered)-1].SetUint64(42)
+		assert.Error(ccs.IsSolved(w, backend.WithSolution(&tampered)))
+
+		// the solution of another witness
+		other, err := frontend.NewWitness(&cubic{X: 2, Y: 15}, ecc.BN254)
+		assert.NoError(err)
+		assert.NoError(ccs.IsSolved(other))
+		err = ccs.IsSolved(other, backend.WithSolution(s.Vector))
+		assert.Error(err)
+		assert.Contains(err.Error(), "differs from the witness")
+
+		// wrong size
+		short := tampered[:len(tampered)-1]
+		assert.Error(ccs.IsSolved(w, backend.WithSolution(&short)))
+	}
+
+	// groth16 proves with the given solution
+	ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &cubic{})
+	assert.NoError(err)
+	s, err := Solve(ccs, w)
+	assert.NoError(err)
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+	proof, err := groth16.Prove(ccs, pk, w, backend.WithSolution(s.Vector))
+	assert.NoError(err)
+	public, err := w.Public()
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, vk, public))
+}
+
+func TestSolveBatch(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &cubic{})
+	assert.NoError(err)
+
+	// enough instances to be solved concurrently, one of them unsatisfied
+	witnesses := make([]*witness.Witness, 2*runtime.NumCPU()+1)
+	for i := range witnesses {
+		x := i + 1
+		y := x*x*x + x + 5
+		if i == 3 {
+			y++
+		}
+		witnesses[i], err = frontend.NewWitness(&cubic{X: x, Y: y}, ecc.BN254)
+		assert.NoError(err)
+	}
+
+	for _, batch := range [][]*witness.Witness{witnesses, witnesses[:2]} {
+		solutions, errs, err := SolveBatch(ccs, batch)
+		assert.NoError(err)
+		assert.Len(solutions, len(batch))
+		for i := range batch {
+			if i == 3 {
+				assert.Error(errs[i])
+				assert.Nil(solutions[i])
+				continue
+			}
+			assert.NoError(errs[i])
+			expected, err := Solve(ccs, batch[i])
+			assert.NoError(err)
+			assert.Equal(expected, solutions[i])
+		}
+	}
+
+	// r1cs are not supported
+	rccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &cubic{})
+	assert.NoError(err)
+	_, _, err = SolveBatch(rccs, witnesses)
+	assert.Error(err)
+}
+
+// printCubic prints x while declaring x**3 + x + 5 == y
+type printCubic struct {
+	cubic
+}
+
+func (c *printCubic) Define(api frontend.API) error {
+	api.Println("x", c.X)
+	return c.cubic.Define(api)
+}
+
+func TestSolveBatchLogs(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &printCubic{})
+	assert.NoError(err)
+	witnesses := make([]*witness.Witness, 2*runtime.NumCPU()+1)
+	for i := range witnesses {
+		x := i + 1
+		witnesses[i], err = frontend.NewWitness(&printCubic{cubic{X: x, Y: x*x*x + x + 5}}, ecc.BN254)
+		assert.NoError(err)
+	}
+
+	// the instances are solved concurrently and share the logger, which isn't safe for
+	// concurrent use: the logs must not interleave
+	var buf bytes.Buffer
+	_, errs, err := SolveBatch(ccs, witnesses, backend.WithCircuitLogger(zerolog.New(&buf)))
+	assert.NoError(err)
+	for i := range errs {
+		assert.NoError(errs[i])
+	}
+
+	seen := make(map[int]bool)
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var line struct {
+			Instance int    `json:"instance"`
+			Message  string `json:"message"`
+		}
+		assert.NoError(json.Unmarshal(scanner.Bytes(), &line), scanner.Text())
+		assert.False(seen[line.Instance], "instance %d logged twice", line.Instance)
+		seen[line.Instance] = true
+		assert.Equal(fmt.Sprintf("x %d", line.Instance+1), line.Message)
+	}
+	assert.Len(seen, len(witnesses))
+}
+
+func TestLRO(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &cubic{})
+	assert.NoError(err)
+	w, err := frontend.NewWitness(&cubic{X: 3, Y: 35}, ecc.BN254)
+	assert.NoError(err)
+	s, err := Solve(ccs, w)
+	assert.NoError(err)
+
+	l, r, o, err := LRO(ccs, s, 0, 1)
+	assert.NoError(err)
+
+	spr := ccs.(*cs_bn254.SparseR1CS)
+	nbRows := int(ecc.NextPowerOfTwo(uint64(spr.NbPublicVariables + len(spr.Constraints))))
+	values := *s.Vector.(*witness_bn254.Witness)
+	lv, rv, ov := *l.(*witness_bn254.Witness), *r.(*witness_bn254.Witness), *o.(*witness_bn254.Witness)
+	assert.Len(lv, nbRows)
+	assert.Len(rv, nbRows)
+	assert.Len(ov, nbRows)
+
+	// every row satisfies qL.l + qR.r + qM.l.r + qO.o + qC == 0
+	offset := spr.NbPublicVariables
+	assert.Equal(values[0], lv[0], "placeholder of the public input")
+	for i, c := range spr.Constraints {
+		var res, t fr.Element
+		t.Mul(&spr.Coefficients[c.L.CoeffID()], &lv[offset+i])
+		res.Add(&res, &t)
+		t.Mul(&spr.Coefficients[c.R.CoeffID()], &rv[offset+i])
+		res.Add(&res, &t)
+		t.Mul(&lv[offset+i], &rv[offset+i]).Mul(&t, &spr.Coefficients[c.M[0].CoeffID()]).Mul(&t, &spr.Coefficients[c.M[1].CoeffID()])
+		res.Add(&res, &t)
+		t.Mul(&spr.Coefficients[c.O.CoeffID()], &ov[offset+i])
+		res.Add(&res, &t)
+		res.Add(&res, &spr.Coefficients[c.K])
+		assert.True(res.IsZero(), "row %d", offset+i)
+	}
+	for i := offset + len(spr.Constraints); i < nbRows; i++ {
+		assert.Equal(values[0], lv[i], "padding")
+	}
+
+	// rows are only defined for a SparseR1CS
+	rcs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &cubic{})
+	assert.NoError(err)
+	_, _, _, err = LRO(rcs, s, 0, 1)
+	assert.Error(err)
+
+	// the rank must be one of the parties
+	_, _, _, err = LRO(ccs, s, 2, 2)
+	assert.Error(err)
+	_, _, _, err = LRO(ccs, s, 0, 0)
+	assert.Error(err)
+}
@@ -0,0 +1,426 @@
+package witness
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+// maxReportedMissing is the number of paths of missing values listed by ReadJSON.
+const maxReportedMissing = 10
+
+// ReadJSON reads a witness encoded in JSON from r, as a stream: the values are decoded one by one
+// into the vector, without building the document in memory.
+//
+// The document is an object whose keys are the names of the fields of the schema (the gnark tag
+// name, or the Go name; like encoding/json, a key may differ in case), nested arrays and
+// objects following the arrays and structs of the circuit. A value is a JSON number or a string
+// in a base accepted by big.Int.SetString with base 0 ("0x..."), in [0, r) where r is the modulus
+// of the scalar field. A null value is not set.
+//
+// Unknown keys, arrays of the wrong size, values out of range and values set twice are errors,
+// reported with their path (for instance "siblings1[2][16]"). If only the public values are set,
+// ReadJSON reads a public witness; otherwise every value must be set, and the error lists the
+// missing ones.
+func (w *Witness) ReadJSON(r io.Reader) error {
+	if w.Schema == nil {
+		return errMissingSchema
+	}
+	v, err := newVector(w.CurveID)
+	if err != nil {
+		return err
+	}
+
+	modulus := w.CurveID.ScalarField()
+	n := w.Schema.NbPublic + w.Schema.NbSecret
+	jr := &jsonReader{
+		dec:      json.NewDecoder(r),
+		modulus:  modulus,
+		size:     (modulus.BitLen() + 7) / 8,
+		nbPublic: w.Schema.NbPublic,
+		counts:   make(map[*schema.Field]leafCount),
+		set:      make([]bool, n),
+	}
+	jr.dec.UseNumber()
+	jr.buf = make([]byte, 4+n*jr.size)
+
+	root := schema.Field{Type: schema.Struct, SubFields: w.Schema.Fields}
+	if err := jr.read(&root, leafCount{}, ""); err != nil {
+		return err
+	}
+	if _, err := jr.dec.Token(); err != io.EOF {
+		return fmt.Errorf("%w: unexpected data after the witness", ErrInvalidWitness)
+	}
+
+	// full witness, or public witness if no secret value is set
+	var nbMissing, nbSecretSet int
+	for i, ok := range jr.set {
+		if !ok {
+			nbMissing++
+		} else if i >= jr.nbPublic {
+			nbSecretSet++
+		}
+	}
+	if nbMissing != 0 {
+		if nbSecretSet != 0 || nbMissing != w.Schema.NbSecret {
+			return jr.missing(&root, nbSecretSet == 0)
+		}
+		n = w.Schema.NbPublic
+	}
+
+	binary.BigEndian.PutUint32(jr.buf[:4], uint32(n))
+	if _, err := v.ReadFrom(bytes.NewReader(jr.buf[:4+n*jr.size])); err != nil {
+		return err
+	}
+	w.Vector = v
+	return nil
+}
+
+// WriteJSON writes the witness in JSON to wr, in the format read by ReadJSON, as a stream. Values
+// are numbers, or decimal strings if they have more than 15 digits. A public witness only has the
+// public values.
+func (w *Witness) WriteJSON(wr io.Writer) error {
+	if w.Schema == nil {
+		return errMissingSchema
+	}
+	if w.Vector == nil {
+		return fmt.Errorf("%w: empty witness", ErrInvalidWitness)
+	}
+	n := w.Vector.Len()
+	nbPublic, nbSecret := w.Schema.NbPublic, w.Schema.NbSecret
+	if n != nbPublic && n != nbPublic+nbSecret {
+		return fmt.Errorf("%w: got %d elements, expected either %d (public) or %d (full)", ErrInvalidWitness, n, nbPublic, nbPublic+nbSecret)
+	}
+
+	var buf bytes.Buffer
+	if _, err := w.Vector.WriteTo(&buf); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(wr)
+	jw := &jsonWriter{
+		w:          bw,
+		values:     buf.Bytes()[4:],
+		size:       (w.CurveID.ScalarField().BitLen() + 7) / 8,
+		nbPublic:   nbPublic,
+		publicOnly: n == nbPublic,
+		counts:     make(map[*schema.Field]leafCount),
+	}
+	root := schema.Field{Type: schema.Struct, SubFields: w.Schema.Fields}
+	if err := jw.write(&root, leafCount{}); err != nil {
+		return err
+	}
+	if err := bw.WriteByte('\n'); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// leafCount counts public and secret leaves. It is also used as the offset of a value in the
+// public and secret parts of the vector.
+type leafCount struct {
+	public, secret int
+}
+
+func (c leafCount) add(o leafCount, times int) leafCount {
+	return leafCount{c.public + times*o.public, c.secret + times*o.secret}
+}
+
+// count returns the number of leaves of f, memoized in counts.
+func count(f *schema.Field, counts map[*schema.Field]leafCount) leafCount {
+	if f.Type == schema.Leaf {
+		if f.Visibility == schema.Public {
+			return leafCount{public: 1}
+		}
+		return leafCount{secret: 1}
+	}
+	if c, ok := counts[f]; ok {
+		return c
+	}
+	var c leafCount
+	switch f.Type {
+	case schema.Array:
+		c = c.add(count(elementOf(f), counts), f.ArraySize)
+	case schema.Struct:
+		for i := range f.SubFields {
+			c = c.add(count(&f.SubFields[i], counts), 1)
+		}
+	}
+	counts[f] = c
+	return c
+}
+
+// elementOf returns the field describing the elements of the array f.
+func elementOf(f *schema.Field) *schema.Field {
+	if len(f.SubFields) == 0 {
+		return &schema.Field{Type: schema.Leaf, Visibility: f.Visibility}
+	}
+	return &f.SubFields[0]
+}
+
+// describe returns path, or "witness" for the root.
+func describe(path string) string {
+	if path == "" {
+		return "witness"
+	}
+	return path
+}
+
+func jsonName(f *schema.Field) string {
+	if f.NameTag != "" {
+		return f.NameTag
+	}
+	return f.Name
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+type jsonReader struct {
+	dec      *json.Decoder
+	modulus  *big.Int
+	size     int // size of an element in buf
+	nbPublic int
+	counts   map[*schema.Field]leafCount
+
+	buf []byte // binary encoding of the vector
+	set []bool
+	v   big.Int
+}
+
+// index returns the index in the vector of the leaf f at offset off.
+func (jr *jsonReader) index(f *schema.Field, off leafCount) int {
+	if f.Visibility == schema.Public {
+		return off.public
+	}
+	return jr.nbPublic + off.secret
+}
+
+// read reads the value of f, at offset off.
+func (jr *jsonReader) read(f *schema.Field, off leafCount, path string) error {
+	tok, err := jr.dec.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidWitness, describe(path), err)
+	}
+	if tok == nil {
+		return nil
+	}
+
+	switch f.Type {
+	case schema.Leaf:
+		return jr.readLeaf(tok, jr.index(f, off), path)
+	case schema.Array:
+		if tok != json.Delim('[') {
+			return fmt.Errorf("%w: %s: expected an array of %d elements", ErrInvalidWitness, path, f.ArraySize)
+		}
+		e := elementOf(f)
+		ec := count(e, jr.counts)
+		j := 0
+		for ; jr.dec.More(); j++ {
+			if j == f.ArraySize {
+				return fmt.Errorf("%w: %s: more than %d elements", ErrInvalidWitness, path, f.ArraySize)
+			}
+			if err := jr.read(e, off.add(ec, j), path+"["+strconv.Itoa(j)+"]"); err != nil {
+				return err
+			}
+		}
+		if j != f.ArraySize {
+			return fmt.Errorf("%w: %s: got %d elements, expected %d", ErrInvalidWitness, path, j, f.ArraySize)
+		}
+	case schema.Struct:
+		if tok != json.Delim('{') {
+			return fmt.Errorf("%w: %s: expected an object", ErrInvalidWitness, describe(path))
+		}
+		for jr.dec.More() {
+			tok, err := jr.dec.Token()
+			if err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrInvalidWitness, describe(path), err)
+			}
+			key := tok.(string)
+			i := jr.lookup(f.SubFields, key)
+			if i < 0 {
+				return fmt.Errorf("%w: unknown field %s", ErrInvalidWitness, joinPath(path, key))
+			}
+			fOff := off
+			for k := 0; k < i; k++ {
+				fOff = fOff.add(count(&f.SubFields[k], jr.counts), 1)
+			}
+			if err := jr.read(&f.SubFields[i], fOff, joinPath(path, key)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// closing delimiter
+	if _, err := jr.dec.Token(); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidWitness, describe(path), err)
+	}
+	return nil
+}
+
+// lookup returns the index of the field named key, or -1.
+func (jr *jsonReader) lookup(fields []schema.Field, key string) int {
+	match := -1
+	for i := range fields {
+		name := jsonName(&fields[i])
+		if name == key {
+			return i
+		}
+		if match < 0 && strings.EqualFold(name, key) {
+			match = i
+		}
+	}
+	return match
+}
+
+func (jr *jsonReader) readLeaf(tok json.Token, i int, path string) error {
+	var ok bool
+	switch t := tok.(type) {
+	case json.Number:
+		_, ok = jr.v.SetString(string(t), 10)
+	case string:
+		_, ok = jr.v.SetString(t, 0)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s: expected a number or a string, got %v", ErrInvalidWitness, path, tok)
+	}
+	if jr.v.Sign() < 0 || jr.v.Cmp(jr.modulus) >= 0 {
+		return fmt.Errorf("%w: %s: %s is not in the scalar field", ErrInvalidWitness, path, tok)
+	}
+	if jr.set[i] {
+		return fmt.Errorf("%w: %s: set twice", ErrInvalidWitness, path)
+	}
+	jr.set[i] = true
+	jr.v.FillBytes(jr.buf[4+i*jr.size : 4+(i+1)*jr.size])
+	return nil
+}
+
+// missing returns the error listing the values which are not set, only the public ones if
+// publicOnly is set.
+func (jr *jsonReader) missing(root *schema.Field, publicOnly bool) error {
+	var paths []string
+	nbMissing := 0
+	var walk func(f *schema.Field, off leafCount, path string)
+	walk = func(f *schema.Field, off leafCount, path string) {
+		c := count(f, jr.counts)
+		if publicOnly && c.public == 0 {
+			return
+		}
+		switch f.Type {
+		case schema.Leaf:
+			if !jr.set[jr.index(f, off)] {
+				if nbMissing < maxReportedMissing {
+					paths = append(paths, path)
+				}
+				nbMissing++
+			}
+		case schema.Array:
+			e := elementOf(f)
+			ec := count(e, jr.counts)
+			for j := 0; j < f.ArraySize; j++ {
+				walk(e, off.add(ec, j), path+"["+strconv.Itoa(j)+"]")
+			}
+		case schema.Struct:
+			for i := range f.SubFields {
+				walk(&f.SubFields[i], off, joinPath(path, jsonName(&f.SubFields[i])))
+				off = off.add(count(&f.SubFields[i], jr.counts), 1)
+			}
+		}
+	}
+	walk(root, leafCount{}, "")
+
+	list := strings.Join(paths, ", ")
+	if nbMissing > len(paths) {
+		list += fmt.Sprintf(" (and %d more)", nbMissing-len(paths))
+	}
+	return fmt.Errorf("%w: missing values: %s", ErrInvalidWitness, list)
+}
+
+type jsonWriter struct {
+	w          *bufio.Writer
+	values     []byte // binary encoding of the elements of the vector
+	size       int
+	nbPublic   int
+	publicOnly bool
+	counts     map[*schema.Field]leafCount
+
+	v big.Int
+}
+
+// write writes the value of f, at offset off.
+func (jw *jsonWriter) write(f *schema.Field, off leafCount) error {
+	switch f.Type {
+	case schema.Leaf:
+		i := off.public
+		if f.Visibility != schema.Public {
+			i = jw.nbPublic + off.secret
+		}
+		jw.v.SetBytes(jw.values[i*jw.size : (i+1)*jw.size])
+		s := jw.v.Text(10)
+		if len(s) > 15 {
+			s = `"` + s + `"`
+		}
+		_, err := jw.w.WriteString(s)
+		return err
+	case schema.Array:
+		e := elementOf(f)
+		ec := count(e, jw.counts)
+		if err := jw.w.WriteByte('['); err != nil {
+			return err
+		}
+		for j := 0; j < f.ArraySize; j++ {
+			if j > 0 {
+				if err := jw.w.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			if err := jw.write(e, off.add(ec, j)); err != nil {
+				return err
+			}
+		}
+		return jw.w.WriteByte(']')
+	default:
+		if err := jw.w.WriteByte('{'); err != nil {
+			return err
+		}
+		first := true
+		for i := range f.SubFields {
+			sub := &f.SubFields[i]
+			c := count(sub, jw.counts)
+			if !jw.publicOnly || c.public != 0 {
+				if !first {
+					if err := jw.w.WriteByte(','); err != nil {
+						return err
+					}
+				}
+				first = false
+				key, err := json.Marshal(jsonName(sub))
+				if err != nil {
+					return err
+				}
+				if _, err := jw.w.Write(key); err != nil {
+					return err
+				}
+				if err := jw.w.WriteByte(':'); err != nil {
+					return err
+				}
+				if err := jw.write(sub, off); err != nil {
+					return err
+				}
+			}
+			off = off.add(c, 1)
+		}
+		return jw.w.WriteByte('}')
+	}
+}
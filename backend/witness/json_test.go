@@ -0,0 +1,111 @@
+package witness
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/stretchr/testify/require"
+)
+
+type leaf struct {
+	Ay      *fr.Element `gnark:"ay,public"`
+	Balance *fr.Element `gnark:"balance"`
+}
+
+type nestedCircuit struct {
+	Root     *fr.Element       `gnark:"oldStateRoot,public"`
+	Siblings [2][3]*fr.Element `gnark:"siblings"`
+	Leaves   [2]leaf           // mixed visibility: no tag
+	Fee      *fr.Element
+}
+
+func newNested(assert *require.Assertions) *Witness {
+	var c nestedCircuit
+	var k int64
+	next := func() *fr.Element { k++; return new(fr.Element).SetInt64(k) }
+	c.Root = new(fr.Element).SetUint64(1 << 63)
+	c.Root.Square(c.Root)
+	for i := range c.Siblings {
+		for j := range c.Siblings[i] {
+			c.Siblings[i][j] = next()
+		}
+	}
+	for i := range c.Leaves {
+		c.Leaves[i] = leaf{Ay: next(), Balance: next()}
+	}
+	c.Fee = next()
+
+	w, err := New(ecc.BN254, nil)
+	assert.NoError(err)
+	w.Schema, err = w.Vector.FromAssignment(&c, tVariable, false)
+	assert.NoError(err)
+	return w
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	assert := require.New(t)
+	w := newNested(assert)
+
+	var buf bytes.Buffer
+	assert.NoError(w.WriteJSON(&buf))
+	assert.Equal(`{"oldStateRoot":"85070591730234615865843651857942052864","siblings":[[1,2,3],[4,5,6]],"Leaves":[{"ay":7,"balance":8},{"ay":9,"balance":10}],"Fee":11}`+"\n", buf.String())
+
+	read := Witness{CurveID: ecc.BN254, Schema: w.Schema}
+	assert.NoError(read.ReadJSON(&buf))
+	assert.Equal(w.Vector, read.Vector)
+
+	// same as MarshalJSON, in any order, with strings and case-insensitive keys
+	data, err := w.MarshalJSON()
+	assert.NoError(err)
+	assert.NoError(read.ReadJSON(bytes.NewReader(data)))
+	assert.Equal(w.Vector, read.Vector)
+	assert.NoError(read.ReadJSON(strings.NewReader(`{"fee":"0xb","leaves":[{"balance":8,"ay":7},{"ay":"9","balance":10}],
+		"siblings":[[1,2,3],[4,5,6]],"oldStateRoot":"85070591730234615865843651857942052864"}`)))
+	assert.Equal(w.Vector, read.Vector)
+
+	// public witness
+	public, err := w.Public()
+	assert.NoError(err)
+	buf.Reset()
+	assert.NoError(public.WriteJSON(&buf))
+	assert.Equal(`{"oldStateRoot":"85070591730234615865843651857942052864","Leaves":[{"ay":7},{"ay":9}]}`+"\n", buf.String())
+	assert.NoError(read.ReadJSON(&buf))
+	assert.Equal(public.Vector, read.Vector)
+}
+
+func TestJSONErrors(t *testing.T) {
+	assert := require.New(t)
+	schema := newNested(assert).Schema
+
+	for input, msg := range map[string]string{
+		`{"siblings":[[1,2,3],[4,5,6,7]]}`:       "siblings[1]: more than 3 elements",
+		`{"siblings":[[1,2,3]]}`:                 "siblings: got 1 elements, expected 2",
+		`{"leaves":[{"ay":1,"nonce":2}]}`:        "unknown field leaves[0].nonce",
+		`{"Fee":1,"fee":2}`:                      "fee: set twice",
+		`{"Fee":1.5}`:                            "Fee: expected a number or a string",
+		`{"Fee":{}}`:                             "Fee: expected a number or a string",
+		`{"Fee":-1}`:                             "Fee: -1 is not in the scalar field",
+		`{"leaves":3}`:                           "leaves: expected an array of 2 elements",
+		`[]`:                                     "witness: expected an object",
+		`{"Fee":1} {}`:                           "unexpected data after the witness",
+		`{"Fee":1,"siblings":[[1,2,3],[4,5,6]]}`: "missing values: oldStateRoot, Leaves[0].ay, Leaves[0].balance, Leaves[1].ay, Leaves[1].balance",
+		`{"oldStateRoot":1,"leaves":[{},{"ay":2}]}`: "missing values: Leaves[0].ay",
+	} {
+		w := Witness{CurveID: ecc.BN254, Schema: schema}
+		err := w.ReadJSON(strings.NewReader(input))
+		assert.Error(err, input)
+		assert.True(errors.Is(err, ErrInvalidWitness), input)
+		assert.Contains(err.Error(), msg, input)
+	}
+
+	// values are not reduced modulo r
+	r := ecc.BN254.ScalarField().String()
+	w := Witness{CurveID: ecc.BN254, Schema: schema}
+	err := w.ReadJSON(strings.NewReader(`{"leaves":[{"ay":"` + r + `"}]}`))
+	assert.True(errors.Is(err, ErrInvalidWitness))
+	assert.Contains(err.Error(), "leaves[0].ay: "+r+" is not in the scalar field")
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/export"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/solution"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/internal/backend/circuits"
+)
+
+func compile(args []string) error {
+	fs := flag.NewFlagSet("compile", flag.ExitOnError)
+	name := fs.String("circuit", "", "name of the registered circuit (see pianist circuits)")
+	curveName := fs.String("curve", ecc.BN254.String(), "curve of the circuit")
+	backendName := fs.String("backend", backend.PLONK.String(), "backend the circuit is compiled for")
+	output := fs.String("o", "circuit.ccs", "output file")
+	_ = fs.Parse(args)
+
+	c, ok := circuits.Circuits[*name]
+	if !ok {
+		return fmt.Errorf("unknown circuit %q", *name)
+	}
+	curve, err := parseCurve(*curveName)
+	if err != nil {
+		return err
+	}
+	b, err := parseBackend(*backendName)
+	if err != nil {
+		return err
+	}
+
+	newBuilder := scs.NewBuilder
+	if b == backend.GROTH16 {
+		newBuilder = r1cs.NewBuilder
+	}
+	ccs, err := frontend.Compile(curve, newBuilder, c.Circuit)
+	if err != nil {
+		return err
+	}
+	return writeFile(*output, ccs)
+}
+
+func exportCCS(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	ccsPath := fs.String("ccs", "circuit.ccs", "compiled circuit")
+	backendName := fs.String("backend", backend.PLONK.String(), "backend the circuit is compiled for")
+	format := fs.String("format", "json", "output format: json or dot")
+	nbParties := fs.Int("parties", 0, "if set, place the rows on this number of parties as gpiano does")
+	output := fs.String("o", "circuit.json", "output file")
+	_ = fs.Parse(args)
+
+	b, err := parseBackend(*backendName)
+	if err != nil {
+		return err
+	}
+	ccs, err := readCCS(*ccsPath, b)
+	if err != nil {
+		return err
+	}
+	var opts []export.Option
+	if *nbParties != 0 {
+		opts = append(opts, export.WithParties(*nbParties))
+	}
+	s, err := export.New(ccs, opts...)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*output) //#nosec G304 -- the path is given by the user
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "json":
+		err = s.WriteJSON(f)
+	case "dot":
+		err = s.WriteDOT(f)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeWitness(args []string) error {
+	fs := flag.NewFlagSet("witness", flag.ExitOnError)
+	name := fs.String("circuit", "", "name of the registered circuit (see pianist circuits)")
+	curveName := fs.String("curve", ecc.BN254.String(), "curve of the circuit")
+	public := fs.Bool("public", false, "write the public part of the witness only")
+	output := fs.String("o", "witness.json", "output file, in JSON if its name ends with .json and in binary otherwise")
+	_ = fs.Parse(args)
+
+	c, ok := circuits.Circuits[*name]
+	if !ok {
+		return fmt.Errorf("unknown circuit %q", *name)
+	}
+	curve, err := parseCurve(*curveName)
+	if err != nil {
+		return err
+	}
+
+	var opts []frontend.WitnessOption
+	if *public {
+		opts = append(opts, frontend.PublicOnly())
+	}
+	w, err := frontend.NewWitness(c.ValidAssignments[0], curve, opts...)
+	if err != nil {
+		return err
+	}
+	return writeWitnessFile(*output, w)
+}
+
+func setup(args []string) error {
+	fs := flag.NewFlagSet("setup", flag.ExitOnError)
+	ccsPath := fs.String("ccs", "circuit.ccs", "compiled circuit")
+	backendName := fs.String("backend", backend.PLONK.String(), "groth16 or plonk")
+	srsPath := fs.String("srs", "srs.bin", "plonk: KZG SRS, from a ceremony")
+	unsafe := fs.Bool("unsafe-srs", false, "plonk: if the -srs file doesn't exist, generate it in this process (its randomness is known: for tests only)")
+	pkPath := fs.String("pk", "pk.bin", "output proving key")
+	vkPath := fs.String("vk", "vk.bin", "output verifying key")
+	_ = fs.Parse(args)
+
+	b, err := parseSerializableBackend(*backendName)
+	if err != nil {
+		return err
+	}
+	ccs, err := readCCS(*ccsPath, b)
+	if err != nil {
+		return err
+	}
+
+	var pk, vk io.WriterTo
+	switch b {
+	case backend.GROTH16:
+		pk, vk, err = groth16.Setup(ccs)
+	case backend.PLONK:
+		var srs kzg.SRS
+		if srs, err = loadSRS(*srsPath, *unsafe, ccs); err != nil {
+			return err
+		}
+		pk, vk, err = plonk.Setup(ccs, srs)
+	}
+	if err != nil {
+		return err
+	}
+	if err := writeFile(*pkPath, pk); err != nil {
+		return err
+	}
+	return writeFile(*vkPath, vk)
+}
+
+func solve(args []string) error {
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	ccsPath := fs.String("ccs", "circuit.ccs", "compiled circuit")
+	backendName := fs.String("backend", backend.PLONK.String(), "backend the circuit is compiled for")
+	witnessPath := fs.String("witness", "witness.json", "full witness")
+	output := fs.String("o", "solution.bin", "output values of all the wires (see package backend/solution)")
+	lroPath := fs.String("lro", "", "if set, also write the l, r and o vectors of the party, in the layout of the piano and gpiano provers")
+	rank := fs.Int("rank", 0, "with -lro and gpiano: rank of the party")
+	nbParties := fs.Int("parties", 1, "with -lro and gpiano: number of parties sharing the rows of the circuit")
+	_ = fs.Parse(args)
+
+	b, err := parseBackend(*backendName)
+	if err != nil {
+		return err
+	}
+	ccs, err := readCCS(*ccsPath, b)
+	if err != nil {
+		return err
+	}
+	w, err := readWitnessFile(*witnessPath, ccs)
+	if err != nil {
+		return err
+	}
+	s, err := solution.Solve(ccs, w)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(*output, s); err != nil {
+		return err
+	}
+	if *lroPath == "" {
+		return nil
+	}
+	l, r, o, err := solution.LRO(ccs, s, *rank, *nbParties)
+	if err != nil {
+		return err
+	}
+	return writeFile(*lroPath, vectors{l, r, o})
+}
+
+// vectors writes witness vectors one after the other.
+type vectors []witness.Vector
+
+func (vs vectors) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for _, v := range vs {
+		m, err := v.WriteTo(w)
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func prove(args []string) error {
+	fs := flag.NewFlagSet("prove", flag.ExitOnError)
+	ccsPath := fs.String("ccs", "circuit.ccs", "compiled circuit")
+	backendName := fs.String("backend", backend.PLONK.String(), "groth16 or plonk")
+	srsPath := fs.String("srs", "srs.bin", "plonk: KZG SRS used by the setup")
+	pkPath := fs.String("pk", "pk.bin", "proving key")
+	witnessPath := fs.String("witness", "witness.json", "full witness")
+	solutionPath := fs.String("solution", "", "values of all the wires written by pianist solve, checked and used instead of running the solver")
+	output := fs.String("o", "proof.bin", "output proof")
+	_ = fs.Parse(args)
+
+	b, err := parseSerializableBackend(*backendName)
+	if err != nil {
+		return err
+	}
+	ccs, err := readCCS(*ccsPath, b)
+	if err != nil {
+		return err
+	}
+	w, err := readWitnessFile(*witnessPath, ccs)
+	if err != nil {
+		return err
+	}
+	var opts []backend.ProverOption
+	if *solutionPath != "" {
+		opt, err := readSolutionFile(*solutionPath, ccs)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, opt)
+	}
+
+	switch b {
+	case backend.GROTH16:
+		pk := groth16.NewProvingKey(ccs.CurveID())
+		if err := readFile(*pkPath, pk); err != nil {
+			return err
+		}
+		proof, err := groth16.Prove(ccs, pk, w, opts...)
+		if err != nil {
+			return err
+		}
+		return writeFile(*output, proof)
+	default:
+		pk := plonk.NewProvingKey(ccs.CurveID())
+		if err := readFile(*pkPath, pk); err != nil {
+			return err
+		}
+		srs, err := readSRS(*srsPath, ccs.CurveID())
+		if err != nil {
+			return err
+		}
+		if err := pk.InitKZG(srs); err != nil {
+			return err
+		}
+		proof, err := plonk.Prove(ccs, pk, w, opts...)
+		if err != nil {
+			return err
+		}
+		return writeFile(*output, proof)
+	}
+}
+
+func verify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	ccsPath := fs.String("ccs", "circuit.ccs", "compiled circuit, for the schema of the witness")
+	backendName := fs.String("backend", backend.PLONK.String(), "groth16 or plonk")
+	srsPath := fs.String("srs", "srs.bin", "plonk: KZG SRS used by the setup")
+	vkPath := fs.String("vk", "vk.bin", "verifying key")
+	witnessPath := fs.String("witness", "public.json", "public (or full) witness")
+	proofPath := fs.String("proof", "proof.bin", "proof")
+	_ = fs.Parse(args)
+
+	b, err := parseSerializableBackend(*backendName)
+	if err != nil {
+		return err
+	}
+	ccs, err := readCCS(*ccsPath, b)
+	if err != nil {
+		return err
+	}
+	w, err := readWitnessFile(*witnessPath, ccs)
+	if err != nil {
+		return err
+	}
+	if w, err = publicWitness(w); err != nil {
+		return err
+	}
+
+	switch b {
+	case backend.GROTH16:
+		vk := groth16.NewVerifyingKey(ccs.CurveID())
+		if err := readFile(*vkPath, vk); err != nil {
+			return err
+		}
+		proof := groth16.NewProof(ccs.CurveID())
+		if err := readFile(*proofPath, proof); err != nil {
+			return err
+		}
+		err = groth16.Verify(proof, vk, w)
+	default:
+		vk := plonk.NewVerifyingKey(ccs.CurveID())
+		if err := readFile(*vkPath, vk); err != nil {
+			return err
+		}
+		srs, err := readSRS(*srsPath, ccs.CurveID())
+		if err != nil {
+			return err
+		}
+		if err := vk.InitKZG(srs); err != nil {
+			return err
+		}
+		proof := plonk.NewProof(ccs.CurveID())
+		if err := readFile(*proofPath, proof); err != nil {
+			return err
+		}
+		err = plonk.Verify(proof, vk, w)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("proof verified")
+	return nil
+}
+
+// parseCurve returns the curve of the given name.
+func parseCurve(name string) (ecc.ID, error) {
+	for _, c := range gnark.Curves() {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return ecc.UNKNOWN, fmt.Errorf("unknown curve %q", name)
+}
+
+// parseSerializableBackend returns the backend of the given name, if its keys and proofs can be serialized.
+func parseSerializableBackend(name string) (backend.ID, error) {
+	b, err := parseBackend(name)
+	if err != nil {
+		return b, err
+	}
+	if isDistributed(b) {
+		return b, fmt.Errorf("the keys and proofs of %s can't be serialized, use pianist run", b)
+	}
+	return b, nil
+}
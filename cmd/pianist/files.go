@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/solution"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+)
+
+// readCCS reads a compiled circuit for backend b. The curve is read from the file.
+func readCCS(path string, b backend.ID) (frontend.CompiledConstraintSystem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// the curve is not known before decoding, so try them in turn
+	var lastErr error
+	for _, curve := range gnark.Curves() {
+		var ccs frontend.CompiledConstraintSystem
+		if b == backend.GROTH16 {
+			ccs = groth16.NewCS(curve)
+		} else {
+			ccs = plonk.NewCS(curve)
+		}
+		if _, lastErr = ccs.ReadFrom(bytes.NewReader(data)); lastErr == nil && ccs.CurveID() == curve {
+			// fail now rather than in the solver if the circuit needs unknown hints
+			if err := backend.CheckHints(ccs.HintManifest()); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			return ccs, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// readWitnessFile reads a witness of ccs, in JSON if the name of the file ends with .json and
+// in binary otherwise.
+func readWitnessFile(path string, ccs frontend.CompiledConstraintSystem) (*witness.Witness, error) {
+	w := &witness.Witness{CurveID: ccs.CurveID(), Schema: ccs.GetSchema()}
+	if strings.HasSuffix(path, ".json") {
+		f, err := os.Open(path) //#nosec G304 -- the path is given by the user
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := w.ReadJSON(bufio.NewReader(f)); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return w, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func writeWitnessFile(path string, w *witness.Witness) error {
+	if strings.HasSuffix(path, ".json") {
+		f, err := os.Create(path) //#nosec G304 -- the path is given by the user
+		if err != nil {
+			return err
+		}
+		if err := w.WriteJSON(f); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	data, err := w.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// readSolutionFile reads the values of all the wires of ccs, written by pianist solve, as a prover
+// option.
+func readSolutionFile(path string, ccs frontend.CompiledConstraintSystem) (backend.ProverOption, error) {
+	s := &solution.Solution{CurveID: ccs.CurveID()}
+	if err := readFile(path, s); err != nil {
+		return nil, err
+	}
+	return backend.WithSolution(s.Vector), nil
+}
+
+// publicWitness returns the public part of w, which may be a full or a public witness.
+func publicWitness(w *witness.Witness) (*witness.Witness, error) {
+	if w.Vector.Len() == w.Schema.NbPublic {
+		return w, nil
+	}
+	return w.Public()
+}
+
+func writeFile(path string, v io.WriterTo) error {
+	f, err := os.Create(path) //#nosec G304 -- the path is given by the user
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	if _, err := v.WriteTo(bw); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readFile(path string, v io.ReaderFrom) error {
+	f, err := os.Open(path) //#nosec G304 -- the path is given by the user
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = v.ReadFrom(bufio.NewReader(f))
+	return err
+}
@@ -0,0 +1,106 @@
+// Command pianist compiles the registered circuits, and runs the setup, prover and verifier of
+// the gnark backends on serialized artifacts.
+//
+// Usage:
+//
+//	pianist compile -circuit name [-curve bn254] [-backend plonk] -o circuit.ccs
+//	pianist witness -circuit name [-curve bn254] [-public] -o witness.json
+//	pianist export  -ccs circuit.ccs [-backend plonk] [-format json|dot] [-parties n] -o circuit.json
+//	pianist solve   -ccs circuit.ccs [-backend plonk] -witness witness.json -o solution.bin [-lro lro.bin [-rank i -parties m]]
+//	pianist setup   -ccs circuit.ccs -backend groth16|plonk [-srs srs.bin [-unsafe-srs]] -pk pk.bin -vk vk.bin
+//	pianist prove   -ccs circuit.ccs -backend groth16|plonk -pk pk.bin -witness witness.json [-solution solution.bin] -o proof.bin
+//	pianist verify  -ccs circuit.ccs -backend groth16|plonk -vk vk.bin -witness public.json -proof proof.bin
+//	pianist run     -ccs circuit.ccs -backend piano|gpiano|groth16|plonk -witness witness.json [-solution solution.bin] [-srs srs.bin | -unsafe-srs] [-cluster cluster.yaml | -local n] [-metrics report.json]
+//
+// plonk needs a KZG SRS, which should come from a ceremony. -unsafe-srs generates one in the
+// process instead: whoever runs it could learn its randomness and forge proofs, so it is for
+// tests only.
+//
+// Witnesses are streamed with witness.ReadJSON and WriteJSON when the file name ends with .json,
+// and read with witness.UnmarshalBinary otherwise; their schema is the one of the compiled circuit.
+//
+// The keys of the distributed backends (piano, gpiano) can't be serialized: run performs the
+// setup, the proof and the verification in one go. The parties are given by the -cluster
+// configuration (see package backend/cluster), the master (rank 0) starting the others with the
+// same command and verifying the proof. With -local n, the n parties run in the process instead,
+// exchanging their messages in memory: it needs neither SSH nor ports.
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/internal/backend/circuits"
+)
+
+type command struct {
+	name, usage string
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{"compile", "compile a registered circuit to a .ccs file", compile},
+	{"witness", "write the valid assignment of a registered circuit as a witness", writeWitness},
+	{"export", "describe a compiled circuit as JSON or as a Graphviz DOT graph", exportCCS},
+	{"solve", "run the solver alone and write the values of all the wires", solve},
+	{"setup", "run the setup of a circuit and write the proving and verifying keys", setup},
+	{"prove", "write a proof for a witness", prove},
+	{"verify", "verify a proof against a public witness", verify},
+	{"run", "setup, prove and verify in one process (required by piano and gpiano)", run},
+	{"circuits", "list the registered circuits", listCircuits},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "pianist %s: %v\n", c.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: pianist <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "\t%-10s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "run 'pianist <command> -h' for the flags of a command")
+}
+
+func listCircuits(args []string) error {
+	names := make([]string, 0, len(circuits.Circuits))
+	for name := range circuits.Circuits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Println(strings.Join(names, "\n"))
+	return nil
+}
+
+// parseBackend returns the backend of the given name.
+func parseBackend(name string) (backend.ID, error) {
+	for _, b := range backend.Implemented() {
+		if b.String() == strings.ToLower(name) {
+			return b, nil
+		}
+	}
+	return backend.UNKNOWN, fmt.Errorf("unknown backend %q", name)
+}
+
+func isDistributed(b backend.ID) bool {
+	return b == backend.PIANO || b == backend.GPIANO
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark/backend/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEndToEnd runs the commands of pianist on a small circuit, as a user would, with the files
+// in a temporary directory.
+func TestEndToEnd(t *testing.T) {
+	for _, b := range []string{"groth16", "plonk"} {
+		t.Run(b, func(t *testing.T) {
+			dir := t.TempDir()
+			file := func(name string) string { return filepath.Join(dir, name) }
+
+			require.NoError(t, compile([]string{"-circuit", "mul", "-backend", b, "-o", file("circuit.ccs")}))
+			require.NoError(t, writeWitness([]string{"-circuit", "mul", "-o", file("witness.json")}))
+			require.NoError(t, writeWitness([]string{"-circuit", "mul", "-public", "-o", file("public.json")}))
+
+			setupArgs := []string{"-ccs", file("circuit.ccs"), "-backend", b, "-srs", file("srs.bin"), "-pk", file("pk.bin"), "-vk", file("vk.bin")}
+			if b == "plonk" {
+				setupArgs = append(setupArgs, "-unsafe-srs")
+			}
+			require.NoError(t, setup(setupArgs))
+			require.NoError(t, prove([]string{"-ccs", file("circuit.ccs"), "-backend", b, "-srs", file("srs.bin"), "-pk", file("pk.bin"), "-witness", file("witness.json"), "-o", file("proof.bin")}))
+			require.NoError(t, verify([]string{"-ccs", file("circuit.ccs"), "-backend", b, "-srs", file("srs.bin"), "-vk", file("vk.bin"), "-witness", file("public.json"), "-proof", file("proof.bin")}))
+		})
+	}
+}
+
+// TestRunLocal runs the distributed backends with -local, the parties in the process of the test.
+func TestRunLocal(t *testing.T) {
+	for _, b := range []string{"piano", "gpiano"} {
+		t.Run(b, func(t *testing.T) {
+			dir := t.TempDir()
+			file := func(name string) string { return filepath.Join(dir, name) }
+
+			require.NoError(t, compile([]string{"-circuit", "mul", "-backend", b, "-o", file("circuit.ccs")}))
+			require.NoError(t, writeWitness([]string{"-circuit", "mul", "-o", file("witness.json")}))
+			require.NoError(t, run([]string{"-ccs", file("circuit.ccs"), "-backend", b, "-witness", file("witness.json"), "-local", "2", "-metrics", file("report.json")}))
+
+			data, err := os.ReadFile(file("report.json"))
+			require.NoError(t, err)
+			var report metrics.Report
+			require.NoError(t, json.Unmarshal(data, &report))
+			require.Len(t, report.Parties, 2)
+		})
+	}
+}
+
+// TestSetupNoSRS checks that plonk doesn't generate an SRS unless asked to.
+func TestSetupNoSRS(t *testing.T) {
+	dir := t.TempDir()
+	file := func(name string) string { return filepath.Join(dir, name) }
+
+	require.NoError(t, compile([]string{"-circuit", "mul", "-o", file("circuit.ccs")}))
+	err := setup([]string{"-ccs", file("circuit.ccs"), "-srs", file("srs.bin"), "-pk", file("pk.bin"), "-vk", file("vk.bin")})
+	require.Error(t, err)
+
+	err = setup([]string{"-ccs", file("circuit.ccs"), "-srs", "", "-pk", file("pk.bin"), "-vk", file("vk.bin")})
+	require.True(t, errors.Is(err, errNoSRS), err)
+}
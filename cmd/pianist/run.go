@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/cluster"
+	"github.com/consensys/gnark/backend/gpiano"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/metrics"
+	"github.com/consensys/gnark/backend/piano"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/mpisim"
+	"github.com/sunblaze-ucb/simpleMPI/mpi"
+)
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	ccsPath := fs.String("ccs", "circuit.ccs", "compiled circuit")
+	backendName := fs.String("backend", backend.PIANO.String(), "groth16, plonk, piano or gpiano")
+	witnessPath := fs.String("witness", "witness.json", "full witness")
+	clusterPath := fs.String("cluster", "", "piano, gpiano: cluster configuration (defaults to $"+cluster.EnvVar+")")
+	local := fs.Int("local", 0, "piano, gpiano: run the n parties in this process, without a cluster")
+	metricsPath := fs.String("metrics", "", "piano, gpiano: write the per-round metrics of all the parties in this JSON file")
+	solutionPath := fs.String("solution", "", "values of all the wires written by pianist solve, checked and used instead of running the solver")
+	srsPath := fs.String("srs", "", "plonk: KZG SRS, from a ceremony")
+	unsafe := fs.Bool("unsafe-srs", false, "plonk: without -srs, generate the SRS in this process (its randomness is known: for tests only)")
+	_ = fs.Parse(args)
+
+	b, err := parseBackend(*backendName)
+	if err != nil {
+		return err
+	}
+	var c *cluster.Config
+	if isDistributed(b) && *local == 0 {
+		if *clusterPath != "" {
+			c, err = cluster.Load(*clusterPath)
+		} else {
+			c, err = cluster.FromEnv()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	ccs, err := readCCS(*ccsPath, b)
+	if err != nil {
+		return err
+	}
+	w, err := readWitnessFile(*witnessPath, ccs)
+	if err != nil {
+		return err
+	}
+	publicW, err := w.Public()
+	if err != nil {
+		return err
+	}
+	var proverOpts []backend.ProverOption
+	if *solutionPath != "" {
+		opt, err := readSolutionFile(*solutionPath, ccs)
+		if err != nil {
+			return err
+		}
+		proverOpts = append(proverOpts, opt)
+	}
+
+	switch b {
+	case backend.GROTH16:
+		pk, vk, err := groth16.Setup(ccs)
+		if err != nil {
+			return err
+		}
+		proof, err := groth16.Prove(ccs, pk, w, proverOpts...)
+		if err != nil {
+			return err
+		}
+		err = groth16.Verify(proof, vk, publicW)
+		if err != nil {
+			return err
+		}
+	case backend.PLONK:
+		srs, err := loadSRS(*srsPath, *unsafe, ccs)
+		if err != nil {
+			return err
+		}
+		pk, vk, err := plonk.Setup(ccs, srs)
+		if err != nil {
+			return err
+		}
+		proof, err := plonk.Prove(ccs, pk, w, proverOpts...)
+		if err != nil {
+			return err
+		}
+		err = plonk.Verify(proof, vk, publicW)
+		if err != nil {
+			return err
+		}
+	case backend.PIANO, backend.GPIANO:
+		prove := func(tr cluster.Transport) error {
+			return proveDistributed(b, ccs, tr, w, publicW, *metricsPath, proverOpts...)
+		}
+		if *local > 0 {
+			world, err := mpisim.NewWorld(uint64(*local))
+			if err != nil {
+				return err
+			}
+			for rank, err := range world.Run(func(p *mpisim.Party) error { return prove(p) }) {
+				if err != nil {
+					return fmt.Errorf("party %d: %w", rank, err)
+				}
+			}
+			break
+		}
+		if err := c.Init(); err != nil {
+			return err
+		}
+		if err := prove(cluster.MPI()); err != nil {
+			return err
+		}
+		// only the master holds the proof
+		if mpi.SelfRank != 0 {
+			return nil
+		}
+	}
+	fmt.Println("proof verified")
+	return nil
+}
+
+// proveDistributed runs the setup, the prover and, on the master, the verifier of the distributed
+// backend b on the party of tr. The master writes the metrics of all the parties in metricsPath,
+// if set.
+func proveDistributed(b backend.ID, ccs frontend.CompiledConstraintSystem, tr cluster.Transport, w, publicW *witness.Witness, metricsPath string, opts ...backend.ProverOption) error {
+	var metricsErr error
+	if metricsPath != "" {
+		// every party records its metrics, for the master to gather them
+		opts = append(opts[:len(opts):len(opts)], backend.WithProverMetrics(func(r *metrics.Report) {
+			if tr.Rank() == 0 {
+				metricsErr = writeReport(metricsPath, r)
+			}
+		}))
+	}
+
+	switch b {
+	case backend.PIANO:
+		pk, vk, err := piano.SetupWithTransport(ccs, tr, publicW)
+		if err != nil {
+			return err
+		}
+		proof, err := piano.ProveWithTransport(ccs, tr, pk, w, opts...)
+		if err != nil || tr.Rank() != 0 {
+			return err
+		}
+		if metricsErr != nil {
+			return metricsErr
+		}
+		return piano.Verify(proof, vk, publicW)
+	case backend.GPIANO:
+		pk, vk, err := gpiano.SetupWithTransport(ccs, tr, publicW)
+		if err != nil {
+			return err
+		}
+		proof, err := gpiano.ProveWithTransport(ccs, tr, pk, w, opts...)
+		if err != nil || tr.Rank() != 0 {
+			return err
+		}
+		if metricsErr != nil {
+			return metricsErr
+		}
+		return gpiano.Verify(proof, vk, publicW)
+	}
+	return fmt.Errorf("%s is not a distributed backend", b)
+}
+
+func writeReport(path string, r *metrics.Report) error {
+	data, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/frontend"
+
+	kzg_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr/kzg"
+	kzg_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/kzg"
+	kzg_bls24315 "github.com/consensys/gnark-crypto/ecc/bls24-315/fr/kzg"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	kzg_bw6633 "github.com/consensys/gnark-crypto/ecc/bw6-633/fr/kzg"
+	kzg_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/kzg"
+)
+
+// errNoSRS is returned when a plonk SRS is needed but neither given nor allowed to be generated.
+var errNoSRS = errors.New("plonk needs a KZG SRS: give the one of a ceremony with -srs, or -unsafe-srs for tests")
+
+// loadSRS reads the SRS of ccs at path. If path is empty or doesn't exist and unsafe is set, it
+// generates one with unsafeSRS instead, and writes it at path if path is not empty.
+func loadSRS(path string, unsafe bool, ccs frontend.CompiledConstraintSystem) (kzg.SRS, error) {
+	if path != "" {
+		srs, err := readSRS(path, ccs.CurveID())
+		if err == nil || !errors.Is(err, os.ErrNotExist) || !unsafe {
+			return srs, err
+		}
+	} else if !unsafe {
+		return nil, errNoSRS
+	}
+
+	srs, err := unsafeSRS(ccs)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return srs, nil
+	}
+	return srs, writeFile(path, srs)
+}
+
+// unsafeSRS generates a KZG SRS large enough for ccs. The toxic waste is drawn in this process,
+// which could keep it and forge proofs: the SRS is for tests only.
+func unsafeSRS(ccs frontend.CompiledConstraintSystem) (kzg.SRS, error) {
+	fmt.Fprintln(os.Stderr, "warning: generating a KZG SRS in this process (-unsafe-srs), for tests only")
+
+	_, _, nbPublic := ccs.GetNbVariables()
+	size := ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints()+nbPublic)) + 3
+	curve := ccs.CurveID()
+	alpha, err := rand.Int(rand.Reader, curve.ScalarField())
+	if err != nil {
+		return nil, err
+	}
+
+	switch curve {
+	case ecc.BN254:
+		return kzg_bn254.NewSRS(size, alpha)
+	case ecc.BLS12_381:
+		return kzg_bls12381.NewSRS(size, alpha)
+	case ecc.BLS12_377:
+		return kzg_bls12377.NewSRS(size, alpha)
+	case ecc.BW6_761:
+		return kzg_bw6761.NewSRS(size, alpha)
+	case ecc.BLS24_315:
+		return kzg_bls24315.NewSRS(size, alpha)
+	case ecc.BW6_633:
+		return kzg_bw6633.NewSRS(size, alpha)
+	default:
+		return nil, fmt.Errorf("unknown curve %s", curve)
+	}
+}
+
+func readSRS(path string, curve ecc.ID) (kzg.SRS, error) {
+	srs := kzg.NewSRS(curve)
+	if err := readFile(path, srs); err != nil {
+		return nil, err
+	}
+	return srs, nil
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
 	"log"
 	"os"
 	"runtime"
 
 	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/cluster"
 	"github.com/consensys/gnark/backend/piano"
+	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/frontend"
 	"github.com/sunblaze-ucb/simpleMPI/mpi"
 )
@@ -26,18 +29,18 @@ func main() {
 	{
 		// Witnesses instantiation. Witness is known only by the prover,
 		// while public w is a public data known by the verifier.
-		var w R1CSCircuit
-		w.Witness = make([]frontend.Variable, 260275)
-		for i := 0; i < len(w.Witness); i++ {
-			w.Witness[i] = frontend.Variable(0)
+		witnessFull, err := readWitness(ccs)
+		if err != nil {
+			log.Fatal(err)
 		}
 
-		witnessFull, err := frontend.NewWitness(&w, ecc.BN254)
+		witnessPublic, err := witnessFull.Public()
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		witnessPublic, err := frontend.NewWitness(&w, ecc.BN254, frontend.PublicOnly())
+		// the parties of the MPI world, see README.md
+		c, err := cluster.FromEnv()
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -45,12 +48,12 @@ func main() {
 		// public data consists the polynomials describing the constants involved
 		// in the constraints, the polynomial describing the permutation ("grand
 		// product argument"), and the FFT domains.
-		pk, vk, err := piano.Setup(ccs, witnessPublic)
+		pk, vk, err := piano.Setup(ccs, c, witnessPublic)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		proof, err := piano.Prove(ccs, pk, witnessFull)
+		proof, err := piano.Prove(ccs, c, pk, witnessFull)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -62,3 +65,28 @@ func main() {
 		}
 	}
 }
+
+// readWitness reads the assignment of the wires of ccs from the JSON file given as argument, of
+// the form {"Witness": [...]}, or assigns 0 to every wire if there is none.
+func readWitness(ccs frontend.CompiledConstraintSystem) (*witness.Witness, error) {
+	if len(os.Args) < 2 {
+		_, nbSecret, _ := ccs.GetNbVariables()
+		var w R1CSCircuit
+		w.Witness = make([]frontend.Variable, nbSecret)
+		for i := 0; i < len(w.Witness); i++ {
+			w.Witness[i] = frontend.Variable(0)
+		}
+		return frontend.NewWitness(&w, ecc.BN254)
+	}
+
+	f, err := os.Open(os.Args[1])
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	w := &witness.Witness{CurveID: ecc.BN254, Schema: ccs.GetSchema()}
+	if err := w.ReadJSON(bufio.NewReader(f)); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/cluster"
 	"github.com/consensys/gnark/backend/piano"
 	"github.com/consensys/gnark/frontend/cs/scs"
 	"github.com/sunblaze-ucb/simpleMPI/mpi"
@@ -104,15 +105,21 @@ func main() {
 			log.Fatal(err)
 		}
 
+		// the parties of the MPI world, see README.md
+		c, err := cluster.FromEnv()
+		if err != nil {
+			log.Fatal(err)
+		}
+
 		// public data consists the polynomials describing the constants involved
 		// in the constraints, the polynomial describing the permutation ("grand
 		// product argument"), and the FFT domains.
-		pk, vk, err := piano.Setup(ccs, witnessPublic)
+		pk, vk, err := piano.Setup(ccs, c, witnessPublic)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		proof, err := piano.Prove(ccs, pk, witnessFull)
+		proof, err := piano.Prove(ccs, c, pk, witnessFull)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -48,6 +48,11 @@ type Compiler interface {
 	// are factorized. That is, measuring 2 times the "repeating" piece of circuit may give less constraints the second time
 	AddCounter(from, to Tag)
 
+	// OnParty places the constraints added by f on the party i of a distributed prover (gpiano).
+	// Constraints added outside of OnParty fill the rows left free by the placed ones, in order.
+	// Builders which don't distribute the constraints (R1CS) and the test engine just call f.
+	OnParty(i int, f func(api API))
+
 	// ConstantValue returns the big.Int value of v and true if op is a success.
 	// nil and false if failure. This API returns a boolean to allow for future refactoring
 	// replacing *big.Int with fr.Element
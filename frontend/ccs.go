@@ -19,6 +19,7 @@ import (
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/frontend/compiled"
 	"github.com/consensys/gnark/frontend/schema"
@@ -46,6 +47,10 @@ type CompiledConstraintSystem interface {
 
 	GetSchema() *schema.Schema
 
+	// HintManifest returns the manifest of the hints needed to solve the constraint system,
+	// see backend.CheckHints
+	HintManifest() hint.Manifest
+
 	// GetConstraints return a human readable representation of the constraints
 	GetConstraints() [][]string
 }
@@ -25,7 +25,7 @@ import (
 //
 // 3. finally, it converts that to a ConstraintSystem.
 // 		if zkpID == backend.GROTH16	→ R1CS
-//		if zkpID == backend.PLONK 	→ SparseR1CS
+//		if zkpID == backend.PLONK, backend.PIANO or backend.GPIANO 	→ SparseR1CS
 //
 // initialCapacity is an optional parameter that reserves memory in slices
 // it should be set to the estimated number of constraints in the circuit, if known.
@@ -125,6 +125,8 @@ type CompileOption func(opt *CompileConfig) error
 type CompileConfig struct {
 	Capacity                  int
 	IgnoreUnconstrainedInputs bool
+	Optimize                  bool
+	OptimizationReport        *OptimizationReport
 }
 
 // WithCapacity is a compile option that specifies the estimated capacity needed
@@ -151,6 +153,38 @@ func IgnoreUnconstrainedInputs() CompileOption {
 	}
 }
 
+// WithOptimizations is a compile option which rewrites the compiled constraint system to use less
+// constraints and wires: duplicate constraints are removed, wires with a constant value are
+// replaced by it, and linear constraints are merged into the one using their output.
+// If report is not nil, it is filled with what the optimizations removed.
+//
+// Only the SparseR1CS builder (scs) optimizes the constraint system. The constraints are
+// rewritten, so the solution vector of the circuit differs from the one without the option.
+func WithOptimizations(report *OptimizationReport) CompileOption {
+	return func(opt *CompileConfig) error {
+		opt.Optimize = true
+		opt.OptimizationReport = report
+		return nil
+	}
+}
+
+// OptimizationReport describes what WithOptimizations removed from a constraint system
+type OptimizationReport struct {
+	NbConstraintsBefore, NbConstraintsAfter int
+	NbInternalBefore, NbInternalAfter       int // number of internal wires
+
+	NbDuplicates int // constraints identical to a previous one
+	NbConstants  int // wires replaced by their constant value
+	NbTrivial    int // constraints with no wire left after the constants were replaced
+	NbMerged     int // linear constraints merged into the one using their output
+}
+
+func (r OptimizationReport) String() string {
+	return fmt.Sprintf("%d constraints -> %d, %d internal wires -> %d (%d duplicates, %d constants, %d trivial, %d merged)",
+		r.NbConstraintsBefore, r.NbConstraintsAfter, r.NbInternalBefore, r.NbInternalAfter,
+		r.NbDuplicates, r.NbConstants, r.NbTrivial, r.NbMerged)
+}
+
 var tVariable reflect.Type
 
 func init() {
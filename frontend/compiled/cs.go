@@ -60,6 +60,11 @@ func (cs *ConstraintSystem) GetCounters() []Counter { return cs.Counters }
 
 func (cs *ConstraintSystem) GetSchema() *schema.Schema { return cs.Schema }
 
+// HintManifest returns the manifest of the hints needed to solve the constraint system
+func (cs *ConstraintSystem) HintManifest() hint.Manifest {
+	return hint.NewManifest(cs.MHintsDependencies)
+}
+
 // Counter contains measurements of useful statistics between two Tag
 type Counter struct {
 	From, To      string
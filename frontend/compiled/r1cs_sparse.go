@@ -23,6 +23,12 @@ import (
 type SparseR1CS struct {
 	ConstraintSystem
 	Constraints []SparseR1C
+
+	// NbParties is the number of parties the constraints are placed on with
+	// frontend.Compiler.OnParty, 0 if they are not placed. The rows
+	// [ placeholders | Constraints ] are then split in NbParties blocks of
+	// NbRowsPerParty rows, padded with empty constraints.
+	NbParties int
 }
 
 // GetNbConstraints returns the number of constraints
@@ -30,6 +36,15 @@ func (cs *SparseR1CS) GetNbConstraints() int {
 	return len(cs.Constraints)
 }
 
+// NbRowsPerParty returns the number of rows of each party, or 0 if the
+// constraints are not placed on parties
+func (cs *SparseR1CS) NbRowsPerParty() int {
+	if cs.NbParties == 0 {
+		return 0
+	}
+	return (cs.NbPublicVariables + len(cs.Constraints)) / cs.NbParties
+}
+
 // SparseR1C used to compute the wires
 // L+R+M[0]M[1]+O+k=0
 // if a Term is zero, it means the field doesn't exist (ex M=[0,0] means there is no multiplicative term)
@@ -583,6 +583,11 @@ func (system *r1cs) AddCounter(from, to frontend.Tag) {
 	})
 }
 
+// OnParty calls f: an R1CS is not distributed between parties
+func (system *r1cs) OnParty(i int, f func(api frontend.API)) {
+	f(system)
+}
+
 // NewHint initializes internal variables whose value will be evaluated using
 // the provided hint function at run time from the inputs. Inputs must be either
 // variables or convertible to *big.Int. The function returns an error if the
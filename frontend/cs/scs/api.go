@@ -200,24 +200,32 @@ func (system *scs) Xor(a, b frontend.Variable) frontend.Variable {
 		_a.Xor(_a, _b)
 		return _a
 	}
-	res := system.newInternalVariable()
 	if aConstant {
 		a, b = b, a
 		bConstant = aConstant
 		_b = _a
 	}
 	if bConstant {
-		l := a.(compiled.Term)
-		r := l
-		one := big.NewInt(1)
-		_b.Lsh(_b, 1).Sub(_b, one)
-		idl := system.st.CoeffID(_b)
-		system.addPlonkConstraint(l, r, res, idl, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdOne, compiled.CoeffIdZero)
-		return res
+		if !(_b.IsUint64() && (_b.Uint64() <= 1)) {
+			panic(fmt.Sprintf("%s should be 0 or 1", _b.String()))
+		}
+		// a ^ 0 = a and a ^ 1 = 1 - a
+		if _b.Uint64() == 0 {
+			return a
+		}
+		return system.Sub(1, a)
 	}
+	res := system.newInternalVariable()
 	l := a.(compiled.Term)
 	r := b.(compiled.Term)
-	system.addPlonkConstraint(l, r, res, compiled.CoeffIdMinusOne, compiled.CoeffIdMinusOne, compiled.CoeffIdTwo, compiled.CoeffIdOne, compiled.CoeffIdOne, compiled.CoeffIdZero)
+
+	// -a - b + 2ab + res == 0, the terms may carry a coefficient
+	cl, cr := system.coeff(l), system.coeff(r)
+	var qL, qR, qM1 big.Int
+	qL.Neg(&cl)
+	qR.Neg(&cr)
+	qM1.Lsh(&cl, 1).Mod(&qM1, system.CurveID.ScalarField())
+	system.addPlonkConstraint(l, r, res, system.st.CoeffID(&qL), system.st.CoeffID(&qR), system.st.CoeffID(&qM1), system.st.CoeffID(&cr), compiled.CoeffIdOne, compiled.CoeffIdZero)
 	return res
 }
 
@@ -231,32 +239,43 @@ func (system *scs) Or(a, b frontend.Variable) frontend.Variable {
 		_a.Or(_a, _b)
 		return _a
 	}
-	res := system.newInternalVariable()
 	if aConstant {
 		a, b = b, a
 		_b = _a
 		bConstant = aConstant
 	}
 	if bConstant {
-		l := a.(compiled.Term)
-		r := l
-
 		if !(_b.IsUint64() && (_b.Uint64() <= 1)) {
 			panic(fmt.Sprintf("%s should be 0 or 1", _b.String()))
 		}
 		system.AssertIsBoolean(a)
 
-		one := big.NewInt(1)
-		_b.Sub(_b, one)
-		idl := system.st.CoeffID(_b)
-		system.addPlonkConstraint(l, r, res, idl, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdOne, compiled.CoeffIdZero)
-		return res
+		// a | 0 = a and a | 1 = 1
+		if _b.Uint64() == 0 {
+			return a
+		}
+		return 1
 	}
+	res := system.newInternalVariable()
 	l := a.(compiled.Term)
 	r := b.(compiled.Term)
 	system.AssertIsBoolean(l)
 	system.AssertIsBoolean(r)
-	system.addPlonkConstraint(l, r, res, compiled.CoeffIdMinusOne, compiled.CoeffIdMinusOne, compiled.CoeffIdOne, compiled.CoeffIdOne, compiled.CoeffIdOne, compiled.CoeffIdZero)
+
+	// -a - b + ab + res == 0, the terms may carry a coefficient
+	cl, cr := system.coeff(l), system.coeff(r)
+	var qL, qR big.Int
+	qL.Neg(&cl)
+	qR.Neg(&cr)
+	system.addPlonkConstraint(l, r, res, system.st.CoeffID(&qL), system.st.CoeffID(&qR), system.st.CoeffID(&cl), system.st.CoeffID(&cr), compiled.CoeffIdOne, compiled.CoeffIdZero)
+	return res
+}
+
+// coeff returns the coefficient of t
+func (system *scs) coeff(t compiled.Term) big.Int {
+	var res big.Int
+	cid, _, _ := t.Unpack()
+	res.Set(&system.st.Coeffs[cid])
 	return res
 }
 
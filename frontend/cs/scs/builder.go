@@ -57,6 +57,12 @@ type scs struct {
 
 	// map for recording boolean constrained variables (to not constrain them twice)
 	mtBooleans map[int]struct{}
+
+	// party of the constraints added in OnParty, -1 outside of OnParty
+	party int
+
+	// party of each constraint (-1 if not placed), nil until OnParty is called
+	mParties []int
 }
 
 // initialCapacity has quite some impact on frontend performance, especially on large circuits size
@@ -72,6 +78,7 @@ func newBuilder(curveID ecc.ID, config frontend.CompileConfig) *scs {
 		Constraints: make([]compiled.SparseR1C, 0, config.Capacity),
 		st:          cs.NewCoeffTable(),
 		config:      config,
+		party:       -1,
 	}
 
 	system.Public = make([]string, 0)
@@ -101,6 +108,9 @@ func (system *scs) addPlonkConstraint(l, r, o compiled.Term, cidl, cidr, cidm1,
 
 	//system.Constraints = append(system.Constraints, compiled.SparseR1C{L: _l, R: _r, O: _o, M: [2]compiled.Term{u, v}, K: k})
 	system.Constraints = append(system.Constraints, compiled.SparseR1C{L: l, R: r, O: o, M: [2]compiled.Term{u, v}, K: k})
+	if system.mParties != nil {
+		system.mParties = append(system.mParties, system.party)
+	}
 }
 
 // newInternalVariable creates a new wire, appends it on the list of wires of the circuit, sets
@@ -322,9 +332,24 @@ func (cs *scs) Compile() (frontend.CompiledConstraintSystem, error) {
 		panic("number of secret variables is inconsitent") // it grew after the schema parsing?
 	}
 
+	// optimize the constraints
+	if cs.config.Optimize {
+		report := cs.optimize(&res)
+		if cs.config.OptimizationReport != nil {
+			*cs.config.OptimizationReport = report
+		}
+	}
+
 	// build levels
 	res.Levels = buildLevels(res)
 
+	// lay out the constraints placed on parties
+	if cs.mParties != nil {
+		if err := cs.layoutParties(&res); err != nil {
+			return nil, err
+		}
+	}
+
 	switch cs.CurveID {
 	case ecc.BLS12_377:
 		return bls12377r1cs.NewSparseR1CS(res, cs.st.Coeffs), nil
@@ -491,6 +516,24 @@ func (system *scs) AddCounter(from, to frontend.Tag) {
 	})
 }
 
+// OnParty places the constraints added by f on the party i of a distributed prover (gpiano).
+// Constraints added outside of OnParty fill the rows left free by the placed ones, in order.
+func (system *scs) OnParty(i int, f func(api frontend.API)) {
+	if i < 0 {
+		panic("party index must be non-negative")
+	}
+	if system.mParties == nil {
+		system.mParties = make([]int, len(system.Constraints), cap(system.Constraints))
+		for j := range system.mParties {
+			system.mParties[j] = -1
+		}
+	}
+	previous := system.party
+	system.party = i
+	f(system)
+	system.party = previous
+}
+
 // NewHint initializes internal variables whose value will be evaluated using
 // the provided hint function at run time from the inputs. Inputs must be either
 // variables or convertible to *big.Int. The function returns an error if the
@@ -0,0 +1,628 @@
+package scs
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/logger"
+)
+
+// optimize rewrites res to use less constraints and wires (see frontend.WithOptimizations).
+//
+// It runs before the levels are built and the constraints are placed on parties: res.MDebug,
+// res.MHints, res.Logs, res.DebugInfo and the placement of OnParty are updated to the new
+// constraint and wire IDs.
+func (system *scs) optimize(res *compiled.SparseR1CS) frontend.OptimizationReport {
+	o := newOptimizer(system, res)
+
+	report := frontend.OptimizationReport{
+		NbConstraintsBefore: len(res.Constraints),
+		NbInternalBefore:    res.NbInternalVariables,
+	}
+	report.NbConstants, report.NbTrivial = o.propagateConstants()
+	report.NbDuplicates = o.removeDuplicates()
+	report.NbMerged = o.mergeLinear()
+	o.compact()
+	report.NbConstraintsAfter = len(res.Constraints)
+	report.NbInternalAfter = res.NbInternalVariables
+
+	log := logger.Logger()
+	log.Info().
+		Int("nbConstraints", report.NbConstraintsAfter).
+		Int("nbDuplicates", report.NbDuplicates).
+		Int("nbConstants", report.NbConstants).
+		Int("nbTrivial", report.NbTrivial).
+		Int("nbMerged", report.NbMerged).
+		Msg("optimized constraint system")
+
+	return report
+}
+
+type optimizer struct {
+	system *scs
+	res    *compiled.SparseR1CS
+
+	modulus  *big.Int
+	nbInputs int
+	nbWires  int
+
+	removed []bool // constraints removed
+
+	// wires read by the hints, the logs or the debug info, which must be kept
+	pinned []bool
+
+	// number of constraints referencing each wire, and the sum of their IDs: when a wire is
+	// referenced by 2 constraints, the ID of the other one is sumRefs - ID of the first one
+	refs, sumRefs []int
+}
+
+func newOptimizer(system *scs, res *compiled.SparseR1CS) *optimizer {
+	o := &optimizer{
+		system:   system,
+		res:      res,
+		modulus:  system.CurveID.ScalarField(),
+		nbInputs: res.NbPublicVariables + res.NbSecretVariables,
+		removed:  make([]bool, len(res.Constraints)),
+	}
+	o.nbWires = o.nbInputs + res.NbInternalVariables
+
+	// the constraints are rewritten in a new slice, res.Constraints may be the builder's one
+	constraints := make([]compiled.SparseR1C, len(res.Constraints))
+	for cID, c := range res.Constraints {
+		constraints[cID] = normalize(c)
+	}
+	res.Constraints = constraints
+
+	o.pinned = make([]bool, o.nbWires)
+	pin := func(t compiled.Term) {
+		if t != compiled.TermDelimitor {
+			o.pinned[t.WireID()] = true
+		}
+	}
+	for _, h := range res.MHints {
+		for _, in := range h.Inputs {
+			switch t := in.(type) {
+			case compiled.LinearExpression:
+				for _, tt := range t {
+					pin(tt)
+				}
+			case compiled.Term:
+				pin(t)
+			}
+		}
+	}
+	for _, entries := range [][]compiled.LogEntry{res.Logs, res.DebugInfo} {
+		for _, entry := range entries {
+			for _, t := range entry.ToResolve {
+				pin(t)
+			}
+		}
+	}
+
+	return o
+}
+
+// hasM reports whether c has a multiplicative term
+func hasM(c compiled.SparseR1C) bool {
+	return c.M[0].CoeffID() != compiled.CoeffIdZero && c.M[1].CoeffID() != compiled.CoeffIdZero
+}
+
+// normalize resets the terms of c which don't contribute to it, so that they reference no wire
+func normalize(c compiled.SparseR1C) compiled.SparseR1C {
+	if !hasM(c) {
+		c.M = [2]compiled.Term{}
+		if c.L.CoeffID() == compiled.CoeffIdZero {
+			c.L = 0
+		}
+		if c.R.CoeffID() == compiled.CoeffIdZero {
+			c.R = 0
+		}
+	}
+	if c.O.CoeffID() == compiled.CoeffIdZero {
+		c.O = 0
+	}
+	return c
+}
+
+// wires returns the distinct wires c depends on
+func wires(c compiled.SparseR1C) []int {
+	res := make([]int, 0, 3)
+	add := func(w int) {
+		for _, v := range res {
+			if v == w {
+				return
+			}
+		}
+		res = append(res, w)
+	}
+	m := hasM(c)
+	if m || c.L.CoeffID() != compiled.CoeffIdZero {
+		add(c.L.WireID())
+	}
+	if m || c.R.CoeffID() != compiled.CoeffIdZero {
+		add(c.R.WireID())
+	}
+	if c.O.CoeffID() != compiled.CoeffIdZero {
+		add(c.O.WireID())
+	}
+	return res
+}
+
+func (o *optimizer) coeff(id int) *big.Int {
+	return &o.system.st.Coeffs[id]
+}
+
+// coeffID returns the ID of v mod the modulus in the coefficient table
+func (o *optimizer) coeffID(v *big.Int) int {
+	var r, n big.Int
+	r.Mod(v, o.modulus)
+	if r.Sign() == 0 {
+		return compiled.CoeffIdZero
+	}
+	// small negative values are stored as such by the builder
+	if n.Sub(&r, o.modulus); n.IsInt64() {
+		r.Set(&n)
+	}
+	return o.system.st.CoeffID(&r)
+}
+
+// solvers returns, for each wire, the constraint the solver computes it with, or -1 if it is an
+// input or an output of a hint
+func (o *optimizer) solvers() []int {
+	solvedBy := make([]int, o.nbWires)
+	solved := make([]bool, o.nbWires)
+	for w := range solvedBy {
+		solvedBy[w] = -1
+		solved[w] = w < o.nbInputs
+	}
+	for cID, c := range o.res.Constraints {
+		if o.removed[cID] {
+			continue
+		}
+		for _, w := range wires(c) {
+			if solved[w] {
+				continue
+			}
+			if h, ok := o.res.MHints[w]; ok {
+				for _, hw := range h.Wires {
+					solved[hw] = true
+				}
+				continue
+			}
+			solved[w] = true
+			solvedBy[w] = cID
+		}
+	}
+	return solvedBy
+}
+
+func (o *optimizer) countRefs() {
+	o.refs = make([]int, o.nbWires)
+	o.sumRefs = make([]int, o.nbWires)
+	for cID, c := range o.res.Constraints {
+		if !o.removed[cID] {
+			o.updateRefs(c, cID, 1)
+		}
+	}
+}
+
+func (o *optimizer) updateRefs(c compiled.SparseR1C, cID, sign int) {
+	for _, w := range wires(c) {
+		o.refs[w] += sign
+		o.sumRefs[w] += sign * cID
+	}
+}
+
+// propagateConstants replaces the wires whose value is fixed by a constraint (q·w + k = 0) by
+// their value in the constraints after it. The constraints left with no wire are removed, and so
+// are the ones fixing an internal wire that nothing else reads anymore.
+//
+// Returns the number of wires replaced and of constraints left with no wire.
+func (o *optimizer) propagateConstants() (nbConstants, nbTrivial int) {
+	constants := make(map[int]*big.Int)
+	fixedBy := make(map[int]int) // constraint fixing the value of each constant wire
+	for cID := range o.res.Constraints {
+		if o.removed[cID] {
+			continue
+		}
+		c := o.substitute(o.res.Constraints[cID], constants)
+		o.res.Constraints[cID] = c
+
+		ws := wires(c)
+		if len(ws) == 0 && c.K == compiled.CoeffIdZero {
+			o.removed[cID] = true
+			nbTrivial++
+			continue
+		}
+		// a constraint with no wire and a non zero constant is kept for the solver to report it
+		if len(ws) != 1 || hasM(c) {
+			continue
+		}
+
+		// q·w + k = 0
+		w := ws[0]
+		var q, v big.Int
+		for _, t := range []compiled.Term{c.L, c.R, c.O} {
+			if t.WireID() == w {
+				q.Add(&q, o.coeff(t.CoeffID()))
+			}
+		}
+		if q.Mod(&q, o.modulus).Sign() == 0 {
+			continue
+		}
+		q.ModInverse(&q, o.modulus)
+		v.Mul(&q, o.coeff(c.K)).Neg(&v).Mod(&v, o.modulus)
+		constants[w] = &v
+		fixedBy[w] = cID
+		nbConstants++
+	}
+
+	solvedBy := o.solvers()
+	o.countRefs()
+	for w, cID := range fixedBy {
+		if w >= o.nbInputs && !o.pinned[w] && solvedBy[w] == cID && o.refs[w] == 1 {
+			o.removed[cID] = true
+		}
+	}
+
+	return
+}
+
+// substitute replaces the wires of c which are in constants by their value
+func (o *optimizer) substitute(c compiled.SparseR1C, constants map[int]*big.Int) compiled.SparseR1C {
+	var k, t big.Int
+	k.Set(o.coeff(c.K))
+	changed := false
+
+	// returns the coefficient of the multiplicative term and clears it
+	clearM := func() *big.Int {
+		var qM big.Int
+		qM.Mul(o.coeff(c.M[0].CoeffID()), o.coeff(c.M[1].CoeffID()))
+		c.M = [2]compiled.Term{}
+		return &qM
+	}
+
+	if v, ok := constants[c.L.WireID()]; ok && (hasM(c) || c.L.CoeffID() != compiled.CoeffIdZero) {
+		// qL·v + qR·r + qM·v·r = (qR + qM·v)·r + qL·v
+		if hasM(c) {
+			qR := clearM()
+			qR.Mul(qR, v).Add(qR, o.coeff(c.R.CoeffID()))
+			c.R.SetCoeffID(o.coeffID(qR))
+		}
+		k.Add(&k, t.Mul(o.coeff(c.L.CoeffID()), v))
+		c.L.SetCoeffID(compiled.CoeffIdZero)
+		changed = true
+	}
+	if v, ok := constants[c.R.WireID()]; ok && (hasM(c) || c.R.CoeffID() != compiled.CoeffIdZero) {
+		if hasM(c) {
+			qL := clearM()
+			qL.Mul(qL, v).Add(qL, o.coeff(c.L.CoeffID()))
+			c.L.SetCoeffID(o.coeffID(qL))
+		}
+		k.Add(&k, t.Mul(o.coeff(c.R.CoeffID()), v))
+		c.R.SetCoeffID(compiled.CoeffIdZero)
+		changed = true
+	}
+	if v, ok := constants[c.O.WireID()]; ok && c.O.CoeffID() != compiled.CoeffIdZero {
+		k.Add(&k, t.Mul(o.coeff(c.O.CoeffID()), v))
+		c.O.SetCoeffID(compiled.CoeffIdZero)
+		changed = true
+	}
+
+	if !changed {
+		return c
+	}
+	c.K = o.coeffID(&k)
+	return normalize(c)
+}
+
+// removeDuplicates removes the constraints identical to a previous one: all their wires are
+// solved by then, so they only check what the first one already checks. The first one takes the
+// debug info of a duplicate if it has none.
+//
+// Returns the number of constraints removed.
+func (o *optimizer) removeDuplicates() (nbDuplicates int) {
+	seen := make(map[compiled.SparseR1C]int, len(o.res.Constraints))
+	for cID, c := range o.res.Constraints {
+		if o.removed[cID] {
+			continue
+		}
+		if first, ok := seen[c]; ok {
+			o.removed[cID] = true
+			if _, ok := o.res.MDebug[first]; !ok {
+				if dID, ok := o.res.MDebug[cID]; ok {
+					o.res.MDebug[first] = dID
+				}
+			}
+			nbDuplicates++
+			continue
+		}
+		seen[c] = cID
+	}
+	return
+}
+
+// linearTerm is a wire and its coefficient in a linear constraint
+type linearTerm struct {
+	t     compiled.Term
+	coeff big.Int
+}
+
+// linear returns the terms of the linear constraint c, one per wire, or false if a coefficient
+// is zero
+func (o *optimizer) linear(c compiled.SparseR1C) ([]linearTerm, bool) {
+	res := make([]linearTerm, 0, 3)
+	for _, t := range []compiled.Term{c.L, c.R, c.O} {
+		if t.CoeffID() == compiled.CoeffIdZero {
+			continue
+		}
+		i := 0
+		for i < len(res) && res[i].t.WireID() != t.WireID() {
+			i++
+		}
+		if i == len(res) {
+			res = append(res, linearTerm{t: t})
+		}
+		res[i].coeff.Add(&res[i].coeff, o.coeff(t.CoeffID()))
+	}
+	for i := range res {
+		if res[i].coeff.Mod(&res[i].coeff, o.modulus).Sign() == 0 {
+			return nil, false
+		}
+	}
+	return res, true
+}
+
+// mergeLinear merges the linear constraints c1 whose output t is read by a single linear
+// constraint c2 into c2, when the result fits in a constraint:
+//
+//	c1: q·t + Σ aᵢ·wᵢ + k1 = 0
+//	c2: s·t + Σ bⱼ·wⱼ + k2 = 0  →  Σ bⱼ·wⱼ - s/q·(Σ aᵢ·wᵢ + k1) + k2 = 0
+//
+// t is dropped. As in addition chains, the output of c2 is then solved in the merged constraint.
+//
+// Returns the number of constraints merged.
+func (o *optimizer) mergeLinear() (nbMerged int) {
+	solvedBy := o.solvers()
+	o.countRefs()
+
+	for g1 := range o.res.Constraints {
+		c1 := o.res.Constraints[g1]
+		if o.removed[g1] || hasM(c1) {
+			continue
+		}
+
+		// output of c1, read by c2 only
+		t := -1
+		for _, w := range wires(c1) {
+			if solvedBy[w] == g1 {
+				t = w
+			}
+		}
+		if t == -1 || o.pinned[t] || o.refs[t] != 2 {
+			continue
+		}
+		g2 := o.sumRefs[t] - g1
+		c2 := o.res.Constraints[g2]
+		if hasM(c2) {
+			continue
+		}
+		if p := o.system.mParties; p != nil && p[g1] != p[g2] {
+			continue
+		}
+
+		merged, ok := o.merge(c1, c2, t, solvedBy, g2)
+		if !ok {
+			continue
+		}
+
+		o.updateRefs(c1, g1, -1)
+		o.updateRefs(c2, g2, -1)
+		o.updateRefs(merged, g2, 1)
+		o.res.Constraints[g2] = merged
+		o.removed[g1] = true
+		if _, ok := o.res.MDebug[g2]; !ok {
+			if dID, ok := o.res.MDebug[g1]; ok {
+				o.res.MDebug[g2] = dID
+			}
+		}
+		nbMerged++
+	}
+
+	return
+}
+
+// merge returns the constraint c2 with the wire t replaced by its value in c1 (see mergeLinear),
+// or false if it doesn't fit in a constraint
+func (o *optimizer) merge(c1, c2 compiled.SparseR1C, t int, solvedBy []int, g2 int) (compiled.SparseR1C, bool) {
+	lc1, ok1 := o.linear(c1)
+	lc2, ok2 := o.linear(c2)
+	if !ok1 || !ok2 {
+		return c2, false
+	}
+
+	var q, f, k big.Int
+	found := false
+	res := make([]linearTerm, 0, len(lc1)+len(lc2))
+	for i := range lc1 {
+		if lc1[i].t.WireID() == t {
+			q.Set(&lc1[i].coeff)
+		}
+	}
+	for i := range lc2 {
+		if lc2[i].t.WireID() == t {
+			f.Set(&lc2[i].coeff)
+			found = true
+		} else {
+			res = append(res, linearTerm{t: lc2[i].t})
+			res[len(res)-1].coeff.Set(&lc2[i].coeff)
+		}
+	}
+	if !found {
+		return c2, false
+	}
+
+	// f = -s/q
+	q.ModInverse(&q, o.modulus)
+	f.Mul(&f, &q).Neg(&f)
+
+	for i := range lc1 {
+		if lc1[i].t.WireID() == t {
+			continue
+		}
+		j := 0
+		for j < len(res) && res[j].t.WireID() != lc1[i].t.WireID() {
+			j++
+		}
+		if j == len(res) {
+			res = append(res, linearTerm{t: lc1[i].t})
+		}
+		var a big.Int
+		res[j].coeff.Add(&res[j].coeff, a.Mul(&f, &lc1[i].coeff))
+	}
+	if len(res) > 3 {
+		return c2, false
+	}
+	for i := range res {
+		// a wire cancelled out may be the one c2 solves: keep c1 and c2
+		if res[i].coeff.Mod(&res[i].coeff, o.modulus).Sign() == 0 {
+			return c2, false
+		}
+	}
+	k.Mul(&f, o.coeff(c1.K)).Add(&k, o.coeff(c2.K))
+
+	var c compiled.SparseR1C
+	c.K = o.coeffID(&k)
+	free := []*compiled.Term{&c.L, &c.R, &c.O}
+	for i := range res {
+		term := res[i].t
+		term.SetCoeffID(o.coeffID(&res[i].coeff))
+		if solvedBy[term.WireID()] == g2 {
+			// the wire c2 solves goes in O, where the solver computes it
+			c.O = term
+			free = free[:2]
+		}
+	}
+	for i := range res {
+		if solvedBy[res[i].t.WireID()] != g2 {
+			term := res[i].t
+			term.SetCoeffID(o.coeffID(&res[i].coeff))
+			*free[0] = term
+			free = free[1:]
+		}
+	}
+
+	return c, true
+}
+
+// compact removes the constraints and the internal wires which are not used anymore, and
+// updates the IDs referencing them
+func (o *optimizer) compact() {
+	res := o.res
+
+	// constraints
+	constraints := make([]compiled.SparseR1C, 0, len(res.Constraints))
+	mDebug := make(map[int]int, len(res.MDebug))
+	var mParties []int
+	if o.system.mParties != nil {
+		mParties = make([]int, 0, len(res.Constraints))
+	}
+	for cID, c := range res.Constraints {
+		if o.removed[cID] {
+			continue
+		}
+		if dID, ok := res.MDebug[cID]; ok {
+			mDebug[len(constraints)] = dID
+		}
+		if mParties != nil {
+			mParties = append(mParties, o.system.mParties[cID])
+		}
+		constraints = append(constraints, c)
+	}
+	res.Constraints = constraints
+	res.MDebug = mDebug
+	o.system.mParties = mParties
+	o.removed = make([]bool, len(constraints))
+
+	// wires: the inputs, the outputs of the hints and the wires read are kept
+	o.countRefs()
+	used := make([]bool, o.nbWires)
+	for w := range used {
+		used[w] = o.pinned[w] || w < o.nbInputs || o.refs[w] != 0
+	}
+	for _, h := range res.MHints {
+		for _, w := range h.Wires {
+			used[w] = true
+		}
+	}
+	newIDs := make([]int, o.nbWires)
+	nbWires := 0
+	for w := range newIDs {
+		if used[w] {
+			newIDs[w] = nbWires
+			nbWires++
+		}
+	}
+	if nbWires == o.nbWires {
+		return
+	}
+
+	rename := func(t *compiled.Term) {
+		if *t != compiled.TermDelimitor {
+			t.SetWireID(newIDs[t.WireID()])
+		}
+	}
+	for cID := range res.Constraints {
+		c := &res.Constraints[cID]
+		rename(&c.L)
+		rename(&c.R)
+		rename(&c.O)
+		rename(&c.M[0])
+		rename(&c.M[1])
+	}
+
+	mHints := make(map[int]*compiled.Hint, len(res.MHints))
+	renamed := make(map[*compiled.Hint]*compiled.Hint)
+	for w, h := range res.MHints {
+		// the outputs of a hint share it
+		nh, ok := renamed[h]
+		if !ok {
+			nh = &compiled.Hint{ID: h.ID, Inputs: make([]interface{}, len(h.Inputs)), Wires: make([]int, len(h.Wires))}
+			for i, in := range h.Inputs {
+				switch t := in.(type) {
+				case compiled.LinearExpression:
+					le := t.Clone()
+					for j := range le {
+						rename(&le[j])
+					}
+					nh.Inputs[i] = le
+				case compiled.Term:
+					rename(&t)
+					nh.Inputs[i] = t
+				default:
+					nh.Inputs[i] = in
+				}
+			}
+			for i, hw := range h.Wires {
+				nh.Wires[i] = newIDs[hw]
+			}
+			renamed[h] = nh
+		}
+		mHints[newIDs[w]] = nh
+	}
+	res.MHints = mHints
+
+	for _, entries := range [][]compiled.LogEntry{res.Logs, res.DebugInfo} {
+		for i := range entries {
+			toResolve := make([]compiled.Term, len(entries[i].ToResolve))
+			copy(toResolve, entries[i].ToResolve)
+			for j := range toResolve {
+				rename(&toResolve[j])
+			}
+			entries[i].ToResolve = toResolve
+		}
+	}
+
+	res.NbInternalVariables = nbWires - o.nbInputs
+}
@@ -0,0 +1,101 @@
+package scs_test
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	bn254r1cs "github.com/consensys/gnark/internal/backend/bn254/cs"
+	"github.com/consensys/gnark/internal/backend/circuits"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOptimizeCircuits compiles the test circuits with and without WithOptimizations, and checks
+// that the optimized systems accept and reject the same assignments, keep their debug info on
+// the constraints, and are proven by plonk.
+func TestOptimizeCircuits(t *testing.T) {
+	names := make([]string, 0, len(circuits.Circuits))
+	for name := range circuits.Circuits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		tc := circuits.Circuits[name]
+		if !hasCurve(tc.Curves, ecc.BN254) {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			assert := require.New(t)
+			opt := backend.WithHints(tc.HintFunctions...)
+
+			ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, tc.Circuit)
+			assert.NoError(err)
+			var report frontend.OptimizationReport
+			optimized, err := frontend.Compile(ecc.BN254, scs.NewBuilder, tc.Circuit, frontend.WithOptimizations(&report))
+			assert.NoError(err)
+			assert.Equal(ccs.GetNbConstraints(), report.NbConstraintsBefore)
+			assert.Equal(optimized.GetNbConstraints(), report.NbConstraintsAfter)
+			assert.LessOrEqual(report.NbConstraintsAfter, report.NbConstraintsBefore)
+
+			// the debug info of the constraints are kept, and the constraints referencing them exist
+			spr, optimizedSpr := ccs.(*bn254r1cs.SparseR1CS), optimized.(*bn254r1cs.SparseR1CS)
+			assert.Equal(len(spr.DebugInfo), len(optimizedSpr.DebugInfo))
+			for cID, dID := range optimizedSpr.MDebug {
+				assert.Less(cID, len(optimizedSpr.Constraints))
+				assert.Less(dID, len(optimizedSpr.DebugInfo))
+			}
+
+			for _, assignment := range tc.ValidAssignments {
+				w, err := frontend.NewWitness(assignment, ecc.BN254)
+				assert.NoError(err)
+				assert.NoError(ccs.IsSolved(w, opt))
+				assert.NoError(optimized.IsSolved(w, opt))
+			}
+
+			for _, assignment := range tc.InvalidAssignments {
+				w, err := frontend.NewWitness(assignment, ecc.BN254)
+				assert.NoError(err)
+				err = ccs.IsSolved(w, opt)
+				assert.Error(err)
+				optimizedErr := optimized.IsSolved(w, opt)
+				assert.Error(optimizedErr)
+
+				// a failure located on an assertion is still reported with the debug info of one
+				var unsatisfiedErr *bn254r1cs.UnsatisfiedConstraintError
+				if errors.As(err, &unsatisfiedErr) && unsatisfiedErr.DebugInfo != nil {
+					assert.True(errors.As(optimizedErr, &unsatisfiedErr), optimizedErr)
+					assert.NotNil(unsatisfiedErr.DebugInfo, optimizedErr)
+				}
+			}
+
+			// the optimized system is proven with the first valid assignment
+			w, err := frontend.NewWitness(tc.ValidAssignments[0], ecc.BN254)
+			assert.NoError(err)
+			publicW, err := w.Public()
+			assert.NoError(err)
+			srs, err := test.NewKZGSRS(optimized)
+			assert.NoError(err)
+			pk, vk, err := plonk.Setup(optimized, srs)
+			assert.NoError(err)
+			proof, err := plonk.Prove(optimized, pk, w, opt)
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, publicW))
+		})
+	}
+}
+
+func hasCurve(curves []ecc.ID, curve ecc.ID) bool {
+	for _, c := range curves {
+		if c == curve {
+			return true
+		}
+	}
+	return false
+}
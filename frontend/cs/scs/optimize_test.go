@@ -0,0 +1,230 @@
+package scs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	bn254r1cs "github.com/consensys/gnark/internal/backend/bn254/cs"
+	"github.com/stretchr/testify/require"
+)
+
+type optimizeCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *optimizeCircuit) Define(api frontend.API) error {
+	// X + 5 = t, t + Y = a: merged in X + Y + 5 = a
+	a := api.Add(circuit.X, circuit.Y, 5)
+	api.AssertIsEqual(a, circuit.Z)
+	api.AssertIsEqual(a, circuit.Z)
+
+	// c = 7 is replaced in c * Y
+	c := api.Add(api.Mul(circuit.X, 3), 1)
+	api.AssertIsEqual(c, 7)
+	api.AssertIsEqual(api.Mul(c, circuit.Y), api.Mul(circuit.Y, 7))
+	return nil
+}
+
+func TestOptimize(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, NewBuilder, &optimizeCircuit{})
+	assert.NoError(err)
+
+	var report frontend.OptimizationReport
+	optimized, err := frontend.Compile(ecc.BN254, NewBuilder, &optimizeCircuit{}, frontend.WithOptimizations(&report))
+	assert.NoError(err)
+
+	assert.Equal(1, report.NbMerged)
+	assert.Equal(1, report.NbDuplicates)
+	assert.Equal(1, report.NbConstants)
+	assert.Equal(0, report.NbTrivial)
+	assert.Equal(ccs.GetNbConstraints(), report.NbConstraintsBefore)
+	assert.Equal(report.NbConstraintsBefore-2, report.NbConstraintsAfter)
+	assert.Equal(report.NbConstraintsAfter, optimized.GetNbConstraints())
+	assert.Equal(report.NbInternalBefore-1, report.NbInternalAfter)
+
+	newWitness := func(x, y, z int) *witness.Witness {
+		w, err := frontend.NewWitness(&optimizeCircuit{X: x, Y: y, Z: z}, ecc.BN254)
+		assert.NoError(err)
+		return w
+	}
+	for _, cs := range []frontend.CompiledConstraintSystem{ccs, optimized} {
+		assert.NoError(cs.IsSolved(newWitness(2, 3, 10)))
+		assert.Error(cs.IsSolved(newWitness(2, 3, 11)))
+		assert.Error(cs.IsSolved(newWitness(3, 3, 11)), "c != 7")
+	}
+}
+
+// passBuilder compiles a circuit running a single pass of the optimizer, followed by the
+// compaction of the constraints and wires it removed.
+type passBuilder struct {
+	*scs
+	pass func(o *optimizer)
+}
+
+func (b passBuilder) Compile() (frontend.CompiledConstraintSystem, error) {
+	res := compiled.SparseR1CS{
+		ConstraintSystem: b.ConstraintSystem,
+		Constraints:      b.Constraints,
+	}
+	o := newOptimizer(b.scs, &res)
+	b.pass(o)
+	o.compact()
+	res.Levels = buildLevels(res)
+	return bn254r1cs.NewSparseR1CS(res, b.st.Coeffs), nil
+}
+
+// compilePass compiles circuit on bn254 with the single pass run by pass.
+func compilePass(t *testing.T, circuit frontend.Circuit, pass func(o *optimizer)) *bn254r1cs.SparseR1CS {
+	ccs, err := frontend.Compile(ecc.BN254, func(curve ecc.ID, config frontend.CompileConfig) (frontend.Builder, error) {
+		return passBuilder{scs: newBuilder(curve, config), pass: pass}, nil
+	}, circuit)
+	require.NoError(t, err)
+	return ccs.(*bn254r1cs.SparseR1CS)
+}
+
+// checkPass checks that the assignments good solve ccs, and that bad fails on an assertion of
+// the Define method of circuitName.
+func checkPass(t *testing.T, ccs frontend.CompiledConstraintSystem, circuitName string, good, bad frontend.Circuit) {
+	w, err := frontend.NewWitness(good, ecc.BN254)
+	require.NoError(t, err)
+	require.NoError(t, ccs.IsSolved(w))
+
+	w, err = frontend.NewWitness(bad, ecc.BN254)
+	require.NoError(t, err)
+	err = ccs.IsSolved(w)
+	var unsatisfiedErr *bn254r1cs.UnsatisfiedConstraintError
+	require.True(t, errors.As(err, &unsatisfiedErr), err)
+	require.NotNil(t, unsatisfiedErr.DebugInfo, "the failing constraint has lost its debug info")
+	require.True(t, strings.HasPrefix(*unsatisfiedErr.DebugInfo, "[assertIsEqual]"), *unsatisfiedErr.DebugInfo)
+	require.True(t, strings.Contains(*unsatisfiedErr.DebugInfo, circuitName+").Define"), *unsatisfiedErr.DebugInfo)
+}
+
+type dedupCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *dedupCircuit) Define(api frontend.API) error {
+	a := api.Mul(circuit.X, circuit.Y)
+	api.AssertIsEqual(a, circuit.Z)
+	api.AssertIsEqual(a, circuit.Z)
+	return nil
+}
+
+func TestOptimizeRemoveDuplicates(t *testing.T) {
+	assert := require.New(t)
+
+	ccs := compilePass(t, &dedupCircuit{}, func(o *optimizer) {})
+	var nbDuplicates int
+	optimized := compilePass(t, &dedupCircuit{}, func(o *optimizer) { nbDuplicates = o.removeDuplicates() })
+
+	assert.Equal(1, nbDuplicates)
+	assert.Equal(len(ccs.Constraints)-1, len(optimized.Constraints))
+	assert.Equal(ccs.NbInternalVariables, optimized.NbInternalVariables)
+	checkPass(t, optimized, "dedupCircuit", &dedupCircuit{X: 2, Y: 3, Z: 6}, &dedupCircuit{X: 2, Y: 3, Z: 7})
+}
+
+type constantCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *constantCircuit) Define(api frontend.API) error {
+	// c = 7 is replaced in c * Y, and the second assertion is left with no wire
+	c := api.Add(api.Mul(circuit.X, 3), 1)
+	api.AssertIsEqual(c, 7)
+	api.AssertIsEqual(c, 7)
+	api.AssertIsEqual(api.Mul(c, circuit.Y), circuit.Z)
+	return nil
+}
+
+func TestOptimizePropagateConstants(t *testing.T) {
+	assert := require.New(t)
+
+	ccs := compilePass(t, &constantCircuit{}, func(o *optimizer) {})
+	var nbConstants, nbTrivial, nbMul int
+	optimized := compilePass(t, &constantCircuit{}, func(o *optimizer) {
+		nbConstants, nbTrivial = o.propagateConstants()
+		for cID, c := range o.res.Constraints {
+			if !o.removed[cID] && hasM(c) {
+				nbMul++
+			}
+		}
+	})
+
+	assert.Equal(1, nbConstants)
+	assert.Equal(1, nbTrivial)
+	assert.Equal(0, nbMul, "c * Y is now linear")
+	assert.Equal(len(ccs.Constraints)-1, len(optimized.Constraints))
+	checkPass(t, optimized, "constantCircuit", &constantCircuit{X: 2, Y: 3, Z: 21}, &constantCircuit{X: 2, Y: 3, Z: 22})
+
+	// the assertion on c is kept
+	w, err := frontend.NewWitness(&constantCircuit{X: 3, Y: 3, Z: 30}, ecc.BN254)
+	assert.NoError(err)
+	assert.Error(optimized.IsSolved(w))
+}
+
+type mergeCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *mergeCircuit) Define(api frontend.API) error {
+	// X + 5 = t, t + Y = a: merged in X + Y + 5 = a. a is read by the debug info of the
+	// assertion, so it is kept.
+	a := api.Add(circuit.X, circuit.Y, 5)
+	api.AssertIsEqual(a, circuit.Z)
+	return nil
+}
+
+func TestOptimizeMergeLinear(t *testing.T) {
+	assert := require.New(t)
+
+	ccs := compilePass(t, &mergeCircuit{}, func(o *optimizer) {})
+	var nbMerged int
+	optimized := compilePass(t, &mergeCircuit{}, func(o *optimizer) { nbMerged = o.mergeLinear() })
+
+	assert.Equal(1, nbMerged)
+	assert.Equal(len(ccs.Constraints)-1, len(optimized.Constraints))
+	assert.Equal(ccs.NbInternalVariables-1, optimized.NbInternalVariables, "t is dropped")
+	checkPass(t, optimized, "mergeCircuit", &mergeCircuit{X: 2, Y: 3, Z: 10}, &mergeCircuit{X: 2, Y: 3, Z: 11})
+}
+
+type compactCircuit struct {
+	X, Y, W frontend.Variable
+	Z       frontend.Variable `gnark:",public"`
+}
+
+func (circuit *compactCircuit) Define(api frontend.API) error {
+	// the first constraint computes X², which nothing reads
+	api.Mul(circuit.X, circuit.X)
+
+	// the wires after it are renamed, in the constraints, the hint of IsZero and the debug info
+	api.AssertIsEqual(api.IsZero(circuit.W), 1)
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.Y), circuit.Z)
+	return nil
+}
+
+func TestOptimizeCompact(t *testing.T) {
+	assert := require.New(t)
+
+	ccs := compilePass(t, &compactCircuit{}, func(o *optimizer) {})
+	optimized := compilePass(t, &compactCircuit{}, func(o *optimizer) { o.removed[0] = true })
+
+	assert.Equal(len(ccs.Constraints)-1, len(optimized.Constraints))
+	assert.Equal(ccs.NbInternalVariables-1, optimized.NbInternalVariables)
+	assert.Len(optimized.MHints, len(ccs.MHints))
+	assert.Len(optimized.MDebug, len(ccs.MDebug))
+	for cID, dID := range optimized.MDebug {
+		assert.Equal(ccs.MDebug[cID+1], dID, "the debug info follows its constraint")
+	}
+	checkPass(t, optimized, "compactCircuit", &compactCircuit{X: 2, Y: 3, W: 0, Z: 6}, &compactCircuit{X: 2, Y: 3, W: 0, Z: 7})
+}
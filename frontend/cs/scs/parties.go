@@ -0,0 +1,121 @@
+package scs
+
+import (
+	"errors"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+// layoutParties reorders res.Constraints following the placement of OnParty.
+//
+// The rows of the circuit, [ placeholders | constraints ], are split in blocks of n rows, one
+// per party, where n is the smallest power of 2 fitting the placed constraints of each party
+// and all the constraints. The constraints not placed fill the free rows, in order, and the
+// blocks are padded with empty constraints. Within a block, the constraints keep their order.
+//
+// res.Levels and res.MDebug are updated to the new constraint IDs.
+func (system *scs) layoutParties(res *compiled.SparseR1CS) error {
+	nbPublic := res.NbPublicVariables
+	if nbPublic+res.NbSecretVariables+res.NbInternalVariables == 0 {
+		return errors.New("constraints placed on parties need at least one wire for padding")
+	}
+
+	nbParties := 0
+	for _, p := range system.mParties {
+		if p+1 > nbParties {
+			nbParties = p + 1
+		}
+	}
+
+	// rows taken by the placed constraints; the placeholders are on party 0
+	nbRows := make([]int, nbParties)
+	nbRows[0] = nbPublic
+	for _, p := range system.mParties {
+		if p >= 0 {
+			nbRows[p]++
+		}
+	}
+	total := nbPublic + len(res.Constraints)
+	n := (total + nbParties - 1) / nbParties
+	for _, r := range nbRows {
+		if r > n {
+			n = r
+		}
+	}
+	n = int(ecc.NextPowerOfTwo(uint64(n)))
+
+	// the constraints not placed go to the first party with a free row
+	parties := make([]int, len(res.Constraints))
+	free := 0
+	for cID, p := range system.mParties {
+		if p < 0 {
+			for nbRows[free] == n {
+				free++
+			}
+			nbRows[free]++
+			p = free
+		}
+		parties[cID] = p
+	}
+
+	// new constraint ID of each constraint
+	next := make([]int, nbParties)
+	for p := range next {
+		next[p] = p*n - nbPublic
+	}
+	next[0] = 0
+	newIDs := make([]int, len(res.Constraints))
+	for cID, p := range parties {
+		newIDs[cID] = next[p]
+		next[p]++
+	}
+
+	// wire 0 with a zero coefficient in the padding constraints
+	visibility := schema.Internal
+	if nbPublic != 0 {
+		visibility = schema.Public
+	} else if res.NbSecretVariables != 0 {
+		visibility = schema.Secret
+	}
+	zero := compiled.Pack(0, compiled.CoeffIdZero, visibility)
+	padding := compiled.SparseR1C{L: zero, R: zero, O: zero, M: [2]compiled.Term{zero, zero}, K: compiled.CoeffIdZero}
+
+	constraints := make([]compiled.SparseR1C, nbParties*n-nbPublic)
+	isPadding := make([]bool, len(constraints))
+	for i := range isPadding {
+		isPadding[i] = true
+	}
+	for cID, c := range res.Constraints {
+		constraints[newIDs[cID]] = c
+		isPadding[newIDs[cID]] = false
+	}
+	var paddingIDs []int
+	for i := range constraints {
+		if isPadding[i] {
+			constraints[i] = padding
+			paddingIDs = append(paddingIDs, i)
+		}
+	}
+
+	for l := range res.Levels {
+		for i, cID := range res.Levels[l] {
+			res.Levels[l][i] = newIDs[cID]
+		}
+	}
+	if len(paddingIDs) != 0 {
+		// all the wires are solved by then
+		res.Levels = append(res.Levels, paddingIDs)
+	}
+
+	mDebug := make(map[int]int, len(res.MDebug))
+	for cID, dID := range res.MDebug {
+		mDebug[newIDs[cID]] = dID
+	}
+	res.MDebug = mDebug
+
+	res.Constraints = constraints
+	res.NbParties = nbParties
+	return nil
+}
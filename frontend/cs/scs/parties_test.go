@@ -0,0 +1,66 @@
+package scs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/backend/bn254/cs"
+	"github.com/stretchr/testify/require"
+)
+
+type partiesCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *partiesCircuit) Define(api frontend.API) error {
+	x := circuit.X
+	api.Compiler().OnParty(1, func(api frontend.API) {
+		for i := 0; i < 3; i++ {
+			x = api.Mul(x, x)
+		}
+	})
+	y := api.Mul(x, x)
+	api.Compiler().OnParty(0, func(api frontend.API) {
+		api.AssertIsEqual(y, circuit.Y)
+	})
+	return nil
+}
+
+func TestOnParty(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, NewBuilder, &partiesCircuit{})
+	assert.NoError(err)
+	spr := ccs.(*cs.SparseR1CS)
+
+	// party 0: [ Y placeholder | y = x⁸ * x⁸ | y == Y | padding ]
+	// party 1: [ x² | x⁴ | x⁸ | padding ]
+	assert.Equal(2, spr.NbParties)
+	assert.Equal(4, spr.NbRowsPerParty())
+	assert.Len(spr.Constraints, 7)
+	for i, o := range map[int]int{3: 2, 4: 3, 5: 4} {
+		assert.Equal(o, spr.Constraints[i].O.WireID(), "x is squared on party 1")
+	}
+	for _, i := range []int{2, 6} {
+		assert.Equal(0, spr.Constraints[i].M[0].CoeffID(), "padding")
+	}
+
+	newWitness := func(x, y int) *witness.Witness {
+		w, err := frontend.NewWitness(&partiesCircuit{X: x, Y: y}, ecc.BN254)
+		assert.NoError(err)
+		return w
+	}
+	assert.NoError(spr.IsSolved(newWitness(2, 1<<16)))
+
+	err = spr.IsSolved(newWitness(2, 1<<15))
+	var unsatisfiedErr *cs.UnsatisfiedConstraintError
+	assert.True(errors.As(err, &unsatisfiedErr))
+	assert.Equal(1, unsatisfiedErr.CID)
+	assert.NotNil(unsatisfiedErr.DebugInfo)
+	assert.True(strings.HasPrefix(*unsatisfiedErr.DebugInfo, "[assertIsEqual]"), *unsatisfiedErr.DebugInfo)
+}
@@ -0,0 +1,95 @@
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+// ErrSchemaMismatch is returned when the assignment of a party doesn't have the schema of the
+// replicated circuit
+var ErrSchemaMismatch = errors.New("assignment doesn't match the schema of the replicated circuit")
+
+// Replicated is a sub-circuit proven by each party of a data-parallel prover (piano).
+//
+// The sub-circuit is compiled once: the parties share its constraints, and so its selectors,
+// and each one proves it on its own assignment.
+type Replicated[C Circuit] struct {
+	CCS       CompiledConstraintSystem
+	NbParties int
+}
+
+// CompileReplicated compiles the sub-circuit proven by each of the nbParties parties of a
+// data-parallel prover. newBuilder should be scs.NewBuilder for piano. See Compile.
+//
+// nbParties must be a power of 2, as the size of the cluster of piano.
+func CompileReplicated[C Circuit](curveID ecc.ID, newBuilder NewBuilder, circuit C, nbParties int, opts ...CompileOption) (*Replicated[C], error) {
+	if nbParties <= 0 || nbParties&(nbParties-1) != 0 {
+		return nil, fmt.Errorf("the number of parties must be a power of 2, got %d", nbParties)
+	}
+	ccs, err := Compile(curveID, newBuilder, circuit, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Replicated[C]{CCS: ccs, NbParties: nbParties}, nil
+}
+
+// NewMultiWitness returns an empty set of witnesses, one per party of r.
+func (r *Replicated[C]) NewMultiWitness() *MultiWitness[C] {
+	return &MultiWitness[C]{
+		r:         r,
+		witnesses: make([]*witness.Witness, r.NbParties),
+	}
+}
+
+// MultiWitness holds the witnesses of the parties of a Replicated circuit, indexed by rank.
+type MultiWitness[C Circuit] struct {
+	r         *Replicated[C]
+	witnesses []*witness.Witness
+}
+
+// Assign builds the witness of the party of rank i from its assignment (see NewWitness).
+//
+// Returns ErrSchemaMismatch if the assignment doesn't have the schema of the sub-circuit,
+// for example if the length of an array differs.
+func (m *MultiWitness[C]) Assign(i int, assignment C, opts ...WitnessOption) error {
+	if i < 0 || i >= len(m.witnesses) {
+		return fmt.Errorf("invalid party %d, expected [0, %d)", i, len(m.witnesses))
+	}
+	w, err := NewWitness(assignment, m.r.CCS.CurveID(), opts...)
+	if err != nil {
+		return fmt.Errorf("party %d: %w", i, err)
+	}
+	if !sameSchema(w.Schema, m.r.CCS.GetSchema()) {
+		return fmt.Errorf("party %d: %w", i, ErrSchemaMismatch)
+	}
+	m.witnesses[i] = w
+	return nil
+}
+
+// Witness returns the witness of the party of rank i, to give to piano.Prove on that party.
+//
+// Returns an error if any party has no assignment: all of them must prove the sub-circuit.
+func (m *MultiWitness[C]) Witness(i int) (*witness.Witness, error) {
+	if i < 0 || i >= len(m.witnesses) {
+		return nil, fmt.Errorf("invalid party %d, expected [0, %d)", i, len(m.witnesses))
+	}
+	for j, w := range m.witnesses {
+		if w == nil {
+			return nil, fmt.Errorf("party %d has no assignment", j)
+		}
+	}
+	return m.witnesses[i], nil
+}
+
+// sameSchema reports whether the inputs described by a and b are the same
+func sameSchema(a, b *schema.Schema) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.NbPublic == b.NbPublic && a.NbSecret == b.NbSecret && reflect.DeepEqual(a.Fields, b.Fields)
+}
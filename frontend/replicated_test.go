@@ -0,0 +1,48 @@
+package frontend_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/stretchr/testify/require"
+)
+
+type replicatedCircuit struct {
+	X   []frontend.Variable
+	Sum frontend.Variable `gnark:",public"`
+}
+
+func (circuit *replicatedCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Add(circuit.X[0], circuit.X[1], circuit.X[2:]...), circuit.Sum)
+	return nil
+}
+
+func TestReplicated(t *testing.T) {
+	assert := require.New(t)
+
+	_, err := frontend.CompileReplicated(ecc.BN254, scs.NewBuilder, &replicatedCircuit{X: make([]frontend.Variable, 3)}, 3)
+	assert.Error(err, "3 parties")
+
+	r, err := frontend.CompileReplicated(ecc.BN254, scs.NewBuilder, &replicatedCircuit{X: make([]frontend.Variable, 3)}, 2)
+	assert.NoError(err)
+	assert.Equal(2, r.NbParties)
+
+	m := r.NewMultiWitness()
+	assert.NoError(m.Assign(0, &replicatedCircuit{X: []frontend.Variable{1, 2, 3}, Sum: 6}))
+	_, err = m.Witness(0)
+	assert.Error(err, "party 1 has no assignment")
+
+	err = m.Assign(1, &replicatedCircuit{X: []frontend.Variable{1, 2, 3, 4}, Sum: 10})
+	assert.True(errors.Is(err, frontend.ErrSchemaMismatch))
+	assert.Error(m.Assign(2, &replicatedCircuit{X: []frontend.Variable{1, 2, 3}, Sum: 6}))
+
+	assert.NoError(m.Assign(1, &replicatedCircuit{X: []frontend.Variable{4, 5, 6}, Sum: 15}))
+	for i := 0; i < r.NbParties; i++ {
+		w, err := m.Witness(i)
+		assert.NoError(err)
+		assert.NoError(r.CCS.IsSolved(w))
+	}
+}
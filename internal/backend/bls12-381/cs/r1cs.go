@@ -70,6 +70,19 @@ func (cs *R1CS) Solve(witness, a, b, c []fr.Element, opt backend.ProverConfig) (
 	log := logger.Logger().With().Str("curve", cs.CurveID().String()).Int("nbConstraints", len(cs.Constraints)).Str("backend", "groth16").Logger()
 
 	nbWires := cs.NbPublicVariables + cs.NbSecretVariables + cs.NbInternalVariables
+
+	// the solution was computed elsewhere (see backend.WithSolution): check it instead
+	if opt.Solution != nil {
+		start := time.Now()
+		values, err := cs.checkSolution(witness, opt.Solution, a, b, c)
+		if err != nil {
+			log.Err(err).Send()
+			return values, err
+		}
+		log.Debug().Dur("took", time.Since(start)).Msg("constraint system solution checked")
+		return values, nil
+	}
+
 	solution, err := newSolution(nbWires, opt.HintFunctions, cs.MHintsDependencies, cs.MHints, cs.Coefficients)
 	if err != nil {
 		return make([]fr.Element, nbWires), err
@@ -383,6 +396,61 @@ func (cs *R1CS) solveConstraint(r compiled.R1C, solution *solution, a, b, c *fr.
 	return nil
 }
 
+// checkSolution checks that the values of all the wires, given with backend.WithSolution, extend
+// the witness and satisfy every constraint, and returns them. It sets the a, b, c vectors.
+func (cs *R1CS) checkSolution(witness []fr.Element, vector witness.Vector, a, b, c []fr.Element) ([]fr.Element, error) {
+	v, ok := vector.(*bls12_381witness.Witness)
+	if !ok {
+		return nil, fmt.Errorf("invalid solution: expected a %T, got %T", v, vector)
+	}
+	values := []fr.Element(*v)
+	nbWires := cs.NbPublicVariables + cs.NbSecretVariables + cs.NbInternalVariables
+	if len(values) != nbWires {
+		return make([]fr.Element, nbWires), fmt.Errorf("invalid solution size, got %d, expected %d", len(values), nbWires)
+	}
+	if len(witness) != int(cs.NbPublicVariables-1+cs.NbSecretVariables) { // - 1 for ONE_WIRE
+		return values, fmt.Errorf("invalid witness size, got %d, expected %d = %d (public) + %d (secret)", len(witness), int(cs.NbPublicVariables-1+cs.NbSecretVariables), cs.NbPublicVariables-1, cs.NbSecretVariables)
+	}
+	if len(a) != len(cs.Constraints) || len(b) != len(cs.Constraints) || len(c) != len(cs.Constraints) {
+		return values, errors.New("invalid input size: len(a, b, c) == len(Constraints)")
+	}
+	var one fr.Element
+	if !values[0].Equal(one.SetOne()) {
+		return values, errors.New("invalid solution: the first wire must be 1")
+	}
+	for i := range witness {
+		if !values[i+1].Equal(&witness[i]) {
+			return values, fmt.Errorf("invalid solution: wire %d differs from the witness", i+1)
+		}
+	}
+
+	solution := solution{values: values, coefficients: cs.Coefficients}
+	var check fr.Element
+	for i, r := range cs.Constraints {
+		a[i].SetZero()
+		b[i].SetZero()
+		c[i].SetZero()
+		for _, t := range r.L {
+			solution.accumulateInto(t, &a[i])
+		}
+		for _, t := range r.R {
+			solution.accumulateInto(t, &b[i])
+		}
+		for _, t := range r.O {
+			solution.accumulateInto(t, &c[i])
+		}
+		if !check.Mul(&a[i], &b[i]).Equal(&c[i]) {
+			if dID, ok := cs.MDebug[i]; ok {
+				errMsg := solution.logValue(cs.DebugInfo[dID])
+				return values, &UnsatisfiedConstraintError{CID: i, DebugInfo: &errMsg}
+			}
+			err := fmt.Errorf("%s ⋅ %s != %s", a[i].String(), b[i].String(), c[i].String())
+			return values, &UnsatisfiedConstraintError{CID: i, Err: err}
+		}
+	}
+	return values, nil
+}
+
 // GetConstraints return a list of constraint formatted as L⋅R == O
 // such that [0] -> L, [1] -> R, [2] -> O
 func (cs *R1CS) GetConstraints() [][]string {
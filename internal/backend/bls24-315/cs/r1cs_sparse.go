@@ -68,14 +68,116 @@ func NewSparseR1CS(ccs compiled.SparseR1CS, coefficients []big.Int) *SparseR1CS
 func (cs *SparseR1CS) Solve(witness []fr.Element, opt backend.ProverConfig) ([]fr.Element, error) {
 	log := logger.Logger().With().Str("curve", cs.CurveID().String()).Int("nbConstraints", len(cs.Constraints)).Str("backend", "plonk").Logger()
 
-	// set the slices holding the solution.values and monitoring which variables have been solved
-	nbVariables := cs.NbInternalVariables + cs.NbSecretVariables + cs.NbPublicVariables
+	start := time.Now()
+
+	if err := cs.checkWitnessSize(witness); err != nil {
+		nbVariables := cs.NbInternalVariables + cs.NbSecretVariables + cs.NbPublicVariables
+		return make([]fr.Element, nbVariables), err
+	}
+
+	// the solution was computed elsewhere (see backend.WithSolution): check it instead
+	if opt.Solution != nil {
+		values, err := cs.checkSolution(witness, opt.Solution)
+		if err != nil {
+			log.Err(err).Send()
+			return values, err
+		}
+		log.Debug().Dur("took", time.Since(start)).Msg("constraint system solution checked")
+		return values, nil
+	}
+
+	values, err := cs.solve(witness, opt, cs.coefficientsNegInv(), cs.parallelSolve, nil)
+	if err != nil {
+		if unsatisfiedErr, ok := err.(*UnsatisfiedConstraintError); ok {
+			log.Err(errors.New("unsatisfied constraint")).Int("id", unsatisfiedErr.CID).Send()
+		} else {
+			log.Err(err).Send()
+		}
+		return values, err
+	}
+
+	log.Debug().Dur("took", time.Since(start)).Msg("constraint system solver done")
+
+	return values, nil
+
+}
+
+// SolveBatch solves the constraint system for several witnesses, such as the instances of a
+// data-parallel circuit. The inverses of the coefficients are computed once for all the
+// instances, which are solved following the level schedule: one after the other with the
+// constraints of a level spread over the CPUs if there are few instances, and concurrently
+// otherwise.
+// It returns the full slice of wires of every instance; errs[i] is the error of instance i, if any.
+// The logs of the circuit are printed instance by instance, with the index of the instance.
+func (cs *SparseR1CS) SolveBatch(witnesses [][]fr.Element, opt backend.ProverConfig) (solutions [][]fr.Element, errs []error) {
+	log := logger.Logger().With().Str("curve", cs.CurveID().String()).Int("nbConstraints", len(cs.Constraints)).Int("nbInstances", len(witnesses)).Str("backend", "plonk").Logger()
 
 	start := time.Now()
 
+	solutions = make([][]fr.Element, len(witnesses))
+	errs = make([]error, len(witnesses))
+	if opt.Solution != nil {
+		for i := range errs {
+			errs[i] = errors.New("a solution can't be given to a batch of witnesses")
+		}
+		return
+	}
+
+	coefficientsNegInv := cs.coefficientsNegInv()
+	var logsM sync.Mutex
+	solveInstance := func(i int, solver func(*solution, []fr.Element) error) {
+		if err := cs.checkWitnessSize(witnesses[i]); err != nil {
+			errs[i] = err
+			return
+		}
+		instanceOpt := opt
+		instanceOpt.CircuitLogger = opt.CircuitLogger.With().Int("instance", i).Logger()
+		solutions[i], errs[i] = cs.solve(witnesses[i], instanceOpt, coefficientsNegInv, solver, &logsM)
+	}
+
+	if len(witnesses) < runtime.NumCPU() {
+		for i := range witnesses {
+			solveInstance(i, cs.parallelSolve)
+		}
+	} else {
+		var wg sync.WaitGroup
+		chInstances := make(chan int, len(witnesses))
+		for i := range witnesses {
+			chInstances <- i
+		}
+		close(chInstances)
+		for w := 0; w < runtime.NumCPU(); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range chInstances {
+					solveInstance(i, cs.sequentialSolve)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	nbFailed := 0
+	for i := range errs {
+		if errs[i] != nil {
+			nbFailed++
+		}
+	}
+	log.Debug().Dur("took", time.Since(start)).Int("nbFailed", nbFailed).Msg("constraint system batch solver done")
+
+	return
+}
+
+// errSolverIncomplete is returned when the solver leaves wires without a value, which is a bug of
+// the solver or of the level schedule rather than of the witness.
+var errSolverIncomplete = errors.New("solver didn't instantiate all wires")
+
+// checkWitnessSize checks that the witness holds the public and secret inputs.
+func (cs *SparseR1CS) checkWitnessSize(witness []fr.Element) error {
 	expectedWitnessSize := int(cs.NbPublicVariables + cs.NbSecretVariables)
 	if len(witness) != expectedWitnessSize {
-		return make([]fr.Element, nbVariables), fmt.Errorf(
+		return fmt.Errorf(
 			"invalid witness size, got %d, expected %d = %d (public) + %d (secret)",
 			len(witness),
 			expectedWitnessSize,
@@ -83,6 +185,25 @@ func (cs *SparseR1CS) Solve(witness []fr.Element, opt backend.ProverConfig) ([]f
 			cs.NbSecretVariables,
 		)
 	}
+	return nil
+}
+
+// coefficientsNegInv returns the opposites of the inverses of the coefficients, batch inverted
+// to avoid many divisions in the solver.
+func (cs *SparseR1CS) coefficientsNegInv() []fr.Element {
+	res := fr.BatchInvert(cs.Coefficients)
+	for i := 0; i < len(res); i++ {
+		res[i].Neg(&res[i])
+	}
+	return res
+}
+
+// solve sets all the wires from the witness, solving the constraints with solver. The logs of
+// the circuit are then printed with opt.CircuitLogger, holding logsM if it is not nil: the
+// instances of a batch solved concurrently share the logger.
+func (cs *SparseR1CS) solve(witness []fr.Element, opt backend.ProverConfig, coefficientsNegInv []fr.Element, solver func(*solution, []fr.Element) error, logsM *sync.Mutex) ([]fr.Element, error) {
+	// set the slices holding the solution.values and monitoring which variables have been solved
+	nbVariables := cs.NbInternalVariables + cs.NbSecretVariables + cs.NbPublicVariables
 
 	// keep track of wire that have a value
 	solution, err := newSolution(nbVariables, opt.HintFunctions, cs.MHintsDependencies, cs.MHints, cs.Coefficients)
@@ -101,33 +222,43 @@ func (cs *SparseR1CS) Solve(witness []fr.Element, opt backend.ProverConfig) ([]f
 	solution.nbSolved += uint64(len(witness))
 
 	// defer log printing once all solution.values are computed
-	defer solution.printLogs(opt.CircuitLogger, cs.Logs)
-
-	// batch invert the coefficients to avoid many divisions in the solver
-	coefficientsNegInv := fr.BatchInvert(cs.Coefficients)
-	for i := 0; i < len(coefficientsNegInv); i++ {
-		coefficientsNegInv[i].Neg(&coefficientsNegInv[i])
-	}
-
-	if err := cs.parallelSolve(&solution, coefficientsNegInv); err != nil {
-		if unsatisfiedErr, ok := err.(*UnsatisfiedConstraintError); ok {
-			log.Err(errors.New("unsatisfied constraint")).Int("id", unsatisfiedErr.CID).Send()
-		} else {
-			log.Err(err).Send()
+	defer func() {
+		if logsM != nil {
+			logsM.Lock()
+			defer logsM.Unlock()
 		}
+		solution.printLogs(opt.CircuitLogger, cs.Logs)
+	}()
+
+	if err := solver(&solution, coefficientsNegInv); err != nil {
 		return solution.values, err
 	}
 
 	// sanity check; ensure all wires are marked as "instantiated"
 	if !solution.isValid() {
-		log.Err(errors.New("solver didn't instantiate all wires")).Send()
-		panic("solver didn't instantiate all wires")
+		return solution.values, errSolverIncomplete
 	}
 
-	log.Debug().Dur("took", time.Since(start)).Msg("constraint system solver done")
-
 	return solution.values, nil
+}
 
+// sequentialSolve solves the constraints level after level, on the calling goroutine.
+func (cs *SparseR1CS) sequentialSolve(solution *solution, coefficientsNegInv []fr.Element) error {
+	for _, level := range cs.Levels {
+		for _, i := range level {
+			if err := cs.solveConstraint(cs.Constraints[i], solution, coefficientsNegInv); err != nil {
+				return &UnsatisfiedConstraintError{CID: i, Err: err}
+			}
+			if err := cs.checkConstraint(cs.Constraints[i], solution); err != nil {
+				if dID, ok := cs.MDebug[i]; ok {
+					errMsg := solution.logValue(cs.DebugInfo[dID])
+					return &UnsatisfiedConstraintError{CID: i, DebugInfo: &errMsg}
+				}
+				return &UnsatisfiedConstraintError{CID: i, Err: err}
+			}
+		}
+	}
+	return nil
 }
 
 func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv []fr.Element) error {
@@ -467,6 +598,40 @@ func (cs *SparseR1CS) termToString(t compiled.Term, sbb *strings.Builder, vOnly
 	}
 }
 
+// checkSolution checks that the values of all the wires, given with backend.WithSolution, extend
+// the witness and satisfy every constraint, and returns them.
+func (cs *SparseR1CS) checkSolution(witness []fr.Element, vector witness.Vector) ([]fr.Element, error) {
+	v, ok := vector.(*bls24_315witness.Witness)
+	if !ok {
+		return nil, fmt.Errorf("invalid solution: expected a %T, got %T", v, vector)
+	}
+	values := []fr.Element(*v)
+	nbVariables := cs.NbInternalVariables + cs.NbSecretVariables + cs.NbPublicVariables
+	if len(values) != nbVariables {
+		return make([]fr.Element, nbVariables), fmt.Errorf("invalid solution size, got %d, expected %d", len(values), nbVariables)
+	}
+	for i := range witness {
+		if !values[i].Equal(&witness[i]) {
+			return values, fmt.Errorf("invalid solution: wire %d differs from the witness", i)
+		}
+	}
+
+	solution := solution{values: values, coefficients: cs.Coefficients, solved: make([]bool, nbVariables)}
+	for i := range solution.solved {
+		solution.solved[i] = true
+	}
+	for i := range cs.Constraints {
+		if err := cs.checkConstraint(cs.Constraints[i], &solution); err != nil {
+			if dID, ok := cs.MDebug[i]; ok {
+				errMsg := solution.logValue(cs.DebugInfo[dID])
+				return values, &UnsatisfiedConstraintError{CID: i, DebugInfo: &errMsg}
+			}
+			return values, &UnsatisfiedConstraintError{CID: i, Err: err}
+		}
+	}
+	return values, nil
+}
+
 // checkConstraint verifies that the constraint holds
 func (cs *SparseR1CS) checkConstraint(c compiled.SparseR1C, solution *solution) error {
 	l := solution.computeTerm(c.L)
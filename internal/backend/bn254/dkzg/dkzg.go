@@ -0,0 +1,452 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dkzg implements the distributed KZG commitments of piano and gpiano, on bn254.
+//
+// The M parties each hold a polynomial pᵢ(X) of the bivariate polynomial
+// f(X, Y) = Σᵢ pᵢ(X)Lᵢ(Y), where Lᵢ is the i-th Lagrange polynomial of the domain of size M on Y.
+// The SRS of the party of rank i is [τₓʲLᵢ(τᵧ)]₁ for j < size, so that the sum of the
+// commitments of the parties to their pᵢ is the commitment to f. An opening at X = α proves the
+// evaluations pᵢ(α), committed on Y: the claimed digest Σᵢ pᵢ(α)[Lᵢ(τᵧ)]₁ is the KZG commitment
+// to f(α, Y) in the SRS [τᵧʲ]₁.
+//
+// The parties exchange their parts through a cluster.Transport, in the star topology of
+// simpleMPI: the other parties send their parts to the master, which sums them. The results
+// (digests, proofs and evaluations) are only meaningful on the master. A party failing to receive
+// a message returns the error of its transport: with an in-process world (internal/mpisim), a
+// dropped message makes the party waiting for it fail after the timeout of the world. A corrupted
+// point is rejected when it is read; a corrupted scalar yields a proof that doesn't verify.
+package dkzg
+
+import (
+	"errors"
+	"hash"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+	"github.com/consensys/gnark-crypto/fiat-shamir"
+	"github.com/consensys/gnark/backend/cluster"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+)
+
+var (
+	ErrInvalidNbDigests          = errors.New("number of digests is not the same as the number of polynomials")
+	ErrInvalidPolynomialSize     = errors.New("invalid polynomial size (larger than SRS or == 0)")
+	ErrVerifyOpeningProof        = errors.New("can't verify opening proof")
+	ErrMinSRSSize                = errors.New("minimum srs size is 2")
+	ErrInvalidDomain             = errors.New("τᵧ is a root of unity of the domain on Y")
+	ErrInvalidRank               = errors.New("the rank is not in the domain on Y")
+	ErrMismatchedNbPointsProofs  = errors.New("the number of points and of opening proofs differ")
+	ErrMismatchedNbDigestsProofs = errors.New("the number of digests and of opening proofs differ")
+
+	errInvalidPoint = errors.New("the point is not in the subgroup")
+)
+
+// Digest commitment of a polynomial.
+type Digest = curve.G1Affine
+
+// SRS stores the result of the MPC of a party.
+type SRS struct {
+	G1 []curve.G1Affine  // [τₓʲLᵢ(τᵧ)]₁, i being the rank of the party
+	G2 [2]curve.G2Affine // [1]₂, [τₓ]₂
+}
+
+// OpeningProof KZG proof for opening the polynomials of the parties at a single point X = α.
+type OpeningProof struct {
+	// H quotient polynomial (f(X, Y) - f(α, Y))/(X - α)
+	H curve.G1Affine
+
+	// ClaimedDigest commitment to f(α, Y)
+	ClaimedDigest Digest
+}
+
+// BatchOpeningProof opening proof for many polynomials at the same point X = α.
+type BatchOpeningProof struct {
+	// H quotient polynomial Σⱼγʲ(fⱼ(X, Y) - fⱼ(α, Y))/(X - α)
+	H curve.G1Affine
+
+	// ClaimedDigests commitments to fⱼ(α, Y)
+	ClaimedDigests []Digest
+}
+
+// NewSRS returns the SRS of the party of the given rank, for polynomials of size at most size.
+//
+// domainY is the domain on Y, of one element per party, tauY and tauX the toxic waste: they
+// must be the same on every party.
+func NewSRS(size uint64, tauY, tauX *big.Int, domainY *fft.Domain, rank uint64) (*SRS, error) {
+	if size < 2 {
+		return nil, ErrMinSRSSize
+	}
+	if rank >= domainY.Cardinality {
+		return nil, ErrInvalidRank
+	}
+
+	// Lᵢ(τᵧ) = ωⁱ(τᵧᴹ - 1)/(M(τᵧ - ωⁱ))
+	var lagrange fr.Element
+	lagrange.SetOne()
+	if domainY.Cardinality > 1 {
+		var ty, omegaI, den fr.Element
+		ty.SetBigInt(tauY)
+		omegaI.Exp(domainY.Generator, new(big.Int).SetUint64(rank))
+		den.Sub(&ty, &omegaI)
+		if den.IsZero() {
+			return nil, ErrInvalidDomain
+		}
+		den.Mul(&den, new(fr.Element).SetUint64(domainY.Cardinality)).Inverse(&den)
+		lagrange.Exp(ty, new(big.Int).SetUint64(domainY.Cardinality))
+		lagrange.Sub(&lagrange, new(fr.Element).SetOne()).
+			Mul(&lagrange, &omegaI).
+			Mul(&lagrange, &den)
+	}
+
+	var srs SRS
+	var tx fr.Element
+	tx.SetBigInt(tauX)
+
+	_, _, gen1Aff, gen2Aff := curve.Generators()
+	srs.G2[0] = gen2Aff
+	srs.G2[1].ScalarMultiplication(&gen2Aff, tauX)
+
+	scalars := make([]fr.Element, size)
+	scalars[0] = lagrange
+	for i := 1; i < len(scalars); i++ {
+		scalars[i].Mul(&scalars[i-1], &tx)
+	}
+	for i := 0; i < len(scalars); i++ {
+		scalars[i].FromMont()
+	}
+	srs.G1 = curve.BatchScalarMultiplicationG1(&gen1Aff, scalars)
+
+	return &srs, nil
+}
+
+// Commit commits to the polynomial p of the party. On the master, the result is the commitment
+// to the polynomial of all the parties.
+func Commit(tr cluster.Transport, p []fr.Element, srs *SRS, nbTasks ...int) (Digest, error) {
+	if len(p) == 0 {
+		return Digest{}, ErrInvalidPolynomialSize
+	}
+	res, err := commit(p, srs, nbTasks...)
+	if err != nil {
+		return Digest{}, err
+	}
+	return sumG1(tr, res)
+}
+
+// Open opens the polynomial p of the party at X = point. On the master, it returns the opening
+// proof of the polynomial of all the parties and their evaluations, indexed by rank.
+func Open(tr cluster.Transport, p []fr.Element, point fr.Element, srs *SRS) (OpeningProof, []fr.Element, error) {
+	if len(p) == 0 || len(p) > len(srs.G1) {
+		return OpeningProof{}, nil, ErrInvalidPolynomialSize
+	}
+
+	claimedValue := eval(p, point)
+	h, err := commit(dividePolyByXminusA(p, claimedValue, point), srs)
+	if err != nil {
+		return OpeningProof{}, nil, err
+	}
+
+	var res OpeningProof
+	if res.H, err = sumG1(tr, h); err != nil {
+		return OpeningProof{}, nil, err
+	}
+	values, err := gatherFr(tr, []fr.Element{claimedValue})
+	if err != nil {
+		return OpeningProof{}, nil, err
+	}
+	if res.ClaimedDigest, err = sumG1(tr, claimedDigest(claimedValue, srs)); err != nil {
+		return OpeningProof{}, nil, err
+	}
+	if tr.Rank() != 0 {
+		return res, nil, nil
+	}
+
+	evaluations := make([]fr.Element, tr.Size())
+	for i := range evaluations {
+		evaluations[i] = values[i][0]
+	}
+	return res, evaluations, nil
+}
+
+// BatchOpenSinglePoint opens the polynomials of the party at X = point. On the master, it returns
+// the opening proof of the polynomials of all the parties and their evaluations, indexed by
+// polynomial then by rank.
+//
+// The master derives the folding challenge from the digests, which are only needed there, and
+// sends it to the other parties.
+func BatchOpenSinglePoint(tr cluster.Transport, polynomials [][]fr.Element, digests []Digest, point fr.Element, hf hash.Hash, srs *SRS) (BatchOpeningProof, [][]fr.Element, error) {
+	nbPolys := len(polynomials)
+	if nbPolys != len(digests) {
+		return BatchOpeningProof{}, nil, ErrInvalidNbDigests
+	}
+	largestPoly := -1
+	for _, p := range polynomials {
+		if len(p) == 0 || len(p) > len(srs.G1) {
+			return BatchOpeningProof{}, nil, ErrInvalidPolynomialSize
+		}
+		if len(p) > largestPoly {
+			largestPoly = len(p)
+		}
+	}
+
+	claimedValues := make([]fr.Element, nbPolys)
+	for i := range polynomials {
+		claimedValues[i] = eval(polynomials[i], point)
+	}
+	values, err := gatherFr(tr, claimedValues)
+	if err != nil {
+		return BatchOpeningProof{}, nil, err
+	}
+
+	var res BatchOpeningProof
+	res.ClaimedDigests = make([]Digest, nbPolys)
+	for i := range claimedValues {
+		if res.ClaimedDigests[i], err = sumG1(tr, claimedDigest(claimedValues[i], srs)); err != nil {
+			return BatchOpeningProof{}, nil, err
+		}
+	}
+
+	// the master derives the challenge to fold the polynomials
+	var gamma fr.Element
+	if tr.Rank() == 0 {
+		if gamma, err = deriveGamma(point, digests, res.ClaimedDigests, hf); err != nil {
+			return BatchOpeningProof{}, nil, err
+		}
+	}
+	if gamma, err = broadcastFr(tr, gamma); err != nil {
+		return BatchOpeningProof{}, nil, err
+	}
+
+	// fold the polynomials of the party and their evaluations
+	foldedPoly := make([]fr.Element, largestPoly)
+	copy(foldedPoly, polynomials[0])
+	foldedValue := claimedValues[0]
+	var gammaI fr.Element
+	gammaI.Set(&gamma)
+	var t fr.Element
+	for i := 1; i < nbPolys; i++ {
+		for j := 0; j < len(polynomials[i]); j++ {
+			t.Mul(&polynomials[i][j], &gammaI)
+			foldedPoly[j].Add(&foldedPoly[j], &t)
+		}
+		t.Mul(&claimedValues[i], &gammaI)
+		foldedValue.Add(&foldedValue, &t)
+		gammaI.Mul(&gammaI, &gamma)
+	}
+
+	h, err := commit(dividePolyByXminusA(foldedPoly, foldedValue, point), srs)
+	if err != nil {
+		return BatchOpeningProof{}, nil, err
+	}
+	if res.H, err = sumG1(tr, h); err != nil {
+		return BatchOpeningProof{}, nil, err
+	}
+	if tr.Rank() != 0 {
+		return res, nil, nil
+	}
+
+	evaluations := make([][]fr.Element, nbPolys)
+	for i := range evaluations {
+		evaluations[i] = make([]fr.Element, tr.Size())
+		for j := range evaluations[i] {
+			evaluations[i][j] = values[j][i]
+		}
+	}
+	return res, evaluations, nil
+}
+
+// FoldProof folds the digests and the proofs in batchOpeningProof using Fiat Shamir
+// to obtain an opening proof at a single point.
+//
+// * digests digests of the polynomials
+// * batchOpeningProof opening proof of the polynomials at X = point
+// * returns the folded version of batchOpeningProof, Digest, the folded version of digests
+func FoldProof(digests []Digest, batchOpeningProof *BatchOpeningProof, point fr.Element, hf hash.Hash) (OpeningProof, Digest, error) {
+	nbDigests := len(digests)
+
+	// check consistency between numbers of claims vs number of digests
+	if nbDigests != len(batchOpeningProof.ClaimedDigests) {
+		return OpeningProof{}, Digest{}, ErrInvalidNbDigests
+	}
+
+	// derive the challenge γ, binded to the point and the commitments
+	gamma, err := deriveGamma(point, digests, batchOpeningProof.ClaimedDigests, hf)
+	if err != nil {
+		return OpeningProof{}, Digest{}, err
+	}
+
+	// fold the claimed digests and the digests
+	gammai := make([]fr.Element, nbDigests)
+	gammai[0].SetOne()
+	for i := 1; i < nbDigests; i++ {
+		gammai[i].Mul(&gammai[i-1], &gamma)
+	}
+	var res OpeningProof
+	res.H.Set(&batchOpeningProof.H)
+	if _, err := res.ClaimedDigest.MultiExp(batchOpeningProof.ClaimedDigests, gammai, ecc.MultiExpConfig{ScalarsMont: true}); err != nil {
+		return OpeningProof{}, Digest{}, err
+	}
+	var foldedDigests Digest
+	if _, err := foldedDigests.MultiExp(digests, gammai, ecc.MultiExpConfig{ScalarsMont: true}); err != nil {
+		return OpeningProof{}, Digest{}, err
+	}
+
+	return res, foldedDigests, nil
+}
+
+// BatchVerifyMultiPoints batch verifies a list of opening proofs at different points.
+// The digests and proofs are all different, each proof opens its digest at X = points[i].
+func BatchVerifyMultiPoints(digests []Digest, proofs []OpeningProof, points []fr.Element, srs *SRS) error {
+	// check consistency nb proogs vs nb digests
+	if len(digests) != len(proofs) {
+		return ErrMismatchedNbDigestsProofs
+	}
+	if len(points) != len(proofs) {
+		return ErrMismatchedNbPointsProofs
+	}
+
+	// sample random numbers for sampling
+	randomNumbers := make([]fr.Element, len(digests))
+	randomNumbers[0].SetOne()
+	for i := 1; i < len(randomNumbers); i++ {
+		if _, err := randomNumbers[i].SetRandom(); err != nil {
+			return err
+		}
+	}
+
+	// e(Σᵢrᵢ(Cᵢ - Dᵢ + αᵢHᵢ), [1]₂) = e(ΣᵢrᵢHᵢ, [τₓ]₂)
+	var foldedLeft, foldedQuotients curve.G1Jac
+	for i := range digests {
+		var t, h curve.G1Jac
+		var bPoint, bRandom big.Int
+		points[i].ToBigIntRegular(&bPoint)
+		randomNumbers[i].ToBigIntRegular(&bRandom)
+
+		h.FromAffine(&proofs[i].H)
+		t.FromAffine(&digests[i])
+		t.SubAssign(new(curve.G1Jac).FromAffine(&proofs[i].ClaimedDigest))
+		t.AddAssign(new(curve.G1Jac).ScalarMultiplication(&h, &bPoint))
+		t.ScalarMultiplication(&t, &bRandom)
+		foldedLeft.AddAssign(&t)
+
+		h.ScalarMultiplication(&h, &bRandom)
+		foldedQuotients.AddAssign(&h)
+	}
+	foldedQuotients.Neg(&foldedQuotients)
+
+	var left, quotients curve.G1Affine
+	left.FromJacobian(&foldedLeft)
+	quotients.FromJacobian(&foldedQuotients)
+	check, err := curve.PairingCheck(
+		[]curve.G1Affine{left, quotients},
+		[]curve.G2Affine{srs.G2[0], srs.G2[1]},
+	)
+	if err != nil {
+		return err
+	}
+	if !check {
+		return ErrVerifyOpeningProof
+	}
+	return nil
+}
+
+// commit commits to p in the SRS of the party. The commitment to an empty polynomial (the
+// quotient of a constant) is the point at infinity.
+func commit(p []fr.Element, srs *SRS, nbTasks ...int) (Digest, error) {
+	if len(p) > len(srs.G1) {
+		return Digest{}, ErrInvalidPolynomialSize
+	}
+
+	var res Digest
+	if len(p) == 0 {
+		return res, nil
+	}
+	config := ecc.MultiExpConfig{ScalarsMont: true}
+	if len(nbTasks) > 0 {
+		config.NbTasks = nbTasks[0]
+	}
+	if _, err := res.MultiExp(srs.G1[:len(p)], p, config); err != nil {
+		return Digest{}, err
+	}
+	return res, nil
+}
+
+// claimedDigest returns the commitment to the evaluation v of the polynomial of the party, in
+// the SRS on Y: v[Lᵢ(τᵧ)]₁.
+func claimedDigest(v fr.Element, srs *SRS) Digest {
+	var bv big.Int
+	v.ToBigIntRegular(&bv)
+	var res Digest
+	res.ScalarMultiplication(&srs.G1[0], &bv)
+	return res
+}
+
+// deriveGamma derives the challenge to fold the polynomials opened at point.
+func deriveGamma(point fr.Element, digests, claimedDigests []Digest, hf hash.Hash) (fr.Element, error) {
+	// derive the challenge gamma, binded to the point and the commitments
+	fs := fiatshamir.NewTranscript(hf, "gamma")
+	if err := fs.Bind("gamma", point.Marshal()); err != nil {
+		return fr.Element{}, err
+	}
+	for i := range digests {
+		if err := fs.Bind("gamma", digests[i].Marshal()); err != nil {
+			return fr.Element{}, err
+		}
+	}
+	for i := range claimedDigests {
+		if err := fs.Bind("gamma", claimedDigests[i].Marshal()); err != nil {
+			return fr.Element{}, err
+		}
+	}
+	gammaByte, err := fs.ComputeChallenge("gamma")
+	if err != nil {
+		return fr.Element{}, err
+	}
+	var gamma fr.Element
+	gamma.SetBytes(gammaByte)
+
+	return gamma, nil
+}
+
+// eval evaluates p at point
+func eval(p []fr.Element, point fr.Element) fr.Element {
+	var res fr.Element
+	for i := len(p) - 1; i >= 0; i-- {
+		res.Mul(&res, &point).Add(&res, &p[i])
+	}
+	return res
+}
+
+// dividePolyByXminusA computes (f-f(a))/(x-a), in canonical basis, in regular form. f is left
+// untouched.
+func dividePolyByXminusA(f []fr.Element, fa, a fr.Element) []fr.Element {
+	res := make([]fr.Element, len(f))
+	copy(res, f)
+
+	// first we compute f-f(a)
+	res[0].Sub(&res[0], &fa)
+
+	// now we use syntetic division to divide by x-a
+	var t fr.Element
+	for i := len(res) - 2; i >= 0; i-- {
+		t.Mul(&res[i+1], &a)
+		res[i].Add(&res[i], &t)
+	}
+
+	// the result is of degree deg(f)-1
+	return res[1:]
+}
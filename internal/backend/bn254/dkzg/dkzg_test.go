@@ -0,0 +1,238 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dkzg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	"github.com/consensys/gnark/internal/mpisim"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	polySize     = 30
+	faultTimeout = 200 * time.Millisecond
+)
+
+var tauY, tauX = big.NewInt(1 << 42), big.NewInt(7777)
+
+// newSRSs returns the SRS of each party of a world of the given size, and the SRS on Y of the
+// master.
+func newSRSs(t *testing.T, size uint64) ([]*SRS, *kzg.SRS, *fft.Domain) {
+	domainY := fft.NewDomain(size)
+	srss := make([]*SRS, size)
+	for i := range srss {
+		var err error
+		srss[i], err = NewSRS(polySize+1, tauY, tauX, domainY, uint64(i))
+		require.NoError(t, err)
+	}
+	srsY, err := kzg.NewSRS(size+1, tauY)
+	require.NoError(t, err)
+	return srss, srsY, domainY
+}
+
+func randomPoly(t *testing.T) []fr.Element {
+	p := make([]fr.Element, polySize)
+	for i := range p {
+		_, err := p[i].SetRandom()
+		require.NoError(t, err)
+	}
+	return p
+}
+
+// commitY returns the KZG commitment on Y to the polynomial taking the evaluations at the
+// elements of domainY.
+func commitY(t *testing.T, evaluations []fr.Element, domainY *fft.Domain, srsY *kzg.SRS) kzg.Digest {
+	p := make([]fr.Element, len(evaluations))
+	copy(p, evaluations)
+	domainY.FFTInverse(p, fft.DIF)
+	fft.BitReverse(p)
+	d, err := kzg.Commit(p, srsY)
+	require.NoError(t, err)
+	return d
+}
+
+func TestOpen(t *testing.T) {
+	for _, size := range []uint64{1, 2, 4} {
+		t.Run(fmt.Sprintf("%d parties", size), func(t *testing.T) {
+			srss, srsY, domainY := newSRSs(t, size)
+			polys := make([][]fr.Element, size)
+			for i := range polys {
+				polys[i] = randomPoly(t)
+			}
+			var point fr.Element
+			_, err := point.SetRandom()
+			require.NoError(t, err)
+
+			world, err := mpisim.NewWorld(size)
+			require.NoError(t, err)
+			var digest Digest
+			var proof OpeningProof
+			var evaluations []fr.Element
+			errs := world.Run(func(p *mpisim.Party) error {
+				d, err := Commit(p, polys[p.Rank()], srss[p.Rank()])
+				if err != nil {
+					return err
+				}
+				pr, e, err := Open(p, polys[p.Rank()], point, srss[p.Rank()])
+				if p.Rank() == 0 {
+					digest, proof, evaluations = d, pr, e
+				}
+				return err
+			})
+			for _, err := range errs {
+				require.NoError(t, err)
+			}
+
+			require.Len(t, evaluations, int(size))
+			for i := range polys {
+				want := eval(polys[i], point)
+				require.True(t, evaluations[i].Equal(&want), "evaluation of party %d", i)
+			}
+			claimed := commitY(t, evaluations, domainY, srsY)
+			require.True(t, proof.ClaimedDigest.Equal(&claimed), "claimed digest")
+
+			require.NoError(t, BatchVerifyMultiPoints([]Digest{digest}, []OpeningProof{proof}, []fr.Element{point}, srss[0]))
+
+			// wrong point
+			var wrongPoint fr.Element
+			wrongPoint.Double(&point)
+			require.ErrorIs(t, BatchVerifyMultiPoints([]Digest{digest}, []OpeningProof{proof}, []fr.Element{wrongPoint}, srss[0]), ErrVerifyOpeningProof)
+		})
+	}
+}
+
+func TestBatchOpenSinglePoint(t *testing.T) {
+	const nbPolys = 5
+	for _, size := range []uint64{1, 2, 4} {
+		t.Run(fmt.Sprintf("%d parties", size), func(t *testing.T) {
+			srss, srsY, domainY := newSRSs(t, size)
+			polys := make([][][]fr.Element, size)
+			for i := range polys {
+				polys[i] = make([][]fr.Element, nbPolys)
+				for j := range polys[i] {
+					polys[i][j] = randomPoly(t)
+				}
+			}
+			var point fr.Element
+			_, err := point.SetRandom()
+			require.NoError(t, err)
+
+			world, err := mpisim.NewWorld(size)
+			require.NoError(t, err)
+			digests := make([]Digest, nbPolys)
+			var proof BatchOpeningProof
+			var evaluations [][]fr.Element
+			errs := world.Run(func(p *mpisim.Party) error {
+				partyDigests := make([]Digest, nbPolys)
+				for j := range partyDigests {
+					var err error
+					if partyDigests[j], err = Commit(p, polys[p.Rank()][j], srss[p.Rank()]); err != nil {
+						return err
+					}
+				}
+				pr, e, err := BatchOpenSinglePoint(p, polys[p.Rank()], partyDigests, point, sha256.New(), srss[p.Rank()])
+				if p.Rank() == 0 {
+					digests, proof, evaluations = partyDigests, pr, e
+				}
+				return err
+			})
+			for _, err := range errs {
+				require.NoError(t, err)
+			}
+
+			require.Len(t, evaluations, nbPolys)
+			for j := range evaluations {
+				require.Len(t, evaluations[j], int(size))
+				for i := range polys {
+					want := eval(polys[i][j], point)
+					require.True(t, evaluations[j][i].Equal(&want), "evaluation of polynomial %d of party %d", j, i)
+				}
+				claimed := commitY(t, evaluations[j], domainY, srsY)
+				require.True(t, proof.ClaimedDigests[j].Equal(&claimed), "claimed digest %d", j)
+			}
+
+			foldedProof, foldedDigest, err := FoldProof(digests, &proof, point, sha256.New())
+			require.NoError(t, err)
+			require.NoError(t, BatchVerifyMultiPoints([]Digest{foldedDigest}, []OpeningProof{foldedProof}, []fr.Element{point}, srss[0]))
+
+			// a wrong claimed digest
+			proof.ClaimedDigests[0], proof.ClaimedDigests[1] = proof.ClaimedDigests[1], proof.ClaimedDigests[0]
+			foldedProof, foldedDigest, err = FoldProof(digests, &proof, point, sha256.New())
+			require.NoError(t, err)
+			require.ErrorIs(t, BatchVerifyMultiPoints([]Digest{foldedDigest}, []OpeningProof{foldedProof}, []fr.Element{point}, srss[0]), ErrVerifyOpeningProof)
+		})
+	}
+}
+
+func TestFaults(t *testing.T) {
+	for _, size := range []uint64{2, 4} {
+		srss, _, _ := newSRSs(t, size)
+		last := size - 1
+		polys := make([][]fr.Element, size)
+		for i := range polys {
+			polys[i] = randomPoly(t)
+		}
+		var point fr.Element
+		point.SetUint64(3)
+
+		// each party commits, then opens: the messages of a party to the master are its
+		// commitment, then its quotient, its evaluation and its claimed digest
+		run := func(faults ...mpisim.Fault) []error {
+			world, err := mpisim.NewWorld(size, mpisim.WithTimeout(faultTimeout), mpisim.WithFaults(faults...))
+			require.NoError(t, err)
+			return world.Run(func(p *mpisim.Party) error {
+				if _, err := Commit(p, polys[p.Rank()], srss[p.Rank()]); err != nil {
+					return err
+				}
+				_, _, err := Open(p, polys[p.Rank()], point, srss[p.Rank()])
+				return err
+			})
+		}
+
+		t.Run(fmt.Sprintf("%d parties/drop", size), func(t *testing.T) {
+			errs := run(mpisim.Fault{Kind: mpisim.Drop, From: last, To: 0, Index: 1})
+			require.ErrorIs(t, errs[0], mpisim.ErrTimeout)
+		})
+
+		t.Run(fmt.Sprintf("%d parties/corrupt", size), func(t *testing.T) {
+			errs := run(mpisim.Fault{Kind: mpisim.Corrupt, From: last, To: 0, Index: 0})
+			require.Error(t, errs[0])
+		})
+	}
+}
+
+func TestSRSSerialization(t *testing.T) {
+	srs, err := NewSRS(polySize, tauY, tauX, fft.NewDomain(4), 2)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	written, err := srs.WriteTo(&buf)
+	require.NoError(t, err)
+
+	var reconstructed SRS
+	read, err := reconstructed.ReadFrom(&buf)
+	require.NoError(t, err)
+	require.Equal(t, written, read)
+	require.Equal(t, *srs, reconstructed)
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dkzg
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/cluster"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+)
+
+// sumG1 sums the points p of the parties on the master. The other parties send their point and
+// get it back.
+func sumG1(tr cluster.Transport, p curve.G1Affine) (curve.G1Affine, error) {
+	if tr.Rank() != 0 {
+		buf := p.RawBytes()
+		return p, tr.SendBytes(buf[:], 0)
+	}
+
+	var res curve.G1Jac
+	res.FromAffine(&p)
+	for i := uint64(1); i < tr.Size(); i++ {
+		buf, err := tr.ReceiveBytes(curve.SizeOfG1AffineUncompressed, i)
+		if err != nil {
+			return curve.G1Affine{}, err
+		}
+		var q curve.G1Affine
+		if _, err := q.SetBytes(buf); err != nil {
+			return curve.G1Affine{}, fmt.Errorf("point of party %d: %w", i, err)
+		}
+		if !q.IsInSubGroup() {
+			return curve.G1Affine{}, fmt.Errorf("point of party %d: %w", i, errInvalidPoint)
+		}
+		res.AddMixed(&q)
+	}
+	p.FromJacobian(&res)
+	return p, nil
+}
+
+// gatherFr gathers the scalars v of the parties on the master, indexed by rank. The other parties
+// send their scalars and get nil.
+func gatherFr(tr cluster.Transport, v []fr.Element) ([][]fr.Element, error) {
+	if tr.Rank() != 0 {
+		buf := make([]byte, 0, len(v)*fr.Bytes)
+		for i := range v {
+			b := v[i].Bytes()
+			buf = append(buf, b[:]...)
+		}
+		return nil, tr.SendBytes(buf, 0)
+	}
+
+	res := make([][]fr.Element, tr.Size())
+	res[0] = v
+	for i := uint64(1); i < tr.Size(); i++ {
+		buf, err := tr.ReceiveBytes(uint64(len(v)*fr.Bytes), i)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = make([]fr.Element, len(v))
+		for j := range res[i] {
+			res[i][j].SetBytes(buf[j*fr.Bytes : (j+1)*fr.Bytes])
+		}
+	}
+	return res, nil
+}
+
+// broadcastFr sends the scalar v of the master to the other parties, and returns it.
+func broadcastFr(tr cluster.Transport, v fr.Element) (fr.Element, error) {
+	if tr.Rank() != 0 {
+		buf, err := tr.ReceiveBytes(fr.Bytes, 0)
+		if err != nil {
+			return fr.Element{}, err
+		}
+		v.SetBytes(buf)
+		return v, nil
+	}
+
+	buf := v.Bytes()
+	for i := uint64(1); i < tr.Size(); i++ {
+		if err := tr.SendBytes(buf[:], i); err != nil {
+			return fr.Element{}, err
+		}
+	}
+	return v, nil
+}
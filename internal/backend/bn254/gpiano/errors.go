@@ -0,0 +1,189 @@
+package gpiano
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/cluster"
+	"github.com/consensys/gnark/internal/backend/bn254/cs"
+)
+
+// errProductNotOne is returned by computeWCanonicalY, on every party, when the
+// permutation accumulators of the parties don't multiply to one.
+var errProductNotOne = errors.New("the product of Z is not one")
+
+// Location is the position of a gate in the distributed circuit.
+type Location struct {
+	Party int // index of the party proving the gate
+	Row   int // row of the gate in the sub-circuit of the party
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("party %d, row %d", l.Party, l.Row)
+}
+
+// UnsatisfiedGateError is returned by Prove when the solution doesn't satisfy a gate.
+type UnsatisfiedGateError struct {
+	Location
+	Err *cs.UnsatisfiedConstraintError
+}
+
+func (e *UnsatisfiedGateError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Location, e.Err)
+}
+
+func (e *UnsatisfiedGateError) Unwrap() error {
+	return e.Err
+}
+
+// newUnsatisfiedGateError locates the constraint of err in spr, when each party
+// proves n rows. The rows of the circuit are [ placeholders | constraints | padding ],
+// split in consecutive blocks of n rows between the parties.
+func newUnsatisfiedGateError(spr *cs.SparseR1CS, n int, err *cs.UnsatisfiedConstraintError) *UnsatisfiedGateError {
+	row := spr.NbPublicVariables + err.CID
+	return &UnsatisfiedGateError{
+		Location: Location{Party: row / n, Row: row % n},
+		Err:      err,
+	}
+}
+
+// Cell is a cell of the L, R or O column of the distributed circuit.
+type Cell struct {
+	Location
+	Column string     // "L", "R" or "O"
+	Value  fr.Element // value held by the cell
+	CID    int        // constraint of the row, -1 for placeholders and padding
+	Stack  string     // stack trace of the constraint recorded at compile time, if any
+}
+
+func (c *Cell) String() string {
+	var sbb strings.Builder
+	sbb.WriteString(fmt.Sprintf("%s, %s = %s", c.Location, c.Column, c.Value.String()))
+	if c.CID >= 0 {
+		sbb.WriteString(fmt.Sprintf(" (constraint #%d)", c.CID))
+	}
+	if c.Stack != "" {
+		sbb.WriteByte('\n')
+		sbb.WriteString(c.Stack)
+	}
+	return sbb.String()
+}
+
+// UnsatisfiedCopyError is returned by Prove when two cells of the same wire
+// hold different values, which breaks the permutation cycle of the wire.
+type UnsatisfiedCopyError struct {
+	WireID int
+	Cells  [2]Cell
+}
+
+func (e *UnsatisfiedCopyError) Error() string {
+	return fmt.Sprintf("copy constraint of wire %d is not satisfied:\n%s\n%s", e.WireID, &e.Cells[0], &e.Cells[1])
+}
+
+// diagnoseCopyConstraints is run by all the parties once errProductNotOne is
+// detected. The parties send their l, r, o to the master, which finds a broken
+// permutation cycle.
+func diagnoseCopyConstraints(tr cluster.Transport, spr *cs.SparseR1CS, l, r, o []fr.Element) error {
+	n := len(l)
+	if tr.Rank() != 0 {
+		buf := make([]byte, 0, 3*n*fr.Bytes)
+		for _, column := range [][]fr.Element{l, r, o} {
+			for i := range column {
+				b := column[i].Bytes()
+				buf = append(buf, b[:]...)
+			}
+		}
+		if err := tr.SendBytes(buf, 0); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: the diagnosis is done by party 0", errProductNotOne)
+	}
+
+	var lro [3][]fr.Element
+	for j, column := range [][]fr.Element{l, r, o} {
+		lro[j] = make([]fr.Element, n*int(tr.Size()))
+		copy(lro[j], column)
+	}
+	for i := 1; i < int(tr.Size()); i++ {
+		buf, err := tr.ReceiveBytes(uint64(3*n*fr.Bytes), uint64(i))
+		if err != nil {
+			return err
+		}
+		for j := range lro {
+			for k := 0; k < n; k++ {
+				lro[j][i*n+k].SetBytes(buf[(j*n+k)*fr.Bytes : (j*n+k+1)*fr.Bytes])
+			}
+		}
+	}
+
+	if err := findBrokenCopy(spr, n, lro); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w, but the copy constraints are satisfied: do the parties prove the same circuit?", errProductNotOne)
+}
+
+// findBrokenCopy returns an UnsatisfiedCopyError for the first wire of spr
+// whose cells in lro don't hold the same value, or nil.
+//
+// lro are the columns of the whole circuit, made of blocks of n rows.
+func findBrokenCopy(spr *cs.SparseR1CS, n int, lro [3][]fr.Element) error {
+	nbRows := len(lro[0])
+	nbVariables := spr.NbInternalVariables + spr.NbPublicVariables + spr.NbSecretVariables
+
+	// wire and constraint of a cell, the layout is the one of buildPermutation
+	cell := func(column, row int) (wireID, cID int) {
+		switch {
+		case row < spr.NbPublicVariables:
+			if column == 0 {
+				return row, -1
+			}
+			return 0, -1
+		case row < spr.NbPublicVariables+len(spr.Constraints):
+			cID = row - spr.NbPublicVariables
+			c := spr.Constraints[cID]
+			return [3]int{c.L.WireID(), c.R.WireID(), c.O.WireID()}[column], cID
+		default:
+			return 0, -1
+		}
+	}
+	newCell := func(column, row, cID int) Cell {
+		c := Cell{
+			Location: Location{Party: row / n, Row: row % n},
+			Column:   [3]string{"L", "R", "O"}[column],
+			Value:    lro[column][row],
+			CID:      cID,
+		}
+		if dID, ok := spr.MDebug[cID]; ok && cID >= 0 {
+			format := spr.DebugInfo[dID].Format
+			c.Stack = format[strings.IndexByte(format, '\n')+1:]
+		}
+		return c
+	}
+
+	// first cell seen for each wire
+	first := make([]int, nbVariables)
+	for i := range first {
+		first[i] = -1
+	}
+	for column := 0; column < 3; column++ {
+		for row := 0; row < nbRows; row++ {
+			wireID, cID := cell(column, row)
+			if first[wireID] == -1 {
+				first[wireID] = column*nbRows + row
+				continue
+			}
+			fColumn, fRow := first[wireID]/nbRows, first[wireID]%nbRows
+			if lro[column][row].Equal(&lro[fColumn][fRow]) {
+				continue
+			}
+			_, fCID := cell(fColumn, fRow)
+			return &UnsatisfiedCopyError{
+				WireID: wireID,
+				Cells:  [2]Cell{newCell(fColumn, fRow, fCID), newCell(column, row, cID)},
+			}
+		}
+	}
+	return nil
+}
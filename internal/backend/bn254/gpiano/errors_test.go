@@ -0,0 +1,111 @@
+package gpiano
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/internal/backend/bn254/cs"
+	"github.com/stretchr/testify/require"
+)
+
+type copyCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *copyCircuit) Define(api frontend.API) error {
+	x := circuit.X
+	for i := 0; i < 4; i++ {
+		x = api.Mul(x, x)
+	}
+	api.AssertIsEqual(x, circuit.Y)
+	return nil
+}
+
+// copyColumns returns the L, R, O columns of nbRows rows of the solution of copyCircuit for
+// x = 2, y = 2^16.
+func copyColumns(t *testing.T, spr *cs.SparseR1CS, nbRows int) [3][]fr.Element {
+	witness := make([]fr.Element, 2)
+	witness[0].SetUint64(1 << 16)
+	witness[1].SetUint64(2)
+	solution, err := spr.Solve(witness, backend.ProverConfig{})
+	require.NoError(t, err)
+
+	require.LessOrEqual(t, spr.NbPublicVariables+len(spr.Constraints), nbRows)
+	var lro [3][]fr.Element
+	for j := range lro {
+		lro[j] = make([]fr.Element, nbRows)
+		for i := range lro[j] {
+			lro[j][i] = solution[0]
+		}
+	}
+	for i := 0; i < spr.NbPublicVariables; i++ {
+		lro[0][i] = solution[i]
+	}
+	for i, c := range spr.Constraints {
+		row := spr.NbPublicVariables + i
+		lro[0][row] = solution[c.L.WireID()]
+		lro[1][row] = solution[c.R.WireID()]
+		lro[2][row] = solution[c.O.WireID()]
+	}
+	return lro
+}
+
+func TestFindBrokenCopy(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &copyCircuit{})
+	assert.NoError(err)
+	spr := ccs.(*cs.SparseR1CS)
+
+	// 2 parties of 4 rows
+	const n = 4
+	lro := copyColumns(t, spr, 2*n)
+	assert.NoError(findBrokenCopy(spr, n, lro))
+
+	// the last constraint is the assertion, its L is the O of the previous one
+	last := len(spr.Constraints) - 1
+	row := spr.NbPublicVariables + last
+	lro[0][row].SetUint64(42)
+
+	// the columns are scanned one after the other: the tampered L is seen first
+	err = findBrokenCopy(spr, n, lro)
+	var copyErr *UnsatisfiedCopyError
+	assert.True(errors.As(err, &copyErr))
+	assert.Equal(spr.Constraints[last].L.WireID(), copyErr.WireID)
+	assert.Equal(Location{Party: row / n, Row: row % n}, copyErr.Cells[0].Location)
+	assert.Equal("L", copyErr.Cells[0].Column)
+	assert.Equal(last, copyErr.Cells[0].CID)
+	assert.True(strings.Contains(copyErr.Cells[0].Stack, "copyCircuit).Define"), copyErr.Cells[0].Stack)
+	assert.Equal(Location{Party: (row - 1) / n, Row: (row - 1) % n}, copyErr.Cells[1].Location)
+	assert.Equal("O", copyErr.Cells[1].Column)
+	assert.Equal(last-1, copyErr.Cells[1].CID)
+	assert.True(strings.Contains(err.Error(), "party 1, row 1, L = 42 (constraint #4)"), err.Error())
+}
+
+func TestUnsatisfiedGateError(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &copyCircuit{})
+	assert.NoError(err)
+	spr := ccs.(*cs.SparseR1CS)
+
+	witness := make([]fr.Element, 2)
+	witness[0].SetUint64(3)
+	witness[1].SetUint64(2)
+	_, err = spr.Solve(witness, backend.ProverConfig{})
+	var unsatisfiedErr *cs.UnsatisfiedConstraintError
+	assert.True(errors.As(err, &unsatisfiedErr))
+
+	gateErr := newUnsatisfiedGateError(spr, 4, unsatisfiedErr)
+	row := spr.NbPublicVariables + unsatisfiedErr.CID
+	assert.Equal(Location{Party: row / 4, Row: row % 4}, gateErr.Location)
+	assert.True(errors.Is(gateErr, unsatisfiedErr))
+	assert.True(strings.HasPrefix(gateErr.Error(), gateErr.Location.String()+": constraint #"))
+}
@@ -18,7 +18,7 @@ package gpiano_test
 
 import (
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
-	"github.com/sunblaze-ucb/simpleMPI/mpi"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
 
 	curve "github.com/consensys/gnark-crypto/ecc/bn254"
 
@@ -32,8 +32,8 @@ import (
 	"math/big"
 	"reflect"
 
-	"github.com/consensys/gnark-crypto/ecc/bn254/fr/dkzg"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	"github.com/consensys/gnark/internal/backend/bn254/dkzg"
 
 	// "testing"
 
@@ -83,12 +83,12 @@ func referenceCircuit() (frontend.CompiledConstraintSystem, frontend.Circuit, *d
 	}
 
 	good.Y = (expectedY)
-	dsrs, err := dkzg.NewSRS(ecc.NextPowerOfTwo(nbConstraints)+3, []*big.Int{new(big.Int).SetUint64(42), new(big.Int).SetUint64(42)}, nil)
+	dsrs, err := dkzg.NewSRS(ecc.NextPowerOfTwo(nbConstraints)+3, new(big.Int).SetUint64(42), new(big.Int).SetUint64(42), fft.NewDomain(2), 0)
 	if err != nil {
 		panic(err)
 	}
 
-	srs, err := kzg.NewSRS(ecc.NextPowerOfTwo(mpi.WorldSize), new(big.Int).SetUint64(42))
+	srs, err := kzg.NewSRS(2, new(big.Int).SetUint64(42))
 	if err != nil {
 		panic(err)
 	}
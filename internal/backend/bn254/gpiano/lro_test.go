@@ -0,0 +1,49 @@
+package gpiano
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+	"github.com/consensys/gnark/backend/solution"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/internal/backend/bn254/cs"
+	"github.com/stretchr/testify/require"
+
+	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
+)
+
+// TestLROLayout checks that solution.LRO returns the vectors the prover of each party commits to.
+func TestLROLayout(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &copyCircuit{})
+	require.NoError(t, err)
+	spr := ccs.(*cs.SparseR1CS)
+	w, err := frontend.NewWitness(&copyCircuit{X: 2, Y: 1 << 16}, ecc.BN254)
+	require.NoError(t, err)
+	s, err := solution.Solve(ccs, w)
+	require.NoError(t, err)
+	values := *s.Vector.(*bn254witness.Witness)
+
+	for _, nbParties := range []int{2, 4} {
+		// the domain of a party, as in Setup
+		nbRows := spr.NbPublicVariables + len(spr.Constraints)
+		var pk ProvingKey
+		pk.Domain[0] = *fft.NewDomain(uint64((nbRows + nbParties - 1) / nbParties))
+
+		for rank := 0; rank < nbParties; rank++ {
+			l, r, o := evaluateLROSmallDomainX(spr, &pk, uint64(rank), values)
+			gotL, gotR, gotO, err := solution.LRO(ccs, s, rank, nbParties)
+			require.NoError(t, err)
+			for j, v := range [][2][]fr.Element{
+				{l, *gotL.(*bn254witness.Witness)},
+				{r, *gotR.(*bn254witness.Witness)},
+				{o, *gotO.(*bn254witness.Witness)},
+			} {
+				require.Equal(t, v[0], v[1], fmt.Sprintf("vector %d of party %d of %d", j, rank, nbParties))
+			}
+		}
+	}
+}
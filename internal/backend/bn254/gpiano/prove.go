@@ -27,24 +27,21 @@ import (
 	"time"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
-	"github.com/consensys/gnark-crypto/ecc/bn254/fr/dkzg"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
 	"github.com/consensys/gnark-crypto/fiat-shamir"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/cluster"
+	"github.com/consensys/gnark/backend/metrics"
 	"github.com/consensys/gnark/internal/backend/bn254/cs"
+	"github.com/consensys/gnark/internal/backend/bn254/dkzg"
 	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gnark/logger"
-	"github.com/sunblaze-ucb/simpleMPI/mpi"
 
 	curve "github.com/consensys/gnark-crypto/ecc/bn254"
 	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
 )
 
-var (
-	World *mpi.MPIWorld
-)
-
 // Proof denotes a Piano proof generated from M parties each with N rows.
 type Proof struct {
 
@@ -83,12 +80,28 @@ type Proof struct {
 }
 
 // Prove from the public data
-func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness, opt backend.ProverConfig) (*Proof, error) {
+func Prove(spr *cs.SparseR1CS, tr cluster.Transport, pk *ProvingKey, fullWitness bn254witness.Witness, opt backend.ProverConfig) (*Proof, error) {
+	log := logger.Logger().With().Str("curve", spr.CurveID().String()).Int("nbConstraints", len(spr.Constraints)).Str("backend", "gpiano").Logger()
+	start := time.Now()
+	log.Debug().Msg("prover started")
+
+	var rec *metrics.Recorder
+	if opt.ProverMetrics != nil {
+		counter := cluster.NewCounter(tr)
+		tr = counter
+		rec = metrics.NewRecorder(counter)
+		defer rec.Stop()
+	}
+	rec.Start(metrics.Solve)
+
 	// compute the constraint system solution
 	var solution []fr.Element
 	var err error
 	if solution, err = spr.Solve(fullWitness, opt); err != nil {
 		if !opt.Force {
+			if unsatisfiedErr, ok := err.(*cs.UnsatisfiedConstraintError); ok {
+				return nil, newUnsatisfiedGateError(spr, int(pk.Domain[0].Cardinality), unsatisfiedErr)
+			}
 			return nil, err
 		} else {
 			// we need to fill solution with random values
@@ -101,10 +114,8 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 		}
 	}
 
-	fmt.Println("Solution computed")
-	fmt.Println("Prover started")
-	log := logger.Logger().With().Str("curve", spr.CurveID().String()).Int("nbConstraints", len(spr.Constraints)).Str("backend", "gpiano").Logger()
-	start := time.Now()
+	rec.Start(metrics.CommitLRO)
+
 	// pick a hash function that will be used to derive the challenges
 	hFunc := sha256.New()
 
@@ -115,7 +126,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	proof := &Proof{}
 
 	// query L, R, O in Lagrange basis, not blinded
-	lSmallX, rSmallX, oSmallX := evaluateLROSmallDomainX(spr, pk, solution)
+	lSmallX, rSmallX, oSmallX := evaluateLROSmallDomainX(spr, pk, tr.Rank(), solution)
 
 	// save lL, lR, lO, and make a copy of them in
 	// canonical basis note that we allocate more capacity to reuse for blinded
@@ -131,7 +142,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	}
 
 	// compute kzg commitments of bcL, bcR and bcO
-	if err := commitToLRO(lCanonicalX, rCanonicalX, oCanonicalX, proof, pk.Vk.DKZGSRS); err != nil {
+	if err := commitToLRO(tr, lCanonicalX, rCanonicalX, oCanonicalX, proof, pk.Vk.DKZGSRS); err != nil {
 		return nil, err
 	}
 
@@ -141,19 +152,21 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	if err := bindPublicData(&fs, "gamma", *pk.Vk, fullWitness[:spr.NbPublicVariables]); err != nil {
 		return nil, err
 	}
-	gamma, err := deriveRandomness(&fs, "gamma", false, &proof.LRO[0], &proof.LRO[1], &proof.LRO[2])
+	gamma, err := shareRandomness(tr, &fs, "gamma", &proof.LRO[0], &proof.LRO[1], &proof.LRO[2])
 	if err != nil {
 		return nil, err
 	}
 
+	rec.Start(metrics.ComputeZ)
+
 	// Fiat Shamir this
-	etaY, err := deriveRandomness(&fs, "etaY", false)
+	etaY, err := shareRandomness(tr, &fs, "etaY")
 	if err != nil {
 		return nil, err
 	}
 
 	// Fiat Shamir this
-	etaX, err := deriveRandomness(&fs, "etaX", false)
+	etaX, err := shareRandomness(tr, &fs, "etaX")
 	if err != nil {
 		return nil, err
 	}
@@ -165,13 +178,16 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 		lSmallX,
 		rSmallX,
 		oSmallX,
-		pk, etaY, etaX, gamma,
+		pk, tr.Rank(), etaY, etaX, gamma,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	wSmallY, wCanonicalY, pW, cW, err := computeWCanonicalY(selfProd)
+	wSmallY, wCanonicalY, pW, cW, err := computeWCanonicalY(tr, &pk.DomainY[0], selfProd)
+	if err == errProductNotOne {
+		return nil, diagnoseCopyConstraints(tr, spr, lSmallX, rSmallX, oSmallX)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -182,41 +198,45 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	// this may add additional arithmetic operations, but with smaller tasks
 	// we ensure that this commitment is well parallelized, without having a
 	// "unbalanced task" making the rest of the code wait too long
-	if proof.Z, err = dkzg.Commit(zCanonicalX, pk.Vk.DKZGSRS, runtime.NumCPU()*2); err != nil {
+	if proof.Z, err = dkzg.Commit(tr, zCanonicalX, pk.Vk.DKZGSRS, runtime.NumCPU()*2); err != nil {
 		return nil, err
 	}
-	if mpi.SelfRank == 0 {
-		if proof.W, err = kzg.Commit(wCanonicalY, globalSRS); err != nil {
+	if tr.Rank() == 0 {
+		if proof.W, err = kzg.Commit(wCanonicalY, pk.Vk.KZGSRS); err != nil {
 			return nil, err
 		}
 	}
 
 	// derive lambda from the Comm(L), Comm(R), Comm(O), Com(Z)
-	lambda, err := deriveRandomness(&fs, "lambda", false, &proof.Z, &proof.W)
+	lambda, err := shareRandomness(tr, &fs, "lambda", &proof.Z, &proof.W)
 	if err != nil {
 		return nil, err
 	}
 
-	hx1, hx2, hx3, hx4 := computeQuotientCanonicalX(pk, lCanonicalX, rCanonicalX, oCanonicalX, zCanonicalX, *pW, *cW, etaY, etaX, gamma, lambda)
+	rec.Start(metrics.QuotientX)
+	hx1, hx2, hx3, hx4 := computeQuotientCanonicalX(pk, tr.Rank(), lCanonicalX, rCanonicalX, oCanonicalX, zCanonicalX, *pW, *cW, etaY, etaX, gamma, lambda)
 
 	// print vector of hx1, hx2, hx3, hx4
 
 	// compute kzg commitments of Hx1, Hx2, Hx3, Hx4
-	if err := commitToQuotientX(hx1, hx2, hx3, hx4, proof, pk.Vk.DKZGSRS); err != nil {
+	if err := commitToQuotientX(tr, hx1, hx2, hx3, hx4, proof, pk.Vk.DKZGSRS); err != nil {
 		return nil, err
 	}
 
 	// derive alpha
-	alpha, err := deriveRandomness(&fs, "alpha", false, &proof.Hx[0], &proof.Hx[1], &proof.Hx[2], &proof.Hx[3])
+	alpha, err := shareRandomness(tr, &fs, "alpha", &proof.Hx[0], &proof.Hx[1], &proof.Hx[2], &proof.Hx[3])
 	if err != nil {
 		return nil, err
 	}
 
+	rec.Start(metrics.PartialOpenings)
+
 	// open Z at u*alpha
 	var alphaShifted fr.Element
 	alphaShifted.Mul(&alpha, &pk.Domain[0].Generator)
 	var zShiftedAlpha []fr.Element
 	proof.PartialZShiftedProof, zShiftedAlpha, err = dkzg.Open(
+		tr,
 		zCanonicalX,
 		alphaShifted,
 		pk.Vk.DKZGSRS,
@@ -292,6 +312,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	// Batch open the first list of polynomials
 	var evalsXOnAlpha [][]fr.Element
 	proof.PartialBatchedProof, evalsXOnAlpha, err = dkzg.BatchOpenSinglePoint(
+		tr,
 		dkzgOpeningPolys,
 		dkzgDigests,
 		alpha,
@@ -303,18 +324,35 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 		return nil, err
 	}
 
-	if mpi.SelfRank != 0 {
+	// the other parties are done: they send their metrics to the master
+	if tr.Rank() != 0 {
 		log.Debug().Dur("took", time.Since(start)).Msg("prover done")
 		if err != nil {
 			return nil, err
 		}
+		if opt.ProverMetrics != nil {
+			party := rec.Stop()
+			if err := metrics.Send(tr, party); err != nil {
+				return nil, err
+			}
+			opt.ProverMetrics(metrics.NewReport("gpiano", len(spr.Constraints), party))
+		}
 
 		return proof, nil
 	}
+	var parties []metrics.Party
+	if opt.ProverMetrics != nil {
+		rec.End()
+		if parties, err = metrics.Receive(tr); err != nil {
+			return nil, err
+		}
+	}
+	rec.Start(metrics.QuotientY)
 
 	// DBG check whether constraints are satisfied
 	if err := checkConstraintX(
 		pk,
+		int(tr.Size()),
 		evalsXOnAlpha,
 		zShiftedAlpha,
 		wSmallY,
@@ -329,7 +367,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 
 	polysCanonicalY := append(evalsXOnAlpha, zShiftedAlpha)
 	for i := 0; i < len(polysCanonicalY); i++ {
-		globalDomain[0].FFTInverse(polysCanonicalY[i], fft.DIF)
+		pk.DomainY[0].FFTInverse(polysCanonicalY[i], fft.DIF)
 		fft.BitReverse(polysCanonicalY[i])
 	}
 	polysCanonicalY = append(polysCanonicalY, wCanonicalY)
@@ -345,7 +383,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	)
 
 	// compute kzg commitments of Hy1, Hy2 and Hy3
-	if err := commitToQuotientOnY(hy1, hy2, hy3, hy4, proof, globalSRS); err != nil {
+	if err := commitToQuotientOnY(hy1, hy2, hy3, hy4, proof, pk.Vk.KZGSRS); err != nil {
 		return nil, err
 	}
 	// derive beta
@@ -358,14 +396,14 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	for _, digest := range proof.Hy {
 		ts = append(ts, &digest)
 	}
-	beta, err := deriveRandomness(&fs, "beta", true, ts...)
+	beta, err := deriveRandomness(&fs, "beta", ts...)
 	if err != nil {
 		return nil, err
 	}
 
 	// foldedHy = Hy1 + (beta**M)*Hy2 + (beta**(2M))*Hy3 + (beta**(3M))*Hy4
 	var bBetaPowerM big.Int
-	bSize.SetUint64(globalDomain[0].Cardinality)
+	bSize.SetUint64(pk.DomainY[0].Cardinality)
 	var betaPowerM fr.Element
 	betaPowerM.Exp(beta, &bSize)
 	betaPowerM.ToBigIntRegular(&bBetaPowerM)
@@ -392,7 +430,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 
 	evalsOnBeta := evalPolynomialsAtPoint(polysCanonicalY, beta)
 	var betaShifted fr.Element
-	betaShifted.Mul(&beta, &globalDomain[0].Generator)
+	betaShifted.Mul(&beta, &pk.DomainY[0].Generator)
 	// DBG check whether constraints are satisfied
 	if err := checkConstraintY(pk.Vk,
 		evalsOnBeta,
@@ -410,22 +448,27 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	var digestsY []curve.G1Affine
 	digestsY = append(digestsY, proof.PartialBatchedProof.ClaimedDigests...)
 	digestsY = append(digestsY, proof.PartialZShiftedProof.ClaimedDigest, proof.W, foldedHyDigest)
+	rec.Start(metrics.FinalOpening)
 	proof.BatchedProof, err = kzg.BatchOpenSinglePoint(
 		polysCanonicalY,
 		digestsY,
 		beta,
 		hFunc,
-		globalSRS,
+		pk.Vk.KZGSRS,
 	)
 	
 	proof.WShiftedProof, err = kzg.Open(
 		wCanonicalY,
 		betaShifted,
-		globalSRS,
+		pk.Vk.KZGSRS,
 	)
 	if err != nil {
 		return nil, err
 	}
+	log.Debug().Dur("took", time.Since(start)).Msg("prover done")
+	if opt.ProverMetrics != nil {
+		opt.ProverMetrics(metrics.NewReport("gpiano", len(spr.Constraints), append(parties, rec.Stop())...))
+	}
 	return proof, nil
 }
 
@@ -446,37 +489,37 @@ func evalPolynomialsAtPoint(polys [][]fr.Element, point fr.Element) []fr.Element
 	return res
 }
 
-func commitToLRO(bcl, bcr, bco []fr.Element, proof *Proof, srs *dkzg.SRS) error {
+func commitToLRO(tr cluster.Transport, bcl, bcr, bco []fr.Element, proof *Proof, srs *dkzg.SRS) error {
 	n := runtime.NumCPU() / 2
 	var err error
-	proof.LRO[0], err = dkzg.Commit(bcl, srs, n)
+	proof.LRO[0], err = dkzg.Commit(tr, bcl, srs, n)
 	if err != nil {
 		return err
 	}
-	proof.LRO[1], err = dkzg.Commit(bcr, srs, n)
+	proof.LRO[1], err = dkzg.Commit(tr, bcr, srs, n)
 	if err != nil {
 		return err
 	}
-	proof.LRO[2], err = dkzg.Commit(bco, srs, n)
+	proof.LRO[2], err = dkzg.Commit(tr, bco, srs, n)
 	return err
 }
 
-func commitToQuotientX(h1, h2, h3, h4 []fr.Element, proof *Proof, srs *dkzg.SRS) error {
+func commitToQuotientX(tr cluster.Transport, h1, h2, h3, h4 []fr.Element, proof *Proof, srs *dkzg.SRS) error {
 	n := runtime.NumCPU() / 2
 	var err error
-	proof.Hx[0], err = dkzg.Commit(h1, srs, n)
+	proof.Hx[0], err = dkzg.Commit(tr, h1, srs, n)
 	if err != nil {
 		return err
 	}
-	proof.Hx[1], err = dkzg.Commit(h2, srs, n)
+	proof.Hx[1], err = dkzg.Commit(tr, h2, srs, n)
 	if err != nil {
 		return err
 	}
-	proof.Hx[2], err = dkzg.Commit(h3, srs, n)
+	proof.Hx[2], err = dkzg.Commit(tr, h3, srs, n)
 	if err != nil {
 		return err
 	}
-	proof.Hx[3], err = dkzg.Commit(h4, srs, n)
+	proof.Hx[3], err = dkzg.Commit(tr, h4, srs, n)
 	return err
 }
 
@@ -555,7 +598,7 @@ func blindPoly(cp []fr.Element, rou, bo uint64) ([]fr.Element, error) {
 
 // evaluateLROSmallDomainX extracts the solution l, r, o, and returns it in lagrange form.
 // solution = [ public | secret | internal ]
-func evaluateLROSmallDomainX(spr *cs.SparseR1CS, pk *ProvingKey, solution []fr.Element) ([]fr.Element, []fr.Element, []fr.Element) {
+func evaluateLROSmallDomainX(spr *cs.SparseR1CS, pk *ProvingKey, rank uint64, solution []fr.Element) ([]fr.Element, []fr.Element, []fr.Element) {
 
 	n := int(pk.Domain[0].Cardinality)
 
@@ -570,7 +613,7 @@ func evaluateLROSmallDomainX(spr *cs.SparseR1CS, pk *ProvingKey, solution []fr.E
 	}
 
 	var offset int
-	if mpi.SelfRank == 0 {
+	if rank == 0 {
 		for i := 0; i < spr.NbPublicVariables; i++ { // placeholders
 			l[i].Set(&solution[i])
 			r[i] = s0
@@ -581,7 +624,7 @@ func evaluateLROSmallDomainX(spr *cs.SparseR1CS, pk *ProvingKey, solution []fr.E
 		offset = 0
 	}
 
-	start := int(mpi.SelfRank) * n + offset
+	start := int(rank) * n + offset
 	end := start - offset + n
 	if end > len(spr.Constraints) + spr.NbPublicVariables {
 		end = len(spr.Constraints) + spr.NbPublicVariables
@@ -615,7 +658,7 @@ func evaluateLROSmallDomainX(spr *cs.SparseR1CS, pk *ProvingKey, solution []fr.E
 //							         (l(g**k)+eta*s1(g**k)+gamma)*(r(g**k)+eta*s2(g**k)+gamma)*(o(g**k)+eta*s3(g**k)+gamma)
 //
 //	* l, r, o are the solution in Lagrange basis, evaluated on the small domain
-func computeZCanonicalX(l, r, o []fr.Element, pk *ProvingKey, etaY, etaX, gamma fr.Element) ([]fr.Element, fr.Element, error) {
+func computeZCanonicalX(l, r, o []fr.Element, pk *ProvingKey, rank uint64, etaY, etaX, gamma fr.Element) ([]fr.Element, fr.Element, error) {
 	// note that z has more capacity has its memory is reused for z later on
 	z := make([]fr.Element, pk.Domain[0].Cardinality + 1)
 	gInv := make([]fr.Element, pk.Domain[0].Cardinality + 1)
@@ -623,14 +666,14 @@ func computeZCanonicalX(l, r, o []fr.Element, pk *ProvingKey, etaY, etaX, gamma
 	z[0].SetOne()
 	gInv[0].SetOne()
 
-	IDys := getIDySmallDomain(globalDomain[0])
+	IDys := getIDySmallDomain(&pk.DomainY[0])
 	IDxs := getIDxSmallDomain(&pk.Domain[0])
 
 	var IDEtaY fr.Element
-	IDEtaY.Mul(&IDys[mpi.SelfRank], &etaY)
+	IDEtaY.Mul(&IDys[rank], &etaY)
 
 	// var IDEtaY2 fr.Element
-	// IDEtaY2.Exp(globalDomain[0].Generator, big.NewInt(int64(mpi.SelfRank))).Mul(&IDEtaY2, &etaY)
+	// IDEtaY2.Exp(pk.DomainY[0].Generator, big.NewInt(int64(rank))).Mul(&IDEtaY2, &etaY)
 	// if !IDEtaY.Equal(&IDEtaY2) {
 	// 	panic("IDEtaY != IDEtaY2")
 	// }
@@ -673,54 +716,63 @@ func computeZCanonicalX(l, r, o []fr.Element, pk *ProvingKey, etaY, etaX, gamma
 	return z[:n], z[n], nil
 }
 
-func computeWCanonicalY(selfProd fr.Element) ([]fr.Element, []fr.Element, *fr.Element, *fr.Element, error) {
-	if mpi.SelfRank == 0 {
-		W := make([]fr.Element, mpi.WorldSize + 1)
+func computeWCanonicalY(tr cluster.Transport, domainY *fft.Domain, selfProd fr.Element) ([]fr.Element, []fr.Element, *fr.Element, *fr.Element, error) {
+	if tr.Rank() == 0 {
+		W := make([]fr.Element, tr.Size() + 1)
 		W[0].SetOne()
 		W[1] = selfProd
-		for i := uint64(1); i < mpi.WorldSize; i++ {
-			recvBuf, err := mpi.ReceiveBytes(fr.Bytes, i)
+		for i := uint64(1); i < tr.Size(); i++ {
+			recvBuf, err := tr.ReceiveBytes(fr.Bytes, i)
 			if err != nil {
 				return nil, nil, nil, nil, err
 			}
 			W[i + 1].SetBytes(recvBuf)
 		}
-		for i := uint64(1); i < mpi.WorldSize; i++ {
+		for i := uint64(1); i < tr.Size(); i++ {
 			W[i + 1].Mul(&W[i + 1], &W[i])
 		}
-		// DBG: Check whether the product is one.
-		if !W[mpi.WorldSize].IsOne() {
-			// wait for 10 seconds to see the output
-			return nil, nil, nil, nil, fmt.Errorf("the product of Z is not one, got %v", W[mpi.WorldSize])
+		if !W[tr.Size()].IsOne() {
+			// W values are never zero otherwise: the other parties take it as a
+			// request to join the diagnosis
+			abort := make([]byte, 2*fr.Bytes)
+			for i := uint64(1); i < tr.Size(); i++ {
+				if err := tr.SendBytes(abort, i); err != nil {
+					return nil, nil, nil, nil, err
+				}
+			}
+			return nil, nil, nil, nil, errProductNotOne
 		}
-		for i := uint64(1); i < mpi.WorldSize; i++ {
+		for i := uint64(1); i < tr.Size(); i++ {
 			// concatenate W[i].Bytes() and W[i+1].Bytes()
 			a := W[i].Bytes()
 			b:= W[i + 1].Bytes()
 			sendBuf := make([]byte, len(a)+len(b))
 			copy(sendBuf, a[:])
 			copy(sendBuf[len(a):], b[:])
-			if err := mpi.SendBytes(sendBuf, i); err != nil {
+			if err := tr.SendBytes(sendBuf, i); err != nil {
 				return nil, nil, nil, nil, err
 			}
 		}
-		wCanonicalY := make([]fr.Element, mpi.WorldSize)
+		wCanonicalY := make([]fr.Element, tr.Size())
 		copy(wCanonicalY, W[:len(W) - 1])
-		globalDomain[0].FFTInverse(wCanonicalY, fft.DIF)
+		domainY.FFTInverse(wCanonicalY, fft.DIF)
 		fft.BitReverse(wCanonicalY)
 		return W[:len(W) - 1], wCanonicalY, &W[0], &W[1], nil
 	} else {
 		sendBuf := selfProd.Bytes()
-		if err := mpi.SendBytes(sendBuf[:], 0); err != nil {
+		if err := tr.SendBytes(sendBuf[:], 0); err != nil {
 			return nil, nil, nil, nil, err
 		}
-		recvBuf, err := mpi.ReceiveBytes(2 * fr.Bytes, 0)
+		recvBuf, err := tr.ReceiveBytes(2 * fr.Bytes, 0)
 		if err != nil {
 			return nil, nil, nil, nil, err
 		}
 		var l, r fr.Element
 		l.SetBytes(recvBuf[:fr.Bytes])
 		r.SetBytes(recvBuf[fr.Bytes:])
+		if l.IsZero() && r.IsZero() {
+			return nil, nil, nil, nil, errProductNotOne
+		}
 		return nil, nil, &l, &r, nil
 	}
 }
@@ -758,7 +810,7 @@ func evaluateXnMinusOneBig(domainBig, domainSmall *fft.Domain) []fr.Element {
 // )
 // + (lambda**2) * L0(X)*(z(X)-1)
 // = hx(X)Zn(X)
-func computeQuotientCanonicalX(pk *ProvingKey, lCanonicalX, rCanonicalX, oCanonicalX, zCanonicalX []fr.Element, pW, cW, etaY, etaX, gamma, lambda fr.Element) ([]fr.Element, []fr.Element, []fr.Element, []fr.Element) {
+func computeQuotientCanonicalX(pk *ProvingKey, rank uint64, lCanonicalX, rCanonicalX, oCanonicalX, zCanonicalX []fr.Element, pW, cW, etaY, etaX, gamma, lambda fr.Element) ([]fr.Element, []fr.Element, []fr.Element, []fr.Element) {
 	ratio := pk.Domain[1].Cardinality / pk.Domain[0].Cardinality
 
 	// Compute the power of domain[1].Generator with bit-reversed order.
@@ -774,7 +826,7 @@ func computeQuotientCanonicalX(pk *ProvingKey, lCanonicalX, rCanonicalX, oCanoni
 	cosetShiftX.Set(&pk.Vk.CosetShift)
 	cosetShiftSquareX.Mul(&cosetShiftX, &pk.Vk.CosetShift)
 	var IDEtaY fr.Element
-	IDEtaY.Exp(globalDomain[0].Generator, big.NewInt(int64(mpi.SelfRank))).Mul(&IDEtaY, &etaY)
+	IDEtaY.Exp(pk.DomainY[0].Generator, big.NewInt(int64(rank))).Mul(&IDEtaY, &etaY)
 
 	var one fr.Element
 	one.SetOne()
@@ -914,19 +966,19 @@ func computeQuotientCanonicalX(pk *ProvingKey, lCanonicalX, rCanonicalX, oCanoni
 // + lambda**3 * Ly0(Y)(W(Y) - 1)
 // - Hx(Y, alpha)Zn(X) = Hy(Y)Zm(Y)
 func computeQuotientCanonicalY(pk *ProvingKey, polys [][]fr.Element, etaY, etaX, gamma, lambda, alpha fr.Element) ([]fr.Element, []fr.Element, []fr.Element, []fr.Element) {
-	h := make([]fr.Element, globalDomain[1].Cardinality)
-	ratio := globalDomain[1].Cardinality / globalDomain[0].Cardinality
+	h := make([]fr.Element, pk.DomainY[1].Cardinality)
+	ratio := pk.DomainY[1].Cardinality / pk.DomainY[0].Cardinality
 
-	// Compute the power of globalDomain[1].Generator with bit-reversed order.
+	// Compute the power of pk.DomainY[1].Generator with bit-reversed order.
 	factorsBR := make([]fr.Element, ratio)
 	factorsBR[0].SetOne()
 	for i := 1; i < int(ratio); i++ {
-		factorsBR[i].Mul(&factorsBR[i-1], &globalDomain[1].Generator)
+		factorsBR[i].Mul(&factorsBR[i-1], &pk.DomainY[1].Generator)
 	}
 	fft.BitReverse(factorsBR)
 
 	// Variables needed in permutation constraint.
-	n := globalDomain[0].Cardinality
+	n := pk.DomainY[0].Cardinality
 	var IDEtaX, IDCosetShiftEtaX, IDCosetShiftSquareEtaX fr.Element
 	IDEtaX.Mul(&alpha, &etaX)
 	IDCosetShiftEtaX.Mul(&IDEtaX, &pk.Vk.CosetShift)
@@ -946,46 +998,46 @@ func computeQuotientCanonicalY(pk *ProvingKey, polys [][]fr.Element, etaY, etaX,
 	lxl.Mul(&lxl, &den).Mul(&lxl, &pk.Domain[0].GeneratorInv).Mul(&lxl, &pk.Domain[0].CardinalityInv)
 	oneMinusLxL.Sub(&one, &lxl)
 
-	LagY0 := make([]fr.Element, globalDomain[0].Cardinality)
-	for i := 0; i < int(globalDomain[0].Cardinality); i++ {
-		LagY0[i].Set(&globalDomain[0].CardinalityInv)
+	LagY0 := make([]fr.Element, pk.DomainY[0].Cardinality)
+	for i := 0; i < int(pk.DomainY[0].Cardinality); i++ {
+		LagY0[i].Set(&pk.DomainY[0].CardinalityInv)
 	}
 
 	var vanishingX fr.Element
 	vanishingX.Exp(alpha, big.NewInt(int64(pk.Domain[0].Cardinality)))
 	vanishingX.Sub(&vanishingX, &one)
 
-	nn := uint64(64 - bits.TrailingZeros64(uint64(globalDomain[0].Cardinality)))
+	nn := uint64(64 - bits.TrailingZeros64(uint64(pk.DomainY[0].Cardinality)))
 	for _j := 0; _j < int(ratio); _j++ {
 		// Compute FFT part for each polynomial.
-		foldedHx := globalDomain[0].FFTPart(polys[0], fft.DIF, factorsBR[_j], true)
-		l := globalDomain[0].FFTPart(polys[1], fft.DIF, factorsBR[_j], true)
-		r := globalDomain[0].FFTPart(polys[2], fft.DIF, factorsBR[_j], true)
-		o := globalDomain[0].FFTPart(polys[3], fft.DIF, factorsBR[_j], true)
-		ql := globalDomain[0].FFTPart(polys[4], fft.DIF, factorsBR[_j], true)
-		qr := globalDomain[0].FFTPart(polys[5], fft.DIF, factorsBR[_j], true)
-		qm := globalDomain[0].FFTPart(polys[6], fft.DIF, factorsBR[_j], true)
-		qo := globalDomain[0].FFTPart(polys[7], fft.DIF, factorsBR[_j], true)
-		qk := globalDomain[0].FFTPart(polys[8], fft.DIF, factorsBR[_j], true)
-		sy1 := globalDomain[0].FFTPart(polys[9], fft.DIF, factorsBR[_j], true)
-		sy2 := globalDomain[0].FFTPart(polys[10], fft.DIF, factorsBR[_j], true)
-		sy3 := globalDomain[0].FFTPart(polys[11], fft.DIF, factorsBR[_j], true)
-		sx1 := globalDomain[0].FFTPart(polys[12], fft.DIF, factorsBR[_j], true)
-		sx2 := globalDomain[0].FFTPart(polys[13], fft.DIF, factorsBR[_j], true)
-		sx3 := globalDomain[0].FFTPart(polys[14], fft.DIF, factorsBR[_j], true)
-		z := globalDomain[0].FFTPart(polys[15], fft.DIF, factorsBR[_j], true)
-		zs := globalDomain[0].FFTPart(polys[16], fft.DIF, factorsBR[_j], true)
-		w := globalDomain[0].FFTPart(polys[17], fft.DIF, factorsBR[_j], true)
-		ly0 := globalDomain[0].FFTPart(LagY0, fft.DIF, factorsBR[_j], true)
+		foldedHx := pk.DomainY[0].FFTPart(polys[0], fft.DIF, factorsBR[_j], true)
+		l := pk.DomainY[0].FFTPart(polys[1], fft.DIF, factorsBR[_j], true)
+		r := pk.DomainY[0].FFTPart(polys[2], fft.DIF, factorsBR[_j], true)
+		o := pk.DomainY[0].FFTPart(polys[3], fft.DIF, factorsBR[_j], true)
+		ql := pk.DomainY[0].FFTPart(polys[4], fft.DIF, factorsBR[_j], true)
+		qr := pk.DomainY[0].FFTPart(polys[5], fft.DIF, factorsBR[_j], true)
+		qm := pk.DomainY[0].FFTPart(polys[6], fft.DIF, factorsBR[_j], true)
+		qo := pk.DomainY[0].FFTPart(polys[7], fft.DIF, factorsBR[_j], true)
+		qk := pk.DomainY[0].FFTPart(polys[8], fft.DIF, factorsBR[_j], true)
+		sy1 := pk.DomainY[0].FFTPart(polys[9], fft.DIF, factorsBR[_j], true)
+		sy2 := pk.DomainY[0].FFTPart(polys[10], fft.DIF, factorsBR[_j], true)
+		sy3 := pk.DomainY[0].FFTPart(polys[11], fft.DIF, factorsBR[_j], true)
+		sx1 := pk.DomainY[0].FFTPart(polys[12], fft.DIF, factorsBR[_j], true)
+		sx2 := pk.DomainY[0].FFTPart(polys[13], fft.DIF, factorsBR[_j], true)
+		sx3 := pk.DomainY[0].FFTPart(polys[14], fft.DIF, factorsBR[_j], true)
+		z := pk.DomainY[0].FFTPart(polys[15], fft.DIF, factorsBR[_j], true)
+		zs := pk.DomainY[0].FFTPart(polys[16], fft.DIF, factorsBR[_j], true)
+		w := pk.DomainY[0].FFTPart(polys[17], fft.DIF, factorsBR[_j], true)
+		ly0 := pk.DomainY[0].FFTPart(LagY0, fft.DIF, factorsBR[_j], true)
 
 		hStart := uint64(_j) * n
 		utils.Parallelize(int(n), func(start, end int) {
 			var f, g, t [3]fr.Element
 			var t0, t1 fr.Element
 			var IDEtaY fr.Element
-			IDEtaY.Exp(globalDomain[0].Generator, big.NewInt(int64(start))).
+			IDEtaY.Exp(pk.DomainY[0].Generator, big.NewInt(int64(start))).
 				Mul(&IDEtaY, &factorsBR[_j]).
-				Mul(&IDEtaY, &globalDomain[1].FrMultiplicativeGen).Mul(&IDEtaY, &etaY)
+				Mul(&IDEtaY, &pk.DomainY[1].FrMultiplicativeGen).Mul(&IDEtaY, &etaY)
 			for i := uint64(start); i < uint64(end); i++ {
 				_i := bits.Reverse64(uint64(i)) >> nn
 				_is := bits.Reverse64(uint64((i + 1)) & (n - 1)) >> nn
@@ -1021,7 +1073,7 @@ func computeQuotientCanonicalY(pk *ProvingKey, polys [][]fr.Element, etaY, etaX,
 				t1.Mul(&g[0], &w[_is])
 				t1.Sub(&t1, &t0).Mul(&t1, &lxl)
 				h[hStart + _i].Add(&h[hStart + _i], &t1)
-				IDEtaY.Mul(&IDEtaY, &globalDomain[0].Generator)
+				IDEtaY.Mul(&IDEtaY, &pk.DomainY[0].Generator)
 
 				// Compute the gate constraint.
 				t1.Mul(&qm[_i], &r[_i])
@@ -1043,17 +1095,17 @@ func computeQuotientCanonicalY(pk *ProvingKey, polys [][]fr.Element, etaY, etaX,
 		})
 	}
 
-	evaluationYmMinusOneInverse := evaluateXnMinusOneBig(globalDomain[1], globalDomain[0])
+	evaluationYmMinusOneInverse := evaluateXnMinusOneBig(&pk.DomainY[1], &pk.DomainY[0])
 	evaluationYmMinusOneInverse = fr.BatchInvert(evaluationYmMinusOneInverse)
-	nn2 := uint64(64 - bits.TrailingZeros64(uint64(globalDomain[1].Cardinality)))
-	utils.Parallelize(int(globalDomain[1].Cardinality), func(start, end int) {
+	nn2 := uint64(64 - bits.TrailingZeros64(uint64(pk.DomainY[1].Cardinality)))
+	utils.Parallelize(int(pk.DomainY[1].Cardinality), func(start, end int) {
 		for _i := uint64(start); _i < uint64(end); _i++ {
 			i := bits.Reverse64(_i) >> nn2
 			h[_i].Mul(&h[_i], &evaluationYmMinusOneInverse[i % ratio])
 		}
 	})
 
-	globalDomain[1].FFTInverse(h, fft.DIT, true)
+	pk.DomainY[1].FFTInverse(h, fft.DIT, true)
 
 	h1 := h[:n]
 	h2 := h[n : 2*n]
@@ -1063,7 +1115,7 @@ func computeQuotientCanonicalY(pk *ProvingKey, polys [][]fr.Element, etaY, etaX,
 }
 
 // checkConstraintX checks that the constraint is satisfied
-func checkConstraintX(pk *ProvingKey, evalsXOnAlpha [][]fr.Element, zShiftedAlpha, wSmallY []fr.Element, etaY, etaX, gamma, lambda, alpha fr.Element) error {
+func checkConstraintX(pk *ProvingKey, nbParties int, evalsXOnAlpha [][]fr.Element, zShiftedAlpha, wSmallY []fr.Element, etaY, etaX, gamma, lambda, alpha fr.Element) error {
 	n := int64(pk.Domain[0].Cardinality)
 	var l0, ll, oneMinusLL, one, den fr.Element
 	one.SetOne()
@@ -1076,7 +1128,7 @@ func checkConstraintX(pk *ProvingKey, evalsXOnAlpha [][]fr.Element, zShiftedAlph
 	den.Sub(&alpha, &pk.Domain[0].GeneratorInv).Inverse(&den)
 	ll.Mul(&ll, &den).Mul(&ll, &pk.Domain[0].CardinalityInv).Mul(&ll, &pk.Domain[0].GeneratorInv)
 	oneMinusLL.Sub(&one, &ll)
-	for k := 0; k < int(mpi.WorldSize); k++ {
+	for k := 0; k < nbParties; k++ {
 		// unpack vector evalsXOnAlpha on hx, l, r, o, ql, qr, qm, qo, qk, s1, s2, s3, z
 		hx := evalsXOnAlpha[0][k]
 		l := evalsXOnAlpha[1][k]
@@ -1096,9 +1148,9 @@ func checkConstraintX(pk *ProvingKey, evalsXOnAlpha [][]fr.Element, zShiftedAlph
 		z := evalsXOnAlpha[15][k]
 		zs := zShiftedAlpha[k]
 		pw := wSmallY[k]
-		cw := wSmallY[(k + 1)%int(mpi.WorldSize)]
+		cw := wSmallY[(k + 1)%nbParties]
 		var IDEtaY fr.Element
-		IDEtaY.Exp(globalDomain[0].Generator, big.NewInt(int64(k))).Mul(&IDEtaY, &etaY)
+		IDEtaY.Exp(pk.DomainY[0].Generator, big.NewInt(int64(k))).Mul(&IDEtaY, &etaY)
 
 		// first part: individual constraints
 		var firstPart fr.Element
@@ -25,21 +25,16 @@ import (
 	"math/big"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
-	"github.com/consensys/gnark-crypto/ecc/bn254/fr/dkzg"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	"github.com/consensys/gnark/backend/cluster"
 	"github.com/consensys/gnark/internal/backend/bn254/cs"
-	"github.com/sunblaze-ucb/simpleMPI/mpi"
+	"github.com/consensys/gnark/internal/backend/bn254/dkzg"
 
-	dkzgg "github.com/consensys/gnark-crypto/dkzg"
+	kzgg "github.com/consensys/gnark-crypto/kzg"
 	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
 )
 
-var (
-	globalDomain [2]*fft.Domain
-	globalSRS    *kzg.SRS
-)
-
 // ProvingKey stores the data needed to generate a proof:
 // * the commitment scheme
 // * ql, prepended with as many ones as they are public inputs
@@ -65,6 +60,11 @@ type ProvingKey struct {
 	Domain [2]fft.Domain
 	// Domain[0], Domain[1] fft.Domain
 
+	// Domains used for the FFTs on Y, of one element per party.
+	// DomainY[0] = small Domain
+	// DomainY[1] = big Domain
+	DomainY [2]fft.Domain
+
 	// Permutation polynomials, indicate the index of sub-circuit for the next one.
 	Sy1Canonical, Sy2Canonical, Sy3Canonical     []fr.Element
 	// Permutation polynomials, indicate the specific row in some sub-circuit for the next one.
@@ -91,7 +91,8 @@ type VerifyingKey struct {
 	GeneratorXInv      fr.Element
 	NbPublicVariables uint64
 
-	// Commitment scheme that is used for an instantiation of PLONK
+	// Commitment scheme that is used for an instantiation of PLONK: the distributed KZG on X, of
+	// the party, and the KZG on Y, on the master only
 	DKZGSRS *dkzg.SRS
 	KZGSRS  *kzg.SRS
 	// cosetShift generator of the coset on the small domain
@@ -106,24 +107,31 @@ type VerifyingKey struct {
 }
 
 // Setup sets proving and verifying keys
-func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey, *VerifyingKey, error) {
-	globalDomain[0] = fft.NewDomain(mpi.WorldSize)
-	if globalDomain[0].Cardinality != mpi.WorldSize {
-		return nil, nil, fmt.Errorf("mpi.WorldSize is not a power of 2")
-	}
-	globalDomain[1] = fft.NewDomain(4 * mpi.WorldSize)
-
+func Setup(spr *cs.SparseR1CS, tr cluster.Transport, publicWitness bn254witness.Witness) (*ProvingKey, *VerifyingKey, error) {
 	var pk ProvingKey
 	var vk VerifyingKey
 
 	// The verifying key shares data with the proving key
 	pk.Vk = &vk
 
+	pk.DomainY[0] = *fft.NewDomain(tr.Size())
+	if pk.DomainY[0].Cardinality != tr.Size() {
+		return nil, nil, fmt.Errorf("the number of parties is not a power of 2")
+	}
+	pk.DomainY[1] = *fft.NewDomain(4 * tr.Size())
+
 	nbConstraints := len(spr.Constraints)
 
 	// fft domains
 	sizeSystem := int(nbConstraints + spr.NbPublicVariables) // spr.NbPublicVariables is for the placeholder constraints
-	sizeSystem = (sizeSystem + int(mpi.WorldSize) - 1) / int(mpi.WorldSize)
+	sizeSystem = (sizeSystem + int(tr.Size()) - 1) / int(tr.Size())
+	if spr.NbParties != 0 {
+		// the constraints were placed on the parties with frontend.Compiler.OnParty
+		if uint64(spr.NbParties) != tr.Size() {
+			return nil, nil, fmt.Errorf("the constraints are placed on %d parties, got %d", spr.NbParties, tr.Size())
+		}
+		sizeSystem = spr.NbRowsPerParty()
+	}
 
 	if sizeSystem < spr.NbPublicVariables {
 		return nil, nil, fmt.Errorf("public variables not in a single sub-circuit")
@@ -134,7 +142,7 @@ func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey,
 
 	var t, s *big.Int
 	var err error
-	if mpi.SelfRank == 0 {
+	if tr.Rank() == 0 {
 		var one fr.Element
 		one.SetOne()
 		for {
@@ -144,7 +152,7 @@ func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey,
 			}
 			var ele fr.Element
 			ele.SetBigInt(t)
-			if !ele.Exp(ele, big.NewInt(int64(globalDomain[0].Cardinality))).Equal(&one) {
+			if !ele.Exp(ele, big.NewInt(int64(pk.DomainY[0].Cardinality))).Equal(&one) {
 				break
 			}
 		}
@@ -159,64 +167,32 @@ func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey,
 				break
 			}
 		}
-		// send t and s to all other processes
-		tByteLen := (t.BitLen() + 7) / 8
-		sByteLen := (s.BitLen() + 7) / 8
-		for i := uint64(1); i < mpi.WorldSize; i++ {
-			if err := mpi.SendBytes([]byte{byte(tByteLen)}, i); err != nil {
-				return nil, nil, err
-			}
-			if err := mpi.SendBytes(t.Bytes(), i); err != nil {
-				return nil, nil, err
-			}
-			if err := mpi.SendBytes([]byte{byte(sByteLen)}, i); err != nil {
-				return nil, nil, err
-			}
-			if err := mpi.SendBytes(s.Bytes(), i); err != nil {
-				return nil, nil, err
-			}
-		}
-		globalSRS, err = kzg.NewSRS(globalDomain[0].Cardinality, t)
-		if err != nil {
-			return nil, nil, err
-		}
-	} else {
-		tByteLen, err := mpi.ReceiveBytes(1, 0)
-		if err != nil {
-			return nil, nil, err
-		}
-		tbytes, err := mpi.ReceiveBytes(uint64(tByteLen[0]), 0)
-		if err != nil {
-			return nil, nil, err
-		}
-		t = new(big.Int).SetBytes(tbytes)
-		sByteLen, err := mpi.ReceiveBytes(1, 0)
-		if err != nil {
+		if err := sendSecrets(tr, t, s); err != nil {
 			return nil, nil, err
 		}
-		sbytes, err := mpi.ReceiveBytes(uint64(sByteLen[0]), 0)
+		vk.KZGSRS, err = kzg.NewSRS(pk.DomainY[0].Cardinality, t)
 		if err != nil {
 			return nil, nil, err
 		}
-		s = new(big.Int).SetBytes(sbytes)
+	} else if t, s, err = receiveSecrets(tr); err != nil {
+		return nil, nil, err
 	}
-	vk.KZGSRS = globalSRS
 
 	// h, the quotient polynomial is of degree 3(n+1)+2, so it's in a 3(n+2) dim vector space,
 	// the domain is the next power of 2 superior to 3(n+2). 4*domainNum is enough in all cases
 	// except when n<6.
 	pk.Domain[1] = *fft.NewDomain(uint64(4 * sizeSystem))
 
-	vk.SizeY = globalDomain[0].Cardinality
-	vk.SizeYInv = globalDomain[0].CardinalityInv
+	vk.SizeY = pk.DomainY[0].Cardinality
+	vk.SizeYInv = pk.DomainY[0].CardinalityInv
 	vk.SizeX = pk.Domain[0].Cardinality
 	vk.SizeXInv = pk.Domain[0].CardinalityInv
-	vk.GeneratorY.Set(&globalDomain[0].Generator)
+	vk.GeneratorY.Set(&pk.DomainY[0].Generator)
 	vk.GeneratorX.Set(&pk.Domain[0].Generator)
 	vk.GeneratorXInv.Set(&pk.Domain[0].GeneratorInv)
 	vk.NbPublicVariables = uint64(spr.NbPublicVariables)
 
-	dkzgSRS, err := dkzg.NewSRS(vk.SizeX+3, []*big.Int{t, s}, &globalDomain[0].Generator)
+	dkzgSRS, err := dkzg.NewSRS(vk.SizeX+3, t, s, &pk.DomainY[0], tr.Rank())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -232,7 +208,7 @@ func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey,
 	pk.Qk = make([]fr.Element, pk.Domain[0].Cardinality)
 
 	var offset int
-	if mpi.SelfRank == 0 {
+	if tr.Rank() == 0 {
 		for i := 0; i < spr.NbPublicVariables; i++ { // placeholders (-PUB_INPUT_i + qk_i = 0) TODO should return error is size is inconsistant
 			pk.Ql[i].SetOne().Neg(&pk.Ql[i])
 			pk.Qr[i].SetZero()
@@ -246,7 +222,7 @@ func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey,
 	}
 	
 	sizeSystem = int(pk.Domain[0].Cardinality)
-	start := int(mpi.SelfRank) * sizeSystem + offset
+	start := int(tr.Rank()) * sizeSystem + offset
 	end := start - offset + sizeSystem
 	if end > len(spr.Constraints) + spr.NbPublicVariables {
 		end = len(spr.Constraints) + spr.NbPublicVariables
@@ -274,43 +250,43 @@ func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey,
 	fft.BitReverse(pk.Qk)
 
 	// build permutation. Note: at this stage, the permutation takes in account the placeholders
-	buildPermutation(spr, &pk)
+	buildPermutation(spr, &pk, tr.Rank(), tr.Size())
 
 	// set s1, s2, s3
 	ccomputePermutationPolynomials(&pk)
 
 	// Commit to the polynomials to set up the verifying key
-	if vk.Ql, err = dkzg.Commit(pk.Ql, vk.DKZGSRS); err != nil {
+	if vk.Ql, err = dkzg.Commit(tr, pk.Ql, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Qr, err = dkzg.Commit(pk.Qr, vk.DKZGSRS); err != nil {
+	if vk.Qr, err = dkzg.Commit(tr, pk.Qr, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Qm, err = dkzg.Commit(pk.Qm, vk.DKZGSRS); err != nil {
+	if vk.Qm, err = dkzg.Commit(tr, pk.Qm, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Qo, err = dkzg.Commit(pk.Qo, vk.DKZGSRS); err != nil {
+	if vk.Qo, err = dkzg.Commit(tr, pk.Qo, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Qk, err = dkzg.Commit(pk.Qk, vk.DKZGSRS); err != nil {
+	if vk.Qk, err = dkzg.Commit(tr, pk.Qk, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Sy[0], err = dkzg.Commit(pk.Sy1Canonical, vk.DKZGSRS); err != nil {
+	if vk.Sy[0], err = dkzg.Commit(tr, pk.Sy1Canonical, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Sy[1], err = dkzg.Commit(pk.Sy2Canonical, vk.DKZGSRS); err != nil {
+	if vk.Sy[1], err = dkzg.Commit(tr, pk.Sy2Canonical, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Sy[2], err = dkzg.Commit(pk.Sy3Canonical, vk.DKZGSRS); err != nil {
+	if vk.Sy[2], err = dkzg.Commit(tr, pk.Sy3Canonical, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Sx[0], err = dkzg.Commit(pk.Sx1Canonical, vk.DKZGSRS); err != nil {
+	if vk.Sx[0], err = dkzg.Commit(tr, pk.Sx1Canonical, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Sx[1], err = dkzg.Commit(pk.Sx2Canonical, vk.DKZGSRS); err != nil {
+	if vk.Sx[1], err = dkzg.Commit(tr, pk.Sx2Canonical, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Sx[2], err = dkzg.Commit(pk.Sx3Canonical, vk.DKZGSRS); err != nil {
+	if vk.Sx[2], err = dkzg.Commit(tr, pk.Sx3Canonical, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
 
@@ -329,10 +305,10 @@ func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey,
 // The permutation is encoded as a slice s of size 3*size(l), where the
 // i-th entry of l∥r∥o is sent to the s[i]-th entry, so it acts on a tab
 // like this: for i in tab: tab[i] = tab[permutation[i]]
-func buildPermutation(spr *cs.SparseR1CS, pk *ProvingKey) {
+func buildPermutation(spr *cs.SparseR1CS, pk *ProvingKey, rank, nbParties uint64) {
 	nbVariables := spr.NbInternalVariables + spr.NbPublicVariables + spr.NbSecretVariables
 	size := pk.Domain[0].Cardinality
-	totalSize := int(pk.Domain[0].Cardinality * mpi.WorldSize)
+	totalSize := int(pk.Domain[0].Cardinality * nbParties)
 
 	// init permutation
 	pk.PermutationY = make([]int64, 3*size)
@@ -382,7 +358,7 @@ func buildPermutation(spr *cs.SparseR1CS, pk *ProvingKey) {
 			// so we need to set the corresponding permutation index.
 			nY, nX := parseID(cycle[lro[i]])
 			cY, cX := parseID(int64(i))
-			if cY == int64(mpi.SelfRank) {
+			if cY == int64(rank) {
 				pk.PermutationY[cX] = nY
 				pk.PermutationX[cX] = nX
 			}
@@ -393,7 +369,7 @@ func buildPermutation(spr *cs.SparseR1CS, pk *ProvingKey) {
 	// complete the Permutation by filling the first IDs encountered
 	for i := 0; i < len(pk.PermutationY); i++ {
 		if pk.PermutationY[i] == -1 {
-			j := computeID(int64(mpi.SelfRank), int64(i))
+			j := computeID(int64(rank), int64(i))
 			pk.PermutationY[i], pk.PermutationX[i] = parseID(cycle[lro[j]])
 		}
 	}
@@ -413,7 +389,7 @@ func ccomputePermutationPolynomials(pk *ProvingKey) {
 	n := int(pk.Domain[0].Cardinality)
 
 	// Lagrange form of ID
-	IDys := getIDySmallDomain(globalDomain[0])
+	IDys := getIDySmallDomain(&pk.DomainY[0])
 	IDxs := getIDxSmallDomain(&pk.Domain[0])
 
 	// Lagrange form of S1, S2, S3
@@ -481,7 +457,7 @@ func getIDySmallDomain(domain *fft.Domain) []fr.Element {
 //
 // This should be used after deserializing a ProvingKey
 // as pk.Vk.KZG is NOT serialized
-func (pk *ProvingKey) InitKZG(srs dkzgg.SRS) error {
+func (pk *ProvingKey) InitKZG(srs kzgg.SRS) error {
 	return pk.Vk.InitKZG(srs)
 }
 
@@ -491,7 +467,7 @@ func (pk *ProvingKey) InitKZG(srs dkzgg.SRS) error {
 // as vk.KZG is NOT serialized
 //
 // Note that this instantiate a new FFT domain using vk.Size
-func (vk *VerifyingKey) InitKZG(srs dkzgg.SRS) error {
+func (vk *VerifyingKey) InitKZG(srs kzgg.SRS) error {
 	_srs := srs.(*dkzg.SRS)
 
 	if len(_srs.G1) < int(vk.SizeX) {
@@ -511,3 +487,36 @@ func (vk *VerifyingKey) NbPublicWitness() int {
 func (pk *ProvingKey) VerifyingKey() interface{} {
 	return pk.Vk
 }
+
+// sendSecrets sends the toxic waste t, s of the setup to the other parties, on the master.
+func sendSecrets(tr cluster.Transport, t, s *big.Int) error {
+	for i := uint64(1); i < tr.Size(); i++ {
+		for _, v := range []*big.Int{t, s} {
+			b := v.Bytes()
+			if err := tr.SendBytes([]byte{byte(len(b))}, i); err != nil {
+				return err
+			}
+			if err := tr.SendBytes(b, i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// receiveSecrets receives the toxic waste t, s sent by sendSecrets, on the other parties.
+func receiveSecrets(tr cluster.Transport) (t, s *big.Int, err error) {
+	var res [2]*big.Int
+	for i := range res {
+		size, err := tr.ReceiveBytes(1, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		b, err := tr.ReceiveBytes(uint64(size[0]), 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		res[i] = new(big.Int).SetBytes(b)
+	}
+	return res[0], res[1], nil
+}
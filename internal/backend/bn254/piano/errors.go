@@ -0,0 +1,41 @@
+package piano
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/internal/backend/bn254/cs"
+)
+
+// Location is the position of a gate in the distributed circuit.
+type Location struct {
+	Party int // index of the party proving the gate
+	Row   int // row of the gate in the sub-circuit of the party
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("party %d, row %d", l.Party, l.Row)
+}
+
+// UnsatisfiedGateError is returned by Prove when the solution doesn't satisfy a gate
+// of the sub-circuit of the party.
+type UnsatisfiedGateError struct {
+	Location
+	Err *cs.UnsatisfiedConstraintError
+}
+
+func (e *UnsatisfiedGateError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Location, e.Err)
+}
+
+func (e *UnsatisfiedGateError) Unwrap() error {
+	return e.Err
+}
+
+// newUnsatisfiedGateError locates the constraint of err in the sub-circuit spr of
+// the party of the given rank, whose rows are [ placeholders | constraints | padding ].
+func newUnsatisfiedGateError(spr *cs.SparseR1CS, rank uint64, err *cs.UnsatisfiedConstraintError) *UnsatisfiedGateError {
+	return &UnsatisfiedGateError{
+		Location: Location{Party: int(rank), Row: spr.NbPublicVariables + err.CID},
+		Err:      err,
+	}
+}
@@ -0,0 +1,38 @@
+package piano
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+	"github.com/consensys/gnark/backend/solution"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/internal/backend/bn254/cs"
+	"github.com/stretchr/testify/require"
+
+	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
+)
+
+// TestLROLayout checks that solution.LRO returns the vectors the prover of a party commits to.
+func TestLROLayout(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &squareCircuit{})
+	require.NoError(t, err)
+	spr := ccs.(*cs.SparseR1CS)
+	w, err := frontend.NewWitness(&squareCircuit{X: 2, Y: 1 << 16}, ecc.BN254)
+	require.NoError(t, err)
+	s, err := solution.Solve(ccs, w)
+	require.NoError(t, err)
+
+	// the domain of a party, as in Setup
+	var pk ProvingKey
+	pk.Domain[0] = *fft.NewDomain(uint64(spr.NbPublicVariables + len(spr.Constraints)))
+
+	l, r, o := evaluateLROSmallDomainX(spr, &pk, *s.Vector.(*bn254witness.Witness))
+	gotL, gotR, gotO, err := solution.LRO(ccs, s, 0, 1)
+	require.NoError(t, err)
+	require.Equal(t, l, []fr.Element(*gotL.(*bn254witness.Witness)), "l")
+	require.Equal(t, r, []fr.Element(*gotR.(*bn254witness.Witness)), "r")
+	require.Equal(t, o, []fr.Element(*gotO.(*bn254witness.Witness)), "o")
+}
@@ -19,16 +19,85 @@ package piano
 import (
 	"fmt"
 	"io"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
 )
 
 // WriteTo writes binary encoding of Proof to w
 func (proof *Proof) WriteTo(w io.Writer) (int64, error) {
-	return 0, fmt.Errorf("not implemented")
+	enc := curve.NewEncoder(w)
+
+	toEncode := []interface{}{
+		&proof.LRO[0],
+		&proof.LRO[1],
+		&proof.LRO[2],
+		&proof.Z,
+		&proof.Hx[0],
+		&proof.Hx[1],
+		&proof.Hx[2],
+		&proof.Hy[0],
+		&proof.Hy[1],
+		&proof.Hy[2],
+	}
+
+	for _, v := range toEncode {
+		if err := enc.Encode(v); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+
+	n := enc.BytesWritten()
+	m, err := proof.PartialBatchedProof.WriteTo(w)
+	n += m
+	if err != nil {
+		return n, err
+	}
+	m, err = proof.PartialZShiftedProof.WriteTo(w)
+	n += m
+	if err != nil {
+		return n, err
+	}
+	m, err = proof.BatchedProof.WriteTo(w)
+	n += m
+	return n, err
 }
 
 // ReadFrom reads binary representation of Proof from r
 func (proof *Proof) ReadFrom(r io.Reader) (int64, error) {
-	return 0, fmt.Errorf("not implemented")
+	dec := curve.NewDecoder(r)
+	toDecode := []interface{}{
+		&proof.LRO[0],
+		&proof.LRO[1],
+		&proof.LRO[2],
+		&proof.Z,
+		&proof.Hx[0],
+		&proof.Hx[1],
+		&proof.Hx[2],
+		&proof.Hy[0],
+		&proof.Hy[1],
+		&proof.Hy[2],
+	}
+
+	for _, v := range toDecode {
+		if err := dec.Decode(v); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+
+	n := dec.BytesRead()
+	m, err := proof.PartialBatchedProof.ReadFrom(r)
+	n += m
+	if err != nil {
+		return n, err
+	}
+	m, err = proof.PartialZShiftedProof.ReadFrom(r)
+	n += m
+	if err != nil {
+		return n, err
+	}
+	m, err = proof.BatchedProof.ReadFrom(r)
+	n += m
+	return n, err
 }
 
 // WriteTo writes binary encoding of ProvingKey to w
@@ -27,6 +27,8 @@ import (
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
 	"reflect"
 	"testing"
+
+	"github.com/consensys/gnark/internal/backend/bn254/dkzg"
 )
 
 func TestProvingKeySerialization(t *testing.T) {
@@ -126,3 +128,47 @@ func TestVerifyingKeySerialization(t *testing.T) {
 		t.Fatal("bytes written / read don't match")
 	}
 }
+
+func TestProofSerialization(t *testing.T) {
+	_, _, g1gen, _ := curve.Generators()
+
+	// a proof of points and scalars of the right shape
+	var proof Proof
+	for i := range proof.LRO {
+		proof.LRO[i] = g1gen
+	}
+	proof.Z = g1gen
+	for i := range proof.Hx {
+		proof.Hx[i] = g1gen
+		proof.Hy[i] = g1gen
+	}
+	proof.PartialBatchedProof.H = g1gen
+	proof.PartialBatchedProof.ClaimedDigests = []dkzg.Digest{g1gen, g1gen}
+	proof.PartialZShiftedProof.H = g1gen
+	proof.PartialZShiftedProof.ClaimedDigest = g1gen
+	proof.BatchedProof.H = g1gen
+	proof.BatchedProof.ClaimedValues = make([]fr.Element, 2)
+	proof.BatchedProof.ClaimedValues[0].SetUint64(42)
+	proof.BatchedProof.ClaimedValues[1] = fr.One()
+
+	var buf bytes.Buffer
+	written, err := proof.WriteTo(&buf)
+	if err != nil {
+		t.Fatal("coudln't serialize", err)
+	}
+
+	var reconstructed Proof
+
+	read, err := reconstructed.ReadFrom(&buf)
+	if err != nil {
+		t.Fatal("coudln't deserialize", err)
+	}
+
+	if !reflect.DeepEqual(&proof, &reconstructed) {
+		t.Fatal("reconstructed object don't match original")
+	}
+
+	if written != read {
+		t.Fatal("bytes written / read don't match")
+	}
+}
@@ -27,24 +27,21 @@ import (
 	"time"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
-	"github.com/consensys/gnark-crypto/ecc/bn254/fr/dkzg"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
 	"github.com/consensys/gnark-crypto/fiat-shamir"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/cluster"
+	"github.com/consensys/gnark/backend/metrics"
 	"github.com/consensys/gnark/internal/backend/bn254/cs"
+	"github.com/consensys/gnark/internal/backend/bn254/dkzg"
 	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gnark/logger"
-	"github.com/sunblaze-ucb/simpleMPI/mpi"
 
 	curve "github.com/consensys/gnark-crypto/ecc/bn254"
 	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
 )
 
-var (
-	World *mpi.MPIWorld
-)
-
 // Proof denotes a Piano proof generated from M parties each with N rows.
 type Proof struct {
 
@@ -78,11 +75,21 @@ type Proof struct {
 	BatchedProof kzg.BatchOpeningProof
 }
 
-// Prove from the public data
-func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness, opt backend.ProverConfig) (*Proof, error) {
-	fmt.Println("Prover started")
+// Prove from the public data. The parties exchange the challenges and the metrics through tr.
+func Prove(spr *cs.SparseR1CS, tr cluster.Transport, pk *ProvingKey, fullWitness bn254witness.Witness, opt backend.ProverConfig) (*Proof, error) {
 	log := logger.Logger().With().Str("curve", spr.CurveID().String()).Int("nbConstraints", len(spr.Constraints)).Str("backend", "piano").Logger()
 	start := time.Now()
+	log.Debug().Msg("prover started")
+
+	var rec *metrics.Recorder
+	if opt.ProverMetrics != nil {
+		counter := cluster.NewCounter(tr)
+		tr = counter
+		rec = metrics.NewRecorder(counter)
+		defer rec.Stop()
+	}
+	rec.Start(metrics.Solve)
+
 	// pick a hash function that will be used to derive the challenges
 	hFunc := sha256.New()
 
@@ -97,6 +104,9 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	var err error
 	if solution, err = spr.Solve(fullWitness, opt); err != nil {
 		if !opt.Force {
+			if unsatisfiedErr, ok := err.(*cs.UnsatisfiedConstraintError); ok {
+				return nil, newUnsatisfiedGateError(spr, tr.Rank(), unsatisfiedErr)
+			}
 			return nil, err
 		} else {
 			// we need to fill solution with random values
@@ -109,7 +119,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 		}
 	}
 
-	fmt.Println("Solution computed")
+	rec.Start(metrics.CommitLRO)
 
 	// query L, R, O in Lagrange basis, not blinded
 	lSmallX, rSmallX, oSmallX := evaluateLROSmallDomainX(spr, pk, solution)
@@ -128,7 +138,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	}
 
 	// compute kzg commitments of bcL, bcR and bcO
-	if err := commitToLRO(lCanonicalX, rCanonicalX, oCanonicalX, proof, pk.Vk.DKZGSRS); err != nil {
+	if err := commitToLRO(tr, lCanonicalX, rCanonicalX, oCanonicalX, proof, pk.Vk.DKZGSRS); err != nil {
 		return nil, err
 	}
 
@@ -138,13 +148,15 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	if err := bindPublicData(&fs, "gamma", *pk.Vk, fullWitness[:spr.NbPublicVariables]); err != nil {
 		return nil, err
 	}
-	gamma, err := deriveRandomness(&fs, "gamma", false, &proof.LRO[0], &proof.LRO[1], &proof.LRO[2])
+	gamma, err := shareRandomness(tr, &fs, "gamma", &proof.LRO[0], &proof.LRO[1], &proof.LRO[2])
 	if err != nil {
 		return nil, err
 	}
 
+	rec.Start(metrics.ComputeZ)
+
 	// Fiat Shamir this
-	eta, err := deriveRandomness(&fs, "eta", false)
+	eta, err := shareRandomness(tr, &fs, "eta")
 	if err != nil {
 		return nil, err
 	}
@@ -167,36 +179,40 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	// this may add additional arithmetic operations, but with smaller tasks
 	// we ensure that this commitment is well parallelized, without having a
 	// "unbalanced task" making the rest of the code wait too long
-	if proof.Z, err = dkzg.Commit(zCanonicalX, pk.Vk.DKZGSRS, runtime.NumCPU()*2); err != nil {
+	if proof.Z, err = dkzg.Commit(tr, zCanonicalX, pk.Vk.DKZGSRS, runtime.NumCPU()*2); err != nil {
 		return nil, err
 	}
 
 	// derive lambda from the Comm(L), Comm(R), Comm(O), Com(Z)
-	lambda, err := deriveRandomness(&fs, "lambda", false, &proof.Z)
+	lambda, err := shareRandomness(tr, &fs, "lambda", &proof.Z)
 	if err != nil {
 		return nil, err
 	}
 
+	rec.Start(metrics.QuotientX)
 	hx1, hx2, hx3 := computeQuotientCanonicalX(pk, lCanonicalX, rCanonicalX, oCanonicalX, zCanonicalX, eta, gamma, lambda)
 
 	// print vector of hx1, hx2, hx3
 
 	// compute kzg commitments of Hx1, Hx2 and Hx3
-	if err := commitToQuotientX(hx1, hx2, hx3, proof, pk.Vk.DKZGSRS); err != nil {
+	if err := commitToQuotientX(tr, hx1, hx2, hx3, proof, pk.Vk.DKZGSRS); err != nil {
 		return nil, err
 	}
 
 	// derive alpha
-	alpha, err := deriveRandomness(&fs, "alpha", false, &proof.Hx[0], &proof.Hx[1], &proof.Hx[2])
+	alpha, err := shareRandomness(tr, &fs, "alpha", &proof.Hx[0], &proof.Hx[1], &proof.Hx[2])
 	if err != nil {
 		return nil, err
 	}
 
+	rec.Start(metrics.PartialOpenings)
+
 	// open Z at mu*alpha
 	var alphaShifted fr.Element
 	alphaShifted.Mul(&alpha, &pk.Vk.Generator)
 	var zShiftedAlpha []fr.Element
 	proof.PartialZShiftedProof, zShiftedAlpha, err = dkzg.Open(
+		tr,
 		zCanonicalX,
 		alphaShifted,
 		pk.Vk.DKZGSRS,
@@ -262,6 +278,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	// Batch open the first list of polynomials
 	var evalsXOnAlpha [][]fr.Element
 	proof.PartialBatchedProof, evalsXOnAlpha, err = dkzg.BatchOpenSinglePoint(
+		tr,
 		dkzgOpeningPolys,
 		dkzgDigests,
 		alpha,
@@ -273,18 +290,35 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 		return nil, err
 	}
 
-	if mpi.SelfRank != 0 {
+	// the other parties are done: they send their metrics to the master
+	if tr.Rank() != 0 {
 		log.Debug().Dur("took", time.Since(start)).Msg("prover done")
 		if err != nil {
 			return nil, err
 		}
+		if opt.ProverMetrics != nil {
+			party := rec.Stop()
+			if err := metrics.Send(tr, party); err != nil {
+				return nil, err
+			}
+			opt.ProverMetrics(metrics.NewReport("piano", len(spr.Constraints), party))
+		}
 
 		return proof, nil
 	}
+	var parties []metrics.Party
+	if opt.ProverMetrics != nil {
+		rec.End()
+		if parties, err = metrics.Receive(tr); err != nil {
+			return nil, err
+		}
+	}
+	rec.Start(metrics.QuotientY)
 
 	// DBG check whether constraints are satisfied
 	if err := checkConstraintX(
 		pk,
+		int(tr.Size()),
 		evalsXOnAlpha,
 		zShiftedAlpha,
 		gamma,
@@ -297,7 +331,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 
 	polysCanonicalY := append(evalsXOnAlpha, zShiftedAlpha)
 	for i := 0; i < len(polysCanonicalY); i++ {
-		globalDomain[0].FFTInverse(polysCanonicalY[i], fft.DIF)
+		pk.DomainY[0].FFTInverse(polysCanonicalY[i], fft.DIF)
 		fft.BitReverse(polysCanonicalY[i])
 	}
 
@@ -311,7 +345,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	)
 
 	// compute kzg commitments of Hy1, Hy2 and Hy3
-	if err := commitToQuotientOnY(hyCanonical1, hyCanonical2, hyCanonical3, proof, globalSRS); err != nil {
+	if err := commitToQuotientOnY(hyCanonical1, hyCanonical2, hyCanonical3, proof, pk.Vk.KZGSRS); err != nil {
 		return nil, err
 	}
 	// derive beta
@@ -324,14 +358,14 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	for _, digest := range proof.Hy {
 		ts = append(ts, &digest)
 	}
-	beta, err := deriveRandomness(&fs, "beta", true, ts...)
+	beta, err := deriveRandomness(&fs, "beta", ts...)
 	if err != nil {
 		return nil, err
 	}
 
 	// foldedHy = Hy1 + (beta**M)*Hy2 + (beta**(2M))*Hy3
 	var bBetaPowerM big.Int
-	bSize.SetUint64(globalDomain[0].Cardinality)
+	bSize.SetUint64(pk.DomainY[0].Cardinality)
 	var betaPowerM fr.Element
 	betaPowerM.Exp(beta, &bSize)
 	betaPowerM.ToBigIntRegular(&bBetaPowerM)
@@ -368,16 +402,21 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bn254witness.Witness,
 	var digestsY []curve.G1Affine
 	digestsY = append(digestsY, proof.PartialBatchedProof.ClaimedDigests...)
 	digestsY = append(digestsY, proof.PartialZShiftedProof.ClaimedDigest, foldedHyDigest)
+	rec.Start(metrics.FinalOpening)
 	proof.BatchedProof, err = kzg.BatchOpenSinglePoint(
 		polysCanonicalY,
 		digestsY,
 		beta,
 		hFunc,
-		globalSRS,
+		pk.Vk.KZGSRS,
 	)
 	if err != nil {
 		return nil, err
 	}
+	log.Debug().Dur("took", time.Since(start)).Msg("prover done")
+	if opt.ProverMetrics != nil {
+		opt.ProverMetrics(metrics.NewReport("piano", len(spr.Constraints), append(parties, rec.Stop())...))
+	}
 	return proof, nil
 }
 
@@ -398,33 +437,33 @@ func evalPolynomialsAtPoint(polys [][]fr.Element, point fr.Element) []fr.Element
 	return res
 }
 
-func commitToLRO(bcl, bcr, bco []fr.Element, proof *Proof, srs *dkzg.SRS) error {
+func commitToLRO(tr cluster.Transport, bcl, bcr, bco []fr.Element, proof *Proof, srs *dkzg.SRS) error {
 	n := runtime.NumCPU() / 2
 	var err error
-	proof.LRO[0], err = dkzg.Commit(bcl, srs, n)
+	proof.LRO[0], err = dkzg.Commit(tr, bcl, srs, n)
 	if err != nil {
 		return err
 	}
-	proof.LRO[1], err = dkzg.Commit(bcr, srs, n)
+	proof.LRO[1], err = dkzg.Commit(tr, bcr, srs, n)
 	if err != nil {
 		return err
 	}
-	proof.LRO[2], err = dkzg.Commit(bco, srs, n)
+	proof.LRO[2], err = dkzg.Commit(tr, bco, srs, n)
 	return err
 }
 
-func commitToQuotientX(h1, h2, h3 []fr.Element, proof *Proof, srs *dkzg.SRS) error {
+func commitToQuotientX(tr cluster.Transport, h1, h2, h3 []fr.Element, proof *Proof, srs *dkzg.SRS) error {
 	n := runtime.NumCPU() / 2
 	var err error
-	proof.Hx[0], err = dkzg.Commit(h1, srs, n)
+	proof.Hx[0], err = dkzg.Commit(tr, h1, srs, n)
 	if err != nil {
 		return err
 	}
-	proof.Hx[1], err = dkzg.Commit(h2, srs, n)
+	proof.Hx[1], err = dkzg.Commit(tr, h2, srs, n)
 	if err != nil {
 		return err
 	}
-	proof.Hx[2], err = dkzg.Commit(h3, srs, n)
+	proof.Hx[2], err = dkzg.Commit(tr, h3, srs, n)
 	return err
 }
 
@@ -736,19 +775,19 @@ func computeQuotientCanonicalX(pk *ProvingKey, lCanonicalX, rCanonicalX, oCanoni
 // + lambda**2 * L0(alpha)*(Z(Y, alpha) - 1)
 // - Hx(Y, alpha)Z(X) = Hy(Y)Z(Y)
 func computeQuotientCanonicalY(pk *ProvingKey, polys [][]fr.Element, eta, gamma, lambda, alpha fr.Element) ([]fr.Element, []fr.Element, []fr.Element) {
-	h := make([]fr.Element, globalDomain[1].Cardinality)
-	ratio := globalDomain[1].Cardinality / globalDomain[0].Cardinality
+	h := make([]fr.Element, pk.DomainY[1].Cardinality)
+	ratio := pk.DomainY[1].Cardinality / pk.DomainY[0].Cardinality
 
-	// Compute the power of globalDomain[1].Generator with bit-reversed order.
+	// Compute the power of pk.DomainY[1].Generator with bit-reversed order.
 	factorsBR := make([]fr.Element, ratio)
 	factorsBR[0].SetOne()
 	for i := 1; i < int(ratio); i++ {
-		factorsBR[i].Mul(&factorsBR[i-1], &globalDomain[1].Generator)
+		factorsBR[i].Mul(&factorsBR[i-1], &pk.DomainY[1].Generator)
 	}
 	fft.BitReverse(factorsBR)
 
 	// Variables needed in permutation constraint.
-	n := globalDomain[0].Cardinality
+	n := pk.DomainY[0].Cardinality
 	var alphaEta, cosetShiftAlphaEta, cosetShiftSquareAlphaEta fr.Element
 	alphaEta.Mul(&alpha, &eta)
 	cosetShiftAlphaEta.Mul(&alphaEta, &pk.Vk.CosetShift)
@@ -772,20 +811,20 @@ func computeQuotientCanonicalY(pk *ProvingKey, polys [][]fr.Element, eta, gamma,
 
 	for idxBR := 0; idxBR < int(ratio); idxBR++ {
 		// Compute FFT part for each polynomial.
-		foldedHx := globalDomain[0].FFTPart(polys[0], fft.DIF, factorsBR[idxBR], true)
-		l := globalDomain[0].FFTPart(polys[1], fft.DIF, factorsBR[idxBR], true)
-		r := globalDomain[0].FFTPart(polys[2], fft.DIF, factorsBR[idxBR], true)
-		o := globalDomain[0].FFTPart(polys[3], fft.DIF, factorsBR[idxBR], true)
-		ql := globalDomain[0].FFTPart(polys[4], fft.DIF, factorsBR[idxBR], true)
-		qr := globalDomain[0].FFTPart(polys[5], fft.DIF, factorsBR[idxBR], true)
-		qm := globalDomain[0].FFTPart(polys[6], fft.DIF, factorsBR[idxBR], true)
-		qo := globalDomain[0].FFTPart(polys[7], fft.DIF, factorsBR[idxBR], true)
-		qk := globalDomain[0].FFTPart(polys[8], fft.DIF, factorsBR[idxBR], true)
-		s1 := globalDomain[0].FFTPart(polys[9], fft.DIF, factorsBR[idxBR], true)
-		s2 := globalDomain[0].FFTPart(polys[10], fft.DIF, factorsBR[idxBR], true)
-		s3 := globalDomain[0].FFTPart(polys[11], fft.DIF, factorsBR[idxBR], true)
-		z := globalDomain[0].FFTPart(polys[12], fft.DIF, factorsBR[idxBR], true)
-		zs := globalDomain[0].FFTPart(polys[13], fft.DIF, factorsBR[idxBR], true)
+		foldedHx := pk.DomainY[0].FFTPart(polys[0], fft.DIF, factorsBR[idxBR], true)
+		l := pk.DomainY[0].FFTPart(polys[1], fft.DIF, factorsBR[idxBR], true)
+		r := pk.DomainY[0].FFTPart(polys[2], fft.DIF, factorsBR[idxBR], true)
+		o := pk.DomainY[0].FFTPart(polys[3], fft.DIF, factorsBR[idxBR], true)
+		ql := pk.DomainY[0].FFTPart(polys[4], fft.DIF, factorsBR[idxBR], true)
+		qr := pk.DomainY[0].FFTPart(polys[5], fft.DIF, factorsBR[idxBR], true)
+		qm := pk.DomainY[0].FFTPart(polys[6], fft.DIF, factorsBR[idxBR], true)
+		qo := pk.DomainY[0].FFTPart(polys[7], fft.DIF, factorsBR[idxBR], true)
+		qk := pk.DomainY[0].FFTPart(polys[8], fft.DIF, factorsBR[idxBR], true)
+		s1 := pk.DomainY[0].FFTPart(polys[9], fft.DIF, factorsBR[idxBR], true)
+		s2 := pk.DomainY[0].FFTPart(polys[10], fft.DIF, factorsBR[idxBR], true)
+		s3 := pk.DomainY[0].FFTPart(polys[11], fft.DIF, factorsBR[idxBR], true)
+		z := pk.DomainY[0].FFTPart(polys[12], fft.DIF, factorsBR[idxBR], true)
+		zs := pk.DomainY[0].FFTPart(polys[13], fft.DIF, factorsBR[idxBR], true)
 
 		hStart := uint64(idxBR) * n
 		utils.Parallelize(int(n), func(start, end int) {
@@ -830,26 +869,26 @@ func computeQuotientCanonicalY(pk *ProvingKey, polys [][]fr.Element, eta, gamma,
 		})
 	}
 
-	evaluationYmMinusOneInverse := evaluateXnMinusOneBig(globalDomain[1], globalDomain[0])
+	evaluationYmMinusOneInverse := evaluateXnMinusOneBig(&pk.DomainY[1], &pk.DomainY[0])
 	evaluationYmMinusOneInverse = fr.BatchInvert(evaluationYmMinusOneInverse)
-	nn2 := uint64(64 - bits.TrailingZeros64(uint64(globalDomain[1].Cardinality)))
-	utils.Parallelize(int(globalDomain[1].Cardinality), func(start, end int) {
+	nn2 := uint64(64 - bits.TrailingZeros64(uint64(pk.DomainY[1].Cardinality)))
+	utils.Parallelize(int(pk.DomainY[1].Cardinality), func(start, end int) {
 		for _i := uint64(start); _i < uint64(end); _i++ {
 			i := bits.Reverse64(_i) >> nn2
 			h[_i].Mul(&h[_i], &evaluationYmMinusOneInverse[i % ratio])
 		}
 	})
 
-	globalDomain[1].FFTInverse(h, fft.DIT, true)
+	pk.DomainY[1].FFTInverse(h, fft.DIT, true)
 
-	h1 := h[:globalDomain[0].Cardinality]
-	h2 := h[globalDomain[0].Cardinality : 2*globalDomain[0].Cardinality]
-	h3 := h[2*globalDomain[0].Cardinality : 3*globalDomain[0].Cardinality]
+	h1 := h[:pk.DomainY[0].Cardinality]
+	h2 := h[pk.DomainY[0].Cardinality : 2*pk.DomainY[0].Cardinality]
+	h3 := h[2*pk.DomainY[0].Cardinality : 3*pk.DomainY[0].Cardinality]
 	return h1, h2, h3
 }
 
 // checkConstraintX checks that the constraint is satisfied
-func checkConstraintX(pk *ProvingKey, evalsXOnAlpha [][]fr.Element, zShiftedAlpha []fr.Element, gamma, eta, lambda, alpha fr.Element) error {
+func checkConstraintX(pk *ProvingKey, nbParties int, evalsXOnAlpha [][]fr.Element, zShiftedAlpha []fr.Element, gamma, eta, lambda, alpha fr.Element) error {
 	var l0, one, den fr.Element
 	one.SetOne()
 	l0.Exp(alpha, big.NewInt(int64(pk.Domain[0].Cardinality))).Sub(&l0, &one)
@@ -860,7 +899,7 @@ func checkConstraintX(pk *ProvingKey, evalsXOnAlpha [][]fr.Element, zShiftedAlph
 	vanishingX.Exp(alpha, big.NewInt(int64(pk.Domain[0].Cardinality)))
 	vanishingX.Sub(&vanishingX, &one)
 
-	for k := 0; k < int(mpi.WorldSize); k++ {
+	for k := 0; k < nbParties; k++ {
 		// unpack vector evalsXOnAlpha on hx, l, r, o, ql, qr, qm, qo, qk, s1, s2, s3, z
 		hx := evalsXOnAlpha[0][k]
 		l := evalsXOnAlpha[1][k]
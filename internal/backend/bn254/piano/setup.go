@@ -21,24 +21,20 @@ package piano
 import (
 	"crypto/rand"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
-	"github.com/consensys/gnark-crypto/ecc/bn254/fr/dkzg"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	"github.com/consensys/gnark/backend/cluster"
 	"github.com/consensys/gnark/internal/backend/bn254/cs"
-	"github.com/sunblaze-ucb/simpleMPI/mpi"
+	"github.com/consensys/gnark/internal/backend/bn254/dkzg"
 
-	dkzgg "github.com/consensys/gnark-crypto/dkzg"
+	kzgg "github.com/consensys/gnark-crypto/kzg"
 	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
 )
 
-var (
-	globalDomain [2]*fft.Domain
-	globalSRS    *kzg.SRS
-)
-
 // ProvingKey stores the data needed to generate a proof:
 // * the commitment scheme
 // * ql, prepended with as many ones as they are public inputs
@@ -64,6 +60,11 @@ type ProvingKey struct {
 	Domain [2]fft.Domain
 	// Domain[0], Domain[1] fft.Domain
 
+	// Domains used for the FFTs on Y, of one element per party.
+	// DomainY[0] = small Domain
+	// DomainY[1] = big Domain
+	DomainY [2]fft.Domain
+
 	// Permutation polynomials
 	S1Canonical, S2Canonical, S3Canonical     []fr.Element
 
@@ -85,7 +86,8 @@ type VerifyingKey struct {
 	Generator         fr.Element
 	NbPublicVariables uint64
 
-	// Commitment scheme that is used for an instantiation of PLONK
+	// Commitment scheme that is used for an instantiation of PLONK: the distributed KZG on X, of
+	// the party, and the KZG on Y, on the master only
 	DKZGSRS *dkzg.SRS
 	KZGSRS *kzg.SRS
 
@@ -100,15 +102,9 @@ type VerifyingKey struct {
 	Ql, Qr, Qm, Qo, Qk kzg.Digest
 }
 
-// Setup sets proving and verifying keys
-func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey, *VerifyingKey, error) {
-	globalDomain[0] = fft.NewDomain(mpi.WorldSize)
-	if mpi.WorldSize < 6 {
-		globalDomain[1] = fft.NewDomain(8 * mpi.WorldSize)
-	} else {
-		globalDomain[1] = fft.NewDomain(4 * mpi.WorldSize)
-	}
-
+// Setup sets proving and verifying keys. The master draws the toxic waste and sends it to the
+// other parties through tr.
+func Setup(spr *cs.SparseR1CS, tr cluster.Transport, publicWitness bn254witness.Witness) (*ProvingKey, *VerifyingKey, error) {
 	one := fr.One()
 
 	var pk ProvingKey
@@ -117,6 +113,16 @@ func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey,
 	// The verifying key shares data with the proving key
 	pk.Vk = &vk
 
+	pk.DomainY[0] = *fft.NewDomain(tr.Size())
+	if pk.DomainY[0].Cardinality != tr.Size() {
+		return nil, nil, fmt.Errorf("the number of parties is not a power of 2")
+	}
+	if tr.Size() < 6 {
+		pk.DomainY[1] = *fft.NewDomain(8 * tr.Size())
+	} else {
+		pk.DomainY[1] = *fft.NewDomain(4 * tr.Size())
+	}
+
 	nbConstraints := len(spr.Constraints)
 
 	// fft domains
@@ -126,7 +132,7 @@ func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey,
 
 	var t, s *big.Int
 	var err error
-	if mpi.SelfRank == 0 {
+	if tr.Rank() == 0 {
 		for {
 			t, err = rand.Int(rand.Reader, spr.CurveID().ScalarField())
 			if err != nil {
@@ -134,7 +140,7 @@ func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey,
 			}
 			var ele fr.Element
 			ele.SetBigInt(t)
-			if !ele.Exp(ele, big.NewInt(int64(globalDomain[0].Cardinality))).Equal(&one) {
+			if !ele.Exp(ele, big.NewInt(int64(pk.DomainY[0].Cardinality))).Equal(&one) {
 				break
 			}
 		}
@@ -149,48 +155,16 @@ func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey,
 				break
 			}
 		}
-		// send t and s to all other processes
-		tByteLen := (t.BitLen() + 7) / 8
-		sByteLen := (s.BitLen() + 7) / 8
-		for i := uint64(1); i < mpi.WorldSize; i++ {
-			if err := mpi.SendBytes([]byte{byte(tByteLen)}, i); err != nil {
-				return nil, nil, err
-			}
-			if err := mpi.SendBytes(t.Bytes(), i); err != nil {
-				return nil, nil, err
-			}
-			if err := mpi.SendBytes([]byte{byte(sByteLen)}, i); err != nil {
-				return nil, nil, err
-			}
-			if err := mpi.SendBytes(s.Bytes(), i); err != nil {
-				return nil, nil, err
-			}
-		}
-		globalSRS, err = kzg.NewSRS(globalDomain[0].Cardinality, t)
-		if err != nil {
-			return nil, nil, err
-		}
-	} else {
-		tByteLen, err := mpi.ReceiveBytes(1, 0)
-		if err != nil {
+		if err := sendSecrets(tr, t, s); err != nil {
 			return nil, nil, err
 		}
-		tbytes, err := mpi.ReceiveBytes(uint64(tByteLen[0]), 0)
+		vk.KZGSRS, err = kzg.NewSRS(pk.DomainY[0].Cardinality, t)
 		if err != nil {
 			return nil, nil, err
 		}
-		t = new(big.Int).SetBytes(tbytes)
-		sByteLen, err := mpi.ReceiveBytes(1, 0)
-		if err != nil {
-			return nil, nil, err
-		}
-		sbytes, err := mpi.ReceiveBytes(uint64(sByteLen[0]), 0)
-		if err != nil {
-			return nil, nil, err
-		}
-		s = new(big.Int).SetBytes(sbytes)
+	} else if t, s, err = receiveSecrets(tr); err != nil {
+		return nil, nil, err
 	}
-	vk.KZGSRS = globalSRS
 
 	// h, the quotient polynomial is of degree 3(n+1)+2, so it's in a 3(n+2) dim vector space,
 	// the domain is the next power of 2 superior to 3(n+2). 4*domainNum is enough in all cases
@@ -201,14 +175,14 @@ func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey,
 		pk.Domain[1] = *fft.NewDomain(4 * sizeSystem)
 	}
 
-	vk.SizeY = globalDomain[0].Cardinality
+	vk.SizeY = pk.DomainY[0].Cardinality
 	vk.SizeYInv.SetUint64(vk.SizeY).Inverse(&vk.SizeYInv)
 	vk.SizeX = pk.Domain[0].Cardinality
 	vk.SizeXInv.SetUint64(vk.SizeX).Inverse(&vk.SizeXInv)
 	vk.Generator.Set(&pk.Domain[0].Generator)
 	vk.NbPublicVariables = uint64(spr.NbPublicVariables)
 
-	dkzgSRS, err := dkzg.NewSRS(vk.SizeX+3, []*big.Int{t, s}, &globalDomain[0].Generator)
+	dkzgSRS, err := dkzg.NewSRS(vk.SizeX+3, t, s, &pk.DomainY[0], tr.Rank())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -259,28 +233,28 @@ func Setup(spr *cs.SparseR1CS, publicWitness bn254witness.Witness) (*ProvingKey,
 	ccomputePermutationPolynomials(&pk)
 
 	// Commit to the polynomials to set up the verifying key
-	if vk.Ql, err = dkzg.Commit(pk.Ql, vk.DKZGSRS); err != nil {
+	if vk.Ql, err = dkzg.Commit(tr, pk.Ql, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Qr, err = dkzg.Commit(pk.Qr, vk.DKZGSRS); err != nil {
+	if vk.Qr, err = dkzg.Commit(tr, pk.Qr, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Qm, err = dkzg.Commit(pk.Qm, vk.DKZGSRS); err != nil {
+	if vk.Qm, err = dkzg.Commit(tr, pk.Qm, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Qo, err = dkzg.Commit(pk.Qo, vk.DKZGSRS); err != nil {
+	if vk.Qo, err = dkzg.Commit(tr, pk.Qo, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.Qk, err = dkzg.Commit(pk.Qk, vk.DKZGSRS); err != nil {
+	if vk.Qk, err = dkzg.Commit(tr, pk.Qk, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.S[0], err = dkzg.Commit(pk.S1Canonical, vk.DKZGSRS); err != nil {
+	if vk.S[0], err = dkzg.Commit(tr, pk.S1Canonical, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.S[1], err = dkzg.Commit(pk.S2Canonical, vk.DKZGSRS); err != nil {
+	if vk.S[1], err = dkzg.Commit(tr, pk.S2Canonical, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
-	if vk.S[2], err = dkzg.Commit(pk.S3Canonical, vk.DKZGSRS); err != nil {
+	if vk.S[2], err = dkzg.Commit(tr, pk.S3Canonical, vk.DKZGSRS); err != nil {
 		return nil, nil, err
 	}
 
@@ -406,7 +380,7 @@ func getIDSmallDomain(domain *fft.Domain) []fr.Element {
 //
 // This should be used after deserializing a ProvingKey
 // as pk.Vk.KZG is NOT serialized
-func (pk *ProvingKey) InitKZG(srs dkzgg.SRS) error {
+func (pk *ProvingKey) InitKZG(srs kzgg.SRS) error {
 	return pk.Vk.InitKZG(srs)
 }
 
@@ -416,7 +390,7 @@ func (pk *ProvingKey) InitKZG(srs dkzgg.SRS) error {
 // as vk.KZG is NOT serialized
 //
 // Note that this instantiate a new FFT domain using vk.Size
-func (vk *VerifyingKey) InitKZG(srs dkzgg.SRS) error {
+func (vk *VerifyingKey) InitKZG(srs kzgg.SRS) error {
 	_srs := srs.(*dkzg.SRS)
 
 	if len(_srs.G1) < int(vk.SizeX) {
@@ -436,3 +410,36 @@ func (vk *VerifyingKey) NbPublicWitness() int {
 func (pk *ProvingKey) VerifyingKey() interface{} {
 	return pk.Vk
 }
+
+// sendSecrets sends the toxic waste t, s of the setup to the other parties, on the master.
+func sendSecrets(tr cluster.Transport, t, s *big.Int) error {
+	for i := uint64(1); i < tr.Size(); i++ {
+		for _, v := range []*big.Int{t, s} {
+			b := v.Bytes()
+			if err := tr.SendBytes([]byte{byte(len(b))}, i); err != nil {
+				return err
+			}
+			if err := tr.SendBytes(b, i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// receiveSecrets receives the toxic waste t, s sent by sendSecrets, on the other parties.
+func receiveSecrets(tr cluster.Transport) (t, s *big.Int, err error) {
+	var res [2]*big.Int
+	for i := range res {
+		size, err := tr.ReceiveBytes(1, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		b, err := tr.ReceiveBytes(uint64(size[0]), 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		res[i] = new(big.Int).SetBytes(b)
+	}
+	return res[0], res[1], nil
+}
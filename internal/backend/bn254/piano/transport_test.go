@@ -0,0 +1,227 @@
+package piano
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	fiatshamir "github.com/consensys/gnark-crypto/fiat-shamir"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/internal/backend/bn254/cs"
+	"github.com/consensys/gnark/internal/mpisim"
+	"github.com/stretchr/testify/require"
+
+	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
+)
+
+// The tests below run the exchanges of Setup and Prove between the parties of an in-process
+// world, with a fault injected in their messages: one by one, then through a whole Prove.
+
+const (
+	faultTimeout = 200 * time.Millisecond
+	// proveTimeout leaves the master the time to compute its part of the proof
+	proveTimeout = 2 * time.Second
+)
+
+func TestShareRandomnessFaults(t *testing.T) {
+	for _, size := range []uint64{2, 4} {
+		last := size - 1
+
+		run := func(faults ...mpisim.Fault) ([]error, []fr.Element) {
+			world, err := mpisim.NewWorld(size, mpisim.WithTimeout(faultTimeout), mpisim.WithFaults(faults...))
+			require.NoError(t, err)
+			challenges := make([]fr.Element, size)
+			errs := world.Run(func(p *mpisim.Party) error {
+				fs := fiatshamir.NewTranscript(sha256.New(), "gamma")
+				var err error
+				challenges[p.Rank()], err = shareRandomness(p, &fs, "gamma")
+				return err
+			})
+			return errs, challenges
+		}
+
+		t.Run(fmt.Sprintf("%d parties/no fault", size), func(t *testing.T) {
+			errs, challenges := run()
+			for i := range errs {
+				require.NoError(t, errs[i])
+				require.True(t, challenges[i].Equal(&challenges[0]))
+			}
+		})
+
+		t.Run(fmt.Sprintf("%d parties/drop", size), func(t *testing.T) {
+			errs, _ := run(mpisim.Fault{Kind: mpisim.Drop, From: 0, To: last})
+			require.ErrorIs(t, errs[last], mpisim.ErrTimeout)
+		})
+
+		t.Run(fmt.Sprintf("%d parties/long delay", size), func(t *testing.T) {
+			errs, _ := run(mpisim.Fault{Kind: mpisim.Delay, From: 0, To: last, Duration: 2 * faultTimeout})
+			require.ErrorIs(t, errs[last], mpisim.ErrTimeout)
+		})
+
+		// the verifier derives the challenge of the master: the proof of a party holding
+		// another challenge doesn't verify
+		t.Run(fmt.Sprintf("%d parties/corrupt", size), func(t *testing.T) {
+			errs, challenges := run(mpisim.Fault{Kind: mpisim.Corrupt, From: 0, To: last})
+			for i := range errs {
+				require.NoError(t, errs[i])
+			}
+			require.False(t, challenges[last].Equal(&challenges[0]))
+		})
+	}
+}
+
+func TestSecretsFaults(t *testing.T) {
+	tSecret, sSecret := big.NewInt(42), big.NewInt(1<<40+7)
+	for _, size := range []uint64{2, 4} {
+		last := size - 1
+
+		run := func(faults ...mpisim.Fault) ([]error, [][2]*big.Int) {
+			world, err := mpisim.NewWorld(size, mpisim.WithTimeout(faultTimeout), mpisim.WithFaults(faults...))
+			require.NoError(t, err)
+			secrets := make([][2]*big.Int, size)
+			errs := world.Run(func(p *mpisim.Party) error {
+				if p.Rank() == 0 {
+					secrets[0] = [2]*big.Int{tSecret, sSecret}
+					return sendSecrets(p, tSecret, sSecret)
+				}
+				ts, ss, err := receiveSecrets(p)
+				secrets[p.Rank()] = [2]*big.Int{ts, ss}
+				return err
+			})
+			return errs, secrets
+		}
+
+		t.Run(fmt.Sprintf("%d parties/no fault", size), func(t *testing.T) {
+			errs, secrets := run()
+			for i := range errs {
+				require.NoError(t, errs[i])
+				require.Equal(t, 0, secrets[i][0].Cmp(tSecret))
+				require.Equal(t, 0, secrets[i][1].Cmp(sSecret))
+			}
+		})
+
+		t.Run(fmt.Sprintf("%d parties/drop", size), func(t *testing.T) {
+			errs, _ := run(mpisim.Fault{Kind: mpisim.Drop, From: 0, To: last, Index: 3})
+			require.ErrorIs(t, errs[last], mpisim.ErrTimeout)
+		})
+
+		t.Run(fmt.Sprintf("%d parties/long delay", size), func(t *testing.T) {
+			errs, _ := run(mpisim.Fault{Kind: mpisim.Delay, From: 0, To: last, Duration: 2 * faultTimeout})
+			require.ErrorIs(t, errs[last], mpisim.ErrTimeout)
+		})
+
+		// the SRS of a party holding another t doesn't match the one of the verifying key
+		t.Run(fmt.Sprintf("%d parties/corrupt", size), func(t *testing.T) {
+			errs, secrets := run(mpisim.Fault{Kind: mpisim.Corrupt, From: 0, To: last, Index: 1})
+			for i := range errs {
+				require.NoError(t, errs[i])
+			}
+			require.NotEqual(t, 0, secrets[last][0].Cmp(tSecret))
+		})
+	}
+}
+
+type squareCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *squareCircuit) Define(api frontend.API) error {
+	x := circuit.X
+	for i := 0; i < 4; i++ {
+		x = api.Mul(x, x)
+	}
+	api.AssertIsEqual(x, circuit.Y)
+	return nil
+}
+
+// The messages of a party to the master, in Prove, are its commitments to L, R, O (0 to 2), Z (3)
+// and Hx (4 to 6), then its opening of Z at μα (7 to 9) and its batch opening at α.
+func TestProveFaults(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &squareCircuit{})
+	require.NoError(t, err)
+	spr := ccs.(*cs.SparseR1CS)
+
+	// x = 2, y = 2^16: every party holds the same sub-circuit and witness
+	fullWitness := make(bn254witness.Witness, 2)
+	fullWitness[0].SetUint64(1 << 16)
+	fullWitness[1].SetUint64(2)
+	publicWitness := fullWitness[:1]
+
+	for _, size := range []uint64{2, 4} {
+		last := size - 1
+
+		world, err := mpisim.NewWorld(size)
+		require.NoError(t, err)
+		pks := make([]*ProvingKey, size)
+		for _, err := range world.Run(func(p *mpisim.Party) error {
+			var err error
+			pks[p.Rank()], _, err = Setup(spr, p, publicWitness)
+			return err
+		}) {
+			require.NoError(t, err)
+		}
+		vk := pks[0].Vk
+
+		run := func(faults ...mpisim.Fault) ([]error, *Proof) {
+			world, err := mpisim.NewWorld(size, mpisim.WithTimeout(proveTimeout), mpisim.WithFaults(faults...))
+			require.NoError(t, err)
+			var proof *Proof
+			errs := world.Run(func(p *mpisim.Party) error {
+				pr, err := Prove(spr, p, pks[p.Rank()], fullWitness, backend.ProverConfig{})
+				if p.Rank() == 0 {
+					proof = pr
+				}
+				return err
+			})
+			return errs, proof
+		}
+
+		t.Run(fmt.Sprintf("%d parties/no fault", size), func(t *testing.T) {
+			errs, proof := run()
+			for i := range errs {
+				require.NoError(t, errs[i])
+			}
+			require.NoError(t, Verify(proof, vk, publicWitness))
+		})
+
+		// the parties waiting for the lost message fail, the others when the world is closed
+		t.Run(fmt.Sprintf("%d parties/drop commitment", size), func(t *testing.T) {
+			errs, _ := run(mpisim.Fault{Kind: mpisim.Drop, From: last, To: 0, Index: 4})
+			require.ErrorIs(t, errs[0], mpisim.ErrTimeout)
+			for i := range errs {
+				require.Error(t, errs[i])
+			}
+		})
+
+		t.Run(fmt.Sprintf("%d parties/drop challenge", size), func(t *testing.T) {
+			errs, _ := run(mpisim.Fault{Kind: mpisim.Drop, From: 0, To: last, Index: 2})
+			require.ErrorIs(t, errs[last], mpisim.ErrTimeout)
+			for i := range errs {
+				require.Error(t, errs[i])
+			}
+		})
+
+		t.Run(fmt.Sprintf("%d parties/corrupt commitment", size), func(t *testing.T) {
+			errs, _ := run(mpisim.Fault{Kind: mpisim.Corrupt, From: last, To: 0, Index: 1})
+			require.Error(t, errs[0])
+		})
+
+		// the master gets a wrong evaluation of Z(X, Y) at μα: either it detects it, or the
+		// proof doesn't verify
+		t.Run(fmt.Sprintf("%d parties/corrupt evaluation", size), func(t *testing.T) {
+			errs, proof := run(mpisim.Fault{Kind: mpisim.Corrupt, From: last, To: 0, Index: 8})
+			err := errs[0]
+			if err == nil {
+				err = Verify(proof, vk, publicWitness)
+			}
+			require.Error(t, err)
+		})
+	}
+}
@@ -26,16 +26,16 @@ import (
 	"time"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
-	"github.com/sunblaze-ucb/simpleMPI/mpi"
 
 	curve "github.com/consensys/gnark-crypto/ecc/bn254"
 	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
 
 	"github.com/consensys/gnark-crypto/fiat-shamir"
+	"github.com/consensys/gnark/backend/cluster"
 	"github.com/consensys/gnark/logger"
 
-	"github.com/consensys/gnark-crypto/ecc/bn254/fr/dkzg"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	"github.com/consensys/gnark/internal/backend/bn254/dkzg"
 )
 
 func Verify(proof *Proof, vk *VerifyingKey, publicWitness bn254witness.Witness) error {
@@ -54,24 +54,24 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness bn254witness.Witness)
 	if err := bindPublicData(&fs, "gamma", *vk, publicWitness); err != nil {
 		return err
 	}
-	gamma, err := deriveRandomness(&fs, "gamma", true, &proof.LRO[0], &proof.LRO[1], &proof.LRO[2])
+	gamma, err := deriveRandomness(&fs, "gamma", &proof.LRO[0], &proof.LRO[1], &proof.LRO[2])
 	if err != nil {
 		return err
 	}
 	// derive eta from Comm(l), Comm(r), Comm(o)
-	eta, err := deriveRandomness(&fs, "eta", true)
+	eta, err := deriveRandomness(&fs, "eta")
 	if err != nil {
 		return err
 	}
 
 	// derive lambda from Comm(l), Comm(r), Comm(o), Com(Z)
-	lambda, err := deriveRandomness(&fs, "lambda", true, &proof.Z)
+	lambda, err := deriveRandomness(&fs, "lambda", &proof.Z)
 	if err != nil {
 		return err
 	}
 
 	// derive alpha, the point of evaluation
-	alpha, err := deriveRandomness(&fs, "alpha", true, &proof.Hx[0], &proof.Hx[1], &proof.Hx[2])
+	alpha, err := deriveRandomness(&fs, "alpha", &proof.Hx[0], &proof.Hx[1], &proof.Hx[2])
 	if err != nil {
 		return err
 	}
@@ -148,7 +148,7 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness bn254witness.Witness)
 	for _, digest := range proof.Hy {
 		ts = append(ts, &digest)
 	}
-	beta, err := deriveRandomness(&fs, "beta", true, ts...)
+	beta, err := deriveRandomness(&fs, "beta", ts...)
 	if err != nil {
 		return err
 	}
@@ -225,44 +225,55 @@ func bindPublicData(fs *fiatshamir.Transcript, challenge string, vk VerifyingKey
 	return nil
 }
 
-func deriveRandomness(fs *fiatshamir.Transcript, challenge string, notSend bool, points ...*curve.G1Affine) (fr.Element, error) {
-	if mpi.SelfRank == 0 {
-		var buf [curve.SizeOfG1AffineUncompressed]byte
-		var r fr.Element
-
-		for _, p := range points {
-			buf = p.RawBytes()
-			if err := fs.Bind(challenge, buf[:]); err != nil {
-				fmt.Println("deriveRandomness", challenge, "err", err)
-				fmt.Println("Stack", string(debug.Stack()))
-				return r, err
-			}
-		}
+// deriveRandomness binds points to the transcript and returns the challenge. The transcript is
+// held by the master: the other parties get the challenges with shareRandomness.
+func deriveRandomness(fs *fiatshamir.Transcript, challenge string, points ...*curve.G1Affine) (fr.Element, error) {
+	var buf [curve.SizeOfG1AffineUncompressed]byte
+	var r fr.Element
 
-		b, err := fs.ComputeChallenge(challenge)
-		if err != nil {
+	for _, p := range points {
+		buf = p.RawBytes()
+		if err := fs.Bind(challenge, buf[:]); err != nil {
 			fmt.Println("deriveRandomness", challenge, "err", err)
 			fmt.Println("Stack", string(debug.Stack()))
 			return r, err
 		}
-		r.SetBytes(b)
-		if notSend {
-			return r, nil
-		}
-		sendBuf := r.Bytes()
-		for i := 1; i < int(mpi.WorldSize); i++ {
-			mpi.SendBytes(sendBuf[:], uint64(i))
-		}
-		return r, nil
-	} else {
-		var r fr.Element
-		recvBuf, err := mpi.ReceiveBytes(fr.Bytes, 0)
+	}
+
+	b, err := fs.ComputeChallenge(challenge)
+	if err != nil {
+		fmt.Println("deriveRandomness", challenge, "err", err)
+		fmt.Println("Stack", string(debug.Stack()))
+		return r, err
+	}
+	r.SetBytes(b)
+	return r, nil
+}
+
+// shareRandomness derives the challenge on the master, which sends it to the other parties
+// through tr.
+func shareRandomness(tr cluster.Transport, fs *fiatshamir.Transcript, challenge string, points ...*curve.G1Affine) (fr.Element, error) {
+	var r fr.Element
+	if tr.Rank() != 0 {
+		recvBuf, err := tr.ReceiveBytes(fr.Bytes, 0)
 		if err != nil {
 			return r, err
 		}
 		r.SetBytes(recvBuf)
 		return r, nil
 	}
+
+	r, err := deriveRandomness(fs, challenge, points...)
+	if err != nil {
+		return r, err
+	}
+	sendBuf := r.Bytes()
+	for i := uint64(1); i < tr.Size(); i++ {
+		if err := tr.SendBytes(sendBuf[:], i); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
 }
 
 // checkConstraintY checks that the constraint is satisfied
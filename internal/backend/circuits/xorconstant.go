@@ -0,0 +1,57 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark/frontend"
+)
+
+// xorConstantCircuit checks the boolean operations on constants and on
+// variables carrying a coefficient
+type xorConstantCircuit struct {
+	Op1, Op2, Res frontend.Variable
+}
+
+func (circuit *xorConstantCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Xor(circuit.Op1, 1), circuit.Res)
+	api.AssertIsEqual(api.Xor(0, circuit.Op1), circuit.Op1)
+	api.AssertIsEqual(api.Or(circuit.Op1, 1), 1)
+	api.AssertIsEqual(api.Or(0, circuit.Op1), circuit.Op1)
+
+	zero := api.Mul(circuit.Op2, 0)
+	api.AssertIsEqual(api.Xor(circuit.Op1, zero), circuit.Op1)
+	api.AssertIsEqual(api.Or(zero, circuit.Op1), circuit.Op1)
+	return nil
+}
+
+func init() {
+	good := []frontend.Circuit{
+		&xorConstantCircuit{
+			Op1: (1),
+			Op2: (1),
+			Res: (0),
+		},
+		&xorConstantCircuit{
+			Op1: (0),
+			Op2: (1),
+			Res: (1),
+		},
+		&xorConstantCircuit{
+			Op1: (1),
+			Op2: (0),
+			Res: (0),
+		},
+	}
+	bad := []frontend.Circuit{
+		&xorConstantCircuit{
+			Op1: (1),
+			Op2: (1),
+			Res: (1),
+		},
+		&xorConstantCircuit{
+			Op1: (0),
+			Op2: (1),
+			Res: (0),
+		},
+	}
+	addNewEntry("xor_constant", &xorConstantCircuit{}, good, bad, gnark.Curves())
+}
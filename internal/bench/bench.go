@@ -0,0 +1,150 @@
+// Package bench measures the distributed provers (piano, gpiano) across sub-circuit sizes and
+// numbers of parties.
+//
+// The parties of a point run in the same process, on an in-process world (internal/mpisim): the
+// bench needs no port nor SSH, and the parties share the cores of the machine. The results are
+// saved in latest.bench, to be compared across commits (see generate/main.go).
+package bench
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+)
+
+// Point is a configuration of the sweep: each of the NbParties parties holds a sub-circuit of
+// 2^LogRows rows.
+type Point struct {
+	Backend   backend.ID
+	LogRows   int
+	NbParties int
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("%s/2^%d/m%d", p.Backend, p.LogRows, p.NbParties)
+}
+
+// Sweep returns the points of the given backends, sizes and numbers of parties.
+func Sweep(backends []backend.ID, logRows, nbParties []int) []Point {
+	var points []Point
+	for _, b := range backends {
+		for _, k := range logRows {
+			for _, m := range nbParties {
+				points = append(points, Point{b, k, m})
+			}
+		}
+	}
+	return points
+}
+
+// Result is the measure of a proof, on the master.
+type Result struct {
+	// Prover is the time of Prove on the master, which ends last.
+	Prover time.Duration
+
+	// Phases is the time of each round of the prover (see package backend/metrics) on the party
+	// which spent the most time in it.
+	Phases map[string]time.Duration
+
+	Verifier time.Duration
+
+	// ProofSize is the size of the proof written by its WriteTo method, in bytes.
+	ProofSize int
+
+	// Communication is the number of bytes sent by all the parties while proving.
+	Communication uint64
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("prover: %s, verifier: %s, proof: %d bytes, communication: %d bytes", r.Prover, r.Verifier, r.ProofSize, r.Communication)
+}
+
+// Results are the results of a sweep, indexed by Point.String().
+type Results struct {
+	sync.RWMutex
+	Results map[string]Result
+}
+
+func NewResults() *Results {
+	return &Results{Results: make(map[string]Result)}
+}
+
+func (r *Results) Add(p Point, res Result) {
+	r.Lock()
+	defer r.Unlock()
+	r.Results[p.String()] = res
+}
+
+// Names returns the sorted names of the points.
+func (r *Results) Names() []string {
+	r.RLock()
+	defer r.RUnlock()
+	names := make([]string, 0, len(r.Results))
+	for name := range r.Results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Results) Save(path string) error {
+	f, err := os.Create(path) //#nosec G304 -- ignoring internal package
+	if err != nil {
+		return err
+	}
+
+	encoder := gob.NewEncoder(f)
+	err = encoder.Encode(r.Results)
+	_ = f.Close()
+	return err
+}
+
+func (r *Results) Load(path string) error {
+	f, err := os.Open(path) //#nosec G304 -- ignoring internal package
+	if err != nil {
+		return err
+	}
+
+	decoder := gob.NewDecoder(f)
+	err = decoder.Decode(&r.Results)
+	_ = f.Close()
+	return err
+}
+
+// Circuit is the sub-circuit of a party: Y == X**(2**nbSquares).
+type Circuit struct {
+	X, Y frontend.Variable `gnark:",public"`
+
+	nbSquares int
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	y := c.X
+	for i := 0; i < c.nbSquares; i++ {
+		y = api.Mul(y, y)
+	}
+	api.AssertIsEqual(c.Y, y)
+	return nil
+}
+
+// NewCircuit returns a sub-circuit of 2^logRows rows, and a valid assignment of it.
+func NewCircuit(logRows int) (circuit, assignment *Circuit) {
+	// the rows are the 2 public inputs, the squares and the assertion
+	n := 1<<logRows - 3
+
+	x := big.NewInt(3)
+	y := new(big.Int).Set(x)
+	modulus := ecc.BN254.ScalarField()
+	for i := 0; i < n; i++ {
+		y.Mul(y, y).Mod(y, modulus)
+	}
+	return &Circuit{nbSquares: n}, &Circuit{X: x, Y: y, nbSquares: n}
+}
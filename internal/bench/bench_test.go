@@ -0,0 +1,69 @@
+package bench
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/metrics"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuit(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	for _, logRows := range []int{4, 6} {
+		circuit, assignment := NewCircuit(logRows)
+		ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, circuit)
+		assert.NoError(err)
+		_, _, nbPublic := ccs.GetNbVariables()
+		nbRows := ccs.GetNbConstraints() + nbPublic
+		assert.Equal(1<<logRows, nbRows, "the sub-circuit fills the rows")
+
+		assert.ProverSucceeded(circuit, assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+	}
+}
+
+func TestRun(t *testing.T) {
+	assert := require.New(t)
+
+	for _, p := range Sweep([]backend.ID{backend.PIANO, backend.GPIANO}, []int{4}, []int{2}) {
+		res, err := Run(p)
+		assert.NoError(err, p.String())
+		assert.NotZero(res.Prover, p.String())
+		assert.NotZero(res.Verifier, p.String())
+		assert.NotZero(res.ProofSize, p.String())
+		assert.NotZero(res.Communication, p.String())
+		assert.Contains(res.Phases, metrics.Solve, p.String())
+		assert.Contains(res.Phases, metrics.FinalOpening, p.String())
+	}
+}
+
+func TestResults(t *testing.T) {
+	assert := require.New(t)
+
+	points := Sweep([]backend.ID{backend.PIANO, backend.GPIANO}, []int{10, 12}, []int{2, 4, 8})
+	assert.Len(points, 12)
+	assert.Equal("piano/2^10/m2", points[0].String())
+
+	results := NewResults()
+	results.Add(points[0], Result{
+		Prover:        time.Second,
+		Phases:        map[string]time.Duration{"solve": time.Millisecond},
+		Verifier:      time.Millisecond,
+		ProofSize:     1024,
+		Communication: 1 << 20,
+	})
+	path := filepath.Join(t.TempDir(), "latest.bench")
+	assert.NoError(results.Save(path))
+
+	loaded := NewResults()
+	assert.NoError(loaded.Load(path))
+	assert.Equal(results.Results, loaded.Results)
+	assert.Equal([]string{points[0].String()}, loaded.Names())
+}
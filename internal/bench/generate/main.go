@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/metrics"
+	"github.com/consensys/gnark/internal/bench"
+)
+
+var (
+	fSave      = flag.Bool("s", false, "save new results in file ")
+	fBackends  = flag.String("backends", "piano,gpiano", "backends of the sweep")
+	fLogRows   = flag.String("logrows", "10,12,14", "log2 of the number of rows of the sub-circuit of a party")
+	fNbParties = flag.String("parties", "2,4,8", "numbers of parties")
+)
+
+const refPath = "../latest.bench"
+
+var phases = []string{
+	metrics.Solve,
+	metrics.CommitLRO,
+	metrics.ComputeZ,
+	metrics.QuotientX,
+	metrics.PartialOpenings,
+	metrics.QuotientY,
+	metrics.FinalOpening,
+}
+
+func main() {
+	flag.Parse()
+
+	points := bench.Sweep(parseBackends(*fBackends), parseInts(*fLogRows), parseInts(*fNbParties))
+
+	reference := bench.NewResults()
+	if err := reference.Load(refPath); err != nil {
+		log.Println("no reference results:", err)
+	}
+
+	results := bench.NewResults()
+	for _, p := range points {
+		res, err := bench.Run(p)
+		if err != nil {
+			log.Fatalf("%s: %v", p, err)
+		}
+		results.Add(p, res)
+	}
+
+	fmt.Print("id,prover")
+	for _, phase := range phases {
+		fmt.Print(",", phase)
+	}
+	fmt.Println(",verifier,proofSize,communication,proverVsReference")
+	for _, p := range points {
+		res := results.Results[p.String()]
+		fmt.Printf("%s,%d", p, res.Prover.Microseconds())
+		for _, phase := range phases {
+			fmt.Printf(",%d", res.Phases[phase].Microseconds())
+		}
+		fmt.Printf(",%d,%d,%d", res.Verifier.Microseconds(), res.ProofSize, res.Communication)
+		if ref, ok := reference.Results[p.String()]; ok && ref.Prover > 0 {
+			fmt.Printf(",%.2f\n", float64(res.Prover)/float64(ref.Prover))
+		} else {
+			fmt.Println(",")
+		}
+	}
+
+	if *fSave {
+		if err := results.Save(refPath); err != nil {
+			log.Fatal(err)
+		}
+		log.Println("successfully saved new reference results file", refPath)
+	}
+}
+
+func parseBackends(s string) []backend.ID {
+	var backends []backend.ID
+	for _, name := range strings.Split(s, ",") {
+		found := false
+		for _, b := range backend.Implemented() {
+			if b.String() == name {
+				backends = append(backends, b)
+				found = true
+			}
+		}
+		if !found {
+			log.Fatalf("unknown backend %q", name)
+		}
+	}
+	return backends
+}
+
+func parseInts(s string) []int {
+	var res []int
+	for _, v := range strings.Split(s, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			log.Fatal(err)
+		}
+		res = append(res, i)
+	}
+	return res
+}
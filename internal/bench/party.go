@@ -0,0 +1,151 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/cluster"
+	"github.com/consensys/gnark/backend/gpiano"
+	"github.com/consensys/gnark/backend/metrics"
+	"github.com/consensys/gnark/backend/piano"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/internal/mpisim"
+)
+
+// Run proves the sub-circuit of p on every party of an in-process world of p.NbParties parties,
+// and returns the measure of the proof.
+func Run(p Point) (Result, error) {
+	circuit, assignment := NewCircuit(p.LogRows)
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, circuit)
+	if err != nil {
+		return Result{}, err
+	}
+	w, err := frontend.NewWitness(assignment, ecc.BN254)
+	if err != nil {
+		return Result{}, err
+	}
+	publicW, err := w.Public()
+	if err != nil {
+		return Result{}, err
+	}
+
+	var prove func(tr cluster.Transport) (Result, error)
+	switch p.Backend {
+	case backend.PIANO:
+		prove = func(tr cluster.Transport) (Result, error) {
+			return runPiano(ccs, tr, w, publicW)
+		}
+	case backend.GPIANO:
+		prove = func(tr cluster.Transport) (Result, error) {
+			return runGPiano(ccs, tr, w, publicW)
+		}
+	default:
+		return Result{}, fmt.Errorf("%s is not a distributed backend", p.Backend)
+	}
+
+	world, err := mpisim.NewWorld(uint64(p.NbParties))
+	if err != nil {
+		return Result{}, err
+	}
+	var res Result
+	errs := world.Run(func(party *mpisim.Party) error {
+		r, err := prove(party)
+		if party.Rank() == 0 {
+			res = r
+		}
+		return err
+	})
+	for rank, err := range errs {
+		if err != nil {
+			return Result{}, fmt.Errorf("party %d: %w", rank, err)
+		}
+	}
+	return res, nil
+}
+
+// runPiano runs Setup, Prove and, on the master, Verify of piano on the party of tr. On the
+// master, it returns the measure of the proof.
+func runPiano(ccs frontend.CompiledConstraintSystem, tr cluster.Transport, w, publicW *witness.Witness) (Result, error) {
+	pk, vk, err := piano.SetupWithTransport(ccs, tr, publicW)
+	if err != nil {
+		return Result{}, err
+	}
+	var res Result
+	report := reportTo(&res)
+	start := time.Now()
+	proof, err := piano.ProveWithTransport(ccs, tr, pk, w, report)
+	if err != nil || tr.Rank() != 0 {
+		return Result{}, err
+	}
+	res.Prover = time.Since(start)
+	start = time.Now()
+	if err := piano.Verify(proof, vk, publicW); err != nil {
+		return Result{}, err
+	}
+	res.Verifier = time.Since(start)
+	res.ProofSize, err = sizeOf(proof)
+	return res, err
+}
+
+// runGPiano is runPiano for gpiano.
+func runGPiano(ccs frontend.CompiledConstraintSystem, tr cluster.Transport, w, publicW *witness.Witness) (Result, error) {
+	pk, vk, err := gpiano.SetupWithTransport(ccs, tr, publicW)
+	if err != nil {
+		return Result{}, err
+	}
+	var res Result
+	report := reportTo(&res)
+	start := time.Now()
+	proof, err := gpiano.ProveWithTransport(ccs, tr, pk, w, report)
+	if err != nil || tr.Rank() != 0 {
+		return Result{}, err
+	}
+	res.Prover = time.Since(start)
+	start = time.Now()
+	if err := gpiano.Verify(proof, vk, publicW); err != nil {
+		return Result{}, err
+	}
+	res.Verifier = time.Since(start)
+	res.ProofSize, err = sizeOf(proof)
+	return res, err
+}
+
+// reportTo returns the prover option filling the phases and the communication of res with the
+// report of the master.
+func reportTo(res *Result) backend.ProverOption {
+	return backend.WithProverMetrics(func(r *metrics.Report) {
+		res.Phases = make(map[string]time.Duration)
+		for _, party := range r.Parties {
+			for _, round := range party.Rounds {
+				if round.Duration > res.Phases[round.Name] {
+					res.Phases[round.Name] = round.Duration
+				}
+			}
+			res.Communication += party.BytesSent
+		}
+	})
+}
+
+// countingWriter counts the bytes written to it, and discards them.
+type countingWriter struct {
+	n int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	return len(p), nil
+}
+
+// sizeOf returns the size of the serialized proof, in bytes.
+func sizeOf(proof io.WriterTo) (int, error) {
+	var w countingWriter
+	if _, err := proof.WriteTo(&w); err != nil {
+		return 0, err
+	}
+	return w.n, nil
+}
@@ -0,0 +1,309 @@
+// Package mpisim simulates an MPI world inside a single process, to test the distributed
+// backends without a cluster.
+//
+// The parties follow the star topology of simpleMPI (github.com/sunblaze-ucb/simpleMPI/mpi):
+// the master (rank 0) exchanges bytes with every other party, and the other parties only with
+// the master. As with the TCP connections of simpleMPI, each direction of a link is a byte
+// stream: ReceiveBytes reads exactly the requested number of bytes, regardless of how they were
+// split between the calls to SendBytes.
+//
+// Faults can be injected deterministically on the messages (the calls to SendBytes) of a link,
+// to exercise the error paths of a protocol: see Fault.
+package mpisim
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTimeout     = errors.New("mpisim: receive timed out")
+	ErrClosed      = errors.New("mpisim: world closed")
+	ErrInvalidRank = errors.New("mpisim: invalid rank")
+)
+
+// DefaultTimeout is the time a party waits for bytes before ReceiveBytes fails, as the read
+// deadline of simpleMPI.
+const DefaultTimeout = 10 * time.Second
+
+// FaultKind is the alteration of a message by a Fault.
+type FaultKind uint8
+
+const (
+	// Drop loses the message.
+	Drop FaultKind = iota
+	// Delay delivers the message after Fault.Duration. The messages sent after it on the same
+	// link are delivered after it, as on a TCP connection.
+	Delay
+	// Corrupt flips the least significant bit of the first byte of the message.
+	Corrupt
+)
+
+// Fault alters the Index-th message (starting at 0) sent by the party of rank From to the party
+// of rank To.
+type Fault struct {
+	Kind     FaultKind
+	From, To uint64
+	Index    int
+	Duration time.Duration // for Delay
+}
+
+// Option configures a World.
+type Option func(*World) error
+
+// WithTimeout sets the time a party waits for bytes before ReceiveBytes fails.
+func WithTimeout(timeout time.Duration) Option {
+	return func(w *World) error {
+		if timeout <= 0 {
+			return errors.New("mpisim: the timeout must be positive")
+		}
+		w.timeout = timeout
+		return nil
+	}
+}
+
+// WithFaults injects faults in the messages exchanged by the parties.
+func WithFaults(faults ...Fault) Option {
+	return func(w *World) error {
+		for _, f := range faults {
+			if _, err := w.link(f.From, f.To); err != nil {
+				return fmt.Errorf("fault %d -> %d: %w", f.From, f.To, err)
+			}
+		}
+		w.faults = append(w.faults, faults...)
+		return nil
+	}
+}
+
+// World is a set of parties exchanging messages in memory.
+type World struct {
+	size    uint64
+	timeout time.Duration
+	faults  []Fault
+
+	// toMaster[i] and fromMaster[i] are the streams between the master and the party of rank i
+	toMaster, fromMaster []*stream
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// NewWorld returns a world of size parties.
+func NewWorld(size uint64, opts ...Option) (*World, error) {
+	if size == 0 {
+		return nil, errors.New("mpisim: the world must have at least one party")
+	}
+	w := &World{
+		size:       size,
+		timeout:    DefaultTimeout,
+		toMaster:   make([]*stream, size),
+		fromMaster: make([]*stream, size),
+		closed:     make(chan struct{}),
+	}
+	for i := uint64(1); i < size; i++ {
+		w.toMaster[i] = newStream()
+		w.fromMaster[i] = newStream()
+	}
+	for _, opt := range opts {
+		if err := opt(w); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Size returns the number of parties.
+func (w *World) Size() uint64 {
+	return w.size
+}
+
+// Party returns the party of the given rank.
+func (w *World) Party(rank uint64) *Party {
+	if rank >= w.size {
+		panic(ErrInvalidRank)
+	}
+	return &Party{world: w, rank: rank}
+}
+
+// Run runs fn for every party concurrently, and returns their errors indexed by rank. When a
+// party fails, the world is closed so that the others don't wait for its messages.
+func (w *World) Run(fn func(p *Party) error) []error {
+	errs := make([]error, w.size)
+	var wg sync.WaitGroup
+	for i := uint64(0); i < w.size; i++ {
+		wg.Add(1)
+		go func(rank uint64) {
+			defer wg.Done()
+			if errs[rank] = fn(w.Party(rank)); errs[rank] != nil {
+				w.Close()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+// Close closes the world: the pending and later calls to ReceiveBytes fail with ErrClosed.
+func (w *World) Close() {
+	w.closeOnce.Do(func() { close(w.closed) })
+}
+
+// link returns the stream from the party of rank from to the party of rank to.
+func (w *World) link(from, to uint64) (*stream, error) {
+	if from >= w.size || to >= w.size || from == to {
+		return nil, ErrInvalidRank
+	}
+	switch {
+	case from == 0:
+		return w.fromMaster[to], nil
+	case to == 0:
+		return w.toMaster[from], nil
+	default:
+		return nil, errors.New("mpisim: only the master exchanges with the other parties")
+	}
+}
+
+// Party is a party of a World. Its methods mirror the functions of simpleMPI.
+type Party struct {
+	world *World
+	rank  uint64
+}
+
+// Rank returns the rank of the party, 0 being the master.
+func (p *Party) Rank() uint64 {
+	return p.rank
+}
+
+// Size returns the number of parties of the world.
+func (p *Party) Size() uint64 {
+	return p.world.size
+}
+
+// SendBytes sends buf to the party of the given rank. As with simpleMPI, the rank is ignored
+// when the party is not the master: it sends to the master.
+func (p *Party) SendBytes(buf []byte, rank uint64) error {
+	to := p.peer(rank)
+	s, err := p.world.link(p.rank, to)
+	if err != nil {
+		return err
+	}
+
+	msg := make([]byte, len(buf))
+	copy(msg, buf)
+	readyAt := time.Now()
+
+	s.lock.Lock()
+	index := s.nbMessages
+	s.nbMessages++
+	s.lock.Unlock()
+
+	for _, f := range p.world.faults {
+		if f.From != p.rank || f.To != to || f.Index != index {
+			continue
+		}
+		switch f.Kind {
+		case Drop:
+			return nil
+		case Delay:
+			readyAt = readyAt.Add(f.Duration)
+		case Corrupt:
+			if len(msg) > 0 {
+				msg[0] ^= 1
+			}
+		}
+	}
+
+	s.push(msg, readyAt)
+	return nil
+}
+
+// ReceiveBytes receives size bytes from the party of the given rank. As with simpleMPI, the rank
+// is ignored when the party is not the master: it receives from the master.
+func (p *Party) ReceiveBytes(size, rank uint64) ([]byte, error) {
+	s, err := p.world.link(p.peer(rank), p.rank)
+	if err != nil {
+		return nil, err
+	}
+	return s.pop(size, p.world.timeout, p.world.closed)
+}
+
+// peer returns the rank of the party p exchanges with, given the rank passed by the caller.
+func (p *Party) peer(rank uint64) uint64 {
+	if p.rank != 0 {
+		return 0
+	}
+	return rank
+}
+
+// stream is one direction of a link: the messages are delivered in order, each one when it is ready.
+type stream struct {
+	lock       sync.Mutex
+	chunks     []chunk
+	nbMessages int
+	notify     chan struct{}
+}
+
+type chunk struct {
+	data    []byte
+	readyAt time.Time
+}
+
+func newStream() *stream {
+	return &stream{notify: make(chan struct{}, 1)}
+}
+
+func (s *stream) push(data []byte, readyAt time.Time) {
+	s.lock.Lock()
+	// a message is not delivered before the ones sent before it
+	if n := len(s.chunks); n > 0 && s.chunks[n-1].readyAt.After(readyAt) {
+		readyAt = s.chunks[n-1].readyAt
+	}
+	s.chunks = append(s.chunks, chunk{data: data, readyAt: readyAt})
+	s.lock.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *stream) pop(size uint64, timeout time.Duration, closed <-chan struct{}) ([]byte, error) {
+	res := make([]byte, 0, size)
+	deadline := time.Now().Add(timeout)
+
+	for uint64(len(res)) < size {
+		s.lock.Lock()
+		wait := time.Until(deadline)
+		if len(s.chunks) > 0 {
+			head := &s.chunks[0]
+			if until := time.Until(head.readyAt); until <= 0 {
+				n := copy(res[len(res):size], head.data)
+				res = res[:len(res)+n]
+				if head.data = head.data[n:]; len(head.data) == 0 {
+					s.chunks = s.chunks[1:]
+				}
+				s.lock.Unlock()
+				continue
+			} else if until < wait {
+				wait = until
+			}
+		}
+		s.lock.Unlock()
+
+		if time.Now().After(deadline) {
+			return res, ErrTimeout
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.notify:
+		case <-timer.C:
+		case <-closed:
+			timer.Stop()
+			return res, ErrClosed
+		}
+		timer.Stop()
+	}
+	return res, nil
+}
@@ -0,0 +1,123 @@
+package mpisim
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// broadcastAndGather is a round of a protocol: the master sends a challenge to every party, and
+// each party answers with the challenge xored with its rank. The master checks the answers.
+func broadcastAndGather(p *Party) error {
+	challenge := []byte{0xca, 0xfe, 0xba, 0xbe}
+	if p.Rank() != 0 {
+		c, err := p.ReceiveBytes(uint64(len(challenge)), 0)
+		if err != nil {
+			return err
+		}
+		for i := range c {
+			c[i] ^= byte(p.Rank())
+		}
+		// split the answer, the receiver reads it at once
+		if err := p.SendBytes(c[:1], 0); err != nil {
+			return err
+		}
+		return p.SendBytes(c[1:], 0)
+	}
+
+	for i := uint64(1); i < p.Size(); i++ {
+		if err := p.SendBytes(challenge, i); err != nil {
+			return err
+		}
+	}
+	for i := uint64(1); i < p.Size(); i++ {
+		answer, err := p.ReceiveBytes(uint64(len(challenge)), i)
+		if err != nil {
+			return err
+		}
+		for j := range answer {
+			answer[j] ^= byte(i)
+		}
+		if !bytes.Equal(answer, challenge) {
+			return errors.New("wrong answer")
+		}
+	}
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	for _, size := range []uint64{1, 2, 4} {
+		w, err := NewWorld(size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rank, err := range w.Run(broadcastAndGather) {
+			if err != nil {
+				t.Fatalf("world of size %d, party %d: %v", size, rank, err)
+			}
+		}
+	}
+}
+
+func TestFaults(t *testing.T) {
+	run := func(faults ...Fault) []error {
+		w, err := NewWorld(4, WithTimeout(100*time.Millisecond), WithFaults(faults...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return w.Run(broadcastAndGather)
+	}
+
+	// the first part of the answer of party 2 is lost: the master times out
+	errs := run(Fault{Kind: Drop, From: 2, To: 0, Index: 0})
+	if !errors.Is(errs[0], ErrTimeout) {
+		t.Fatalf("expected a timeout, got %v", errs[0])
+	}
+
+	// the challenge sent to party 3 is corrupted: its answer is wrong
+	errs = run(Fault{Kind: Corrupt, From: 0, To: 3, Index: 0})
+	if errs[0] == nil || errors.Is(errs[0], ErrTimeout) {
+		t.Fatalf("expected a wrong answer, got %v", errs[0])
+	}
+	for rank := 1; rank < 4; rank++ {
+		if errs[rank] != nil {
+			t.Fatalf("party %d: %v", rank, errs[rank])
+		}
+	}
+
+	// a delayed message is received, in order, within the timeout
+	errs = run(Fault{Kind: Delay, From: 1, To: 0, Index: 0, Duration: 20 * time.Millisecond})
+	for rank, err := range errs {
+		if err != nil {
+			t.Fatalf("party %d: %v", rank, err)
+		}
+	}
+
+	// but not after it
+	errs = run(Fault{Kind: Delay, From: 0, To: 1, Index: 0, Duration: time.Second})
+	if !errors.Is(errs[1], ErrTimeout) {
+		t.Fatalf("expected a timeout, got %v", errs[1])
+	}
+	// the master waiting for party 1 is released when it fails
+	if !errors.Is(errs[0], ErrClosed) && !errors.Is(errs[0], ErrTimeout) {
+		t.Fatalf("expected the master to fail, got %v", errs[0])
+	}
+}
+
+func TestInvalidLinks(t *testing.T) {
+	if _, err := NewWorld(4, WithFaults(Fault{From: 1, To: 2})); err == nil {
+		t.Fatal("expected an error for a fault between two parties which are not the master")
+	}
+	if _, err := NewWorld(2, WithFaults(Fault{From: 0, To: 2})); !errors.Is(err, ErrInvalidRank) {
+		t.Fatalf("expected ErrInvalidRank, got %v", err)
+	}
+
+	w, err := NewWorld(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Party(0).SendBytes([]byte{1}, 0); !errors.Is(err, ErrInvalidRank) {
+		t.Fatalf("expected ErrInvalidRank, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+)
+
+// Budget is the maximal number of constraints of a gadget, per backend and curve. The pairs
+// without an entry are not checked.
+type Budget map[backend.ID]map[ecc.ID]int
+
+// bn254Budget returns the budget of a gadget on bn254, the curve of the distributed backends.
+// The plonk, piano and gpiano backends prove the same sparse constraint system.
+func bn254Budget(groth16, sparse int) Budget {
+	return Budget{
+		backend.GROTH16: {ecc.BN254: groth16},
+		backend.PLONK:   {ecc.BN254: sparse},
+		backend.PIANO:   {ecc.BN254: sparse},
+		backend.GPIANO:  {ecc.BN254: sparse},
+	}
+}
+
+// Report is the cost of a gadget on a backend and curve, against its budget.
+type Report struct {
+	Name    string
+	Backend backend.ID
+	Curve   ecc.ID
+
+	Budget                         int
+	NbConstraints, NbInternalWires int
+
+	// NbRows is the number of rows a piano party proves for the circuit: the placeholder rows
+	// of the public inputs and the constraints, padded to a power of two. It is 0 for groth16.
+	NbRows int
+}
+
+// Regression reports whether the gadget takes more constraints than its budget.
+func (r Report) Regression() bool {
+	return r.NbConstraints > r.Budget
+}
+
+// NbRows returns the number of rows of the piano proof of a circuit with nbConstraints sparse
+// constraints and nbPublic public inputs.
+func NbRows(nbConstraints, nbPublic int) int {
+	return int(ecc.NextPowerOfTwo(uint64(nbConstraints + nbPublic)))
+}
+
+// Check compiles circuit for each backend and curve of budget, and returns the reports sorted
+// by backend and curve.
+func Check(name string, circuit frontend.Circuit, budget Budget) ([]Report, error) {
+	var res []Report
+	for backendID, curves := range budget {
+		for curve, nbConstraints := range curves {
+			ccs, err := compile(curve, backendID, circuit)
+			if err != nil {
+				return nil, fmt.Errorf("%s - %s - %s: %w", name, backendID, curve, err)
+			}
+			internal, _, public := ccs.GetNbVariables()
+			r := Report{
+				Name:            name,
+				Backend:         backendID,
+				Curve:           curve,
+				Budget:          nbConstraints,
+				NbConstraints:   ccs.GetNbConstraints(),
+				NbInternalWires: internal,
+			}
+			if backendID != backend.GROTH16 {
+				r.NbRows = NbRows(r.NbConstraints, public)
+			}
+			res = append(res, r)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Backend != res[j].Backend {
+			return res[i].Backend < res[j].Backend
+		}
+		return res[i].Curve < res[j].Curve
+	})
+	return res, nil
+}
+
+// Diff formats reports as a table of the constraint counts against the budgets, marking the
+// regressions, and the gadgets under budget whose budget can be lowered.
+func Diff(reports []Report) string {
+	var sbb strings.Builder
+	w := tabwriter.NewWriter(&sbb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "gadget\tbackend\tcurve\tbudget\tconstraints\tdelta\tpiano rows\t")
+	for _, r := range reports {
+		status := ""
+		switch {
+		case r.Regression():
+			status = "REGRESSION"
+		case r.NbConstraints < r.Budget:
+			status = "under budget"
+		}
+		rows := "-"
+		if r.NbRows != 0 {
+			rows = fmt.Sprint(r.NbRows)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%+d\t%s\t%s\n", r.Name, r.Backend, r.Curve, r.Budget, r.NbConstraints, r.NbConstraints-r.Budget, rows, status)
+	}
+	_ = w.Flush()
+	return sbb.String()
+}
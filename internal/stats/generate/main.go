@@ -40,6 +40,9 @@ func main() {
 			defer wg.Done()
 			for _, curve := range circuit.Curves {
 				for _, backendID := range backend.Implemented() {
+					if !stats.Supported(backendID, curve) {
+						continue
+					}
 					cs, err := stats.NewSnippetStats(curve, backendID, circuit.Circuit)
 					if err != nil {
 						log.Fatalf("building stats for circuit %s %v", name, err)
@@ -51,7 +54,7 @@ func main() {
 	}
 	wg.Wait()
 
-	fmt.Println("id,curve,backend,nbConstraints,nbWires")
+	fmt.Println("id,curve,backend,nbConstraints,nbWires,nbRows")
 	for name, c := range snippets {
 		if r != nil && !r.MatchString(name) {
 			continue
@@ -59,8 +62,16 @@ func main() {
 		ss := s.Stats[name]
 		for _, curve := range c.Curves {
 			for _, backendID := range backend.Implemented() {
+				if !stats.Supported(backendID, curve) {
+					continue
+				}
 				cs := ss[backendID][stats.CurveIdx(curve)]
-				fmt.Printf("%s,%s,%s,%d,%d\n", name, curve, backendID, cs.NbConstraints, cs.NbInternalWires)
+				// rows of the piano proof
+				nbRows := 0
+				if backendID != backend.GROTH16 {
+					nbRows = stats.NbRows(cs.NbConstraints, cs.NbPublicVariables)
+				}
+				fmt.Printf("%s,%s,%s,%d,%d,%d\n", name, curve, backendID, cs.NbConstraints, cs.NbInternalWires, nbRows)
 			}
 		}
 	}
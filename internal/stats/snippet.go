@@ -32,7 +32,17 @@ func registerSnippet(name string, snippet snippet, curves ...ecc.ID) {
 	if len(curves) == 0 {
 		curves = gnark.Curves()
 	}
-	snippets[name] = Circuit{makeSnippetCircuit(snippet), curves}
+	snippets[name] = Circuit{makeSnippetCircuit(snippet), curves, nil}
+}
+
+// setBudget sets the budget of the registered snippet name, checked by TestBudgets.
+func setBudget(name string, budget Budget) {
+	c, ok := snippets[name]
+	if !ok {
+		panic("circuit " + name + " not registered")
+	}
+	c.Budget = budget
+	snippets[name] = c
 }
 
 func initSnippets() {
@@ -119,6 +129,15 @@ func initSnippets() {
 		_ = sw_bls24315.FinalExponentiation(api, resMillerLoop)
 	}, ecc.BW6_633)
 
+	// constraint budgets of the gadgets on bn254
+	setBudget("api/IsZero", bn254Budget(3, 4))
+	setBudget("api/Lookup2", bn254Budget(5, 13))
+	setBudget("api/AssertIsLessOrEqual", bn254Budget(1270, 3046))
+	setBudget("api/AssertIsLessOrEqual/constant_bound_64_bits", bn254Budget(255, 508))
+	setBudget("math/bits.ToBinary", bn254Budget(255, 508))
+	setBudget("math/bits.ToTernary", bn254Budget(484, 966))
+	setBudget("math/bits.ToNAF", bn254Budget(763, 1524))
+	setBudget("hash/mimc", bn254Budget(273, 365))
 }
 
 type snippetCircuit struct {
@@ -43,7 +43,7 @@ func init() {
 
 func NewGlobalStats() *globalStats {
 	return &globalStats{
-		Stats: make(map[string][backend.PLONK + 1][nbCurves + 1]snippetStats),
+		Stats: make(map[string][backend.GPIANO + 1][nbCurves + 1]snippetStats),
 	}
 }
 
@@ -72,27 +72,40 @@ func (s *globalStats) Load(path string) error {
 }
 
 func NewSnippetStats(curve ecc.ID, backendID backend.ID, circuit frontend.Circuit) (snippetStats, error) {
+	ccs, err := compile(curve, backendID, circuit)
+	if err != nil {
+		return snippetStats{}, err
+	}
+
+	// ensure we didn't introduce regressions that make circuits less efficient
+	nbConstraints := ccs.GetNbConstraints()
+	internal, _, public := ccs.GetNbVariables()
+
+	return snippetStats{nbConstraints, internal, public}, nil
+}
+
+func compile(curve ecc.ID, backendID backend.ID, circuit frontend.Circuit) (frontend.CompiledConstraintSystem, error) {
 	var newCompiler frontend.NewBuilder
 
 	switch backendID {
 	case backend.GROTH16:
 		newCompiler = r1cs.NewBuilder
-	case backend.PLONK:
+	case backend.PLONK, backend.PIANO, backend.GPIANO:
 		newCompiler = scs.NewBuilder
 	default:
 		panic("not implemented")
 	}
 
-	ccs, err := frontend.Compile(curve, newCompiler, circuit, frontend.IgnoreUnconstrainedInputs())
-	if err != nil {
-		return snippetStats{}, err
-	}
-
-	// ensure we didn't introduce regressions that make circuits less efficient
-	nbConstraints := ccs.GetNbConstraints()
-	internal, _, _ := ccs.GetNbVariables()
+	return frontend.Compile(curve, newCompiler, circuit, frontend.IgnoreUnconstrainedInputs())
+}
 
-	return snippetStats{nbConstraints, internal}, nil
+// Supported reports whether backendID is implemented on curve: the distributed backends (piano,
+// gpiano) only are on bn254.
+func Supported(backendID backend.ID, curve ecc.ID) bool {
+	if backendID == backend.PIANO || backendID == backend.GPIANO {
+		return curve == ecc.BN254
+	}
+	return true
 }
 
 func (s *globalStats) Add(curve ecc.ID, backendID backend.ID, cs snippetStats, circuitName string) {
@@ -106,17 +119,21 @@ func (s *globalStats) Add(curve ecc.ID, backendID backend.ID, cs snippetStats, c
 type Circuit struct {
 	Circuit frontend.Circuit
 	Curves  []ecc.ID
+	Budget  Budget // nil if the constraint count of the circuit is not gated
 }
 
 type globalStats struct {
 	sync.RWMutex
-	Stats map[string][backend.PLONK + 1][nbCurves + 1]snippetStats
+	Stats map[string][backend.GPIANO + 1][nbCurves + 1]snippetStats
 }
 
 type snippetStats struct {
 	NbConstraints, NbInternalWires int
+
+	// NbPublicVariables counts the wire of 1 in an R1CS, see frontend.CompiledConstraintSystem
+	NbPublicVariables int
 }
 
 func (cs snippetStats) String() string {
-	return fmt.Sprintf("nbConstraints: %d, nbInternalWires: %d", cs.NbConstraints, cs.NbInternalWires)
+	return fmt.Sprintf("nbConstraints: %d, nbInternalWires: %d, nbPublicVariables: %d", cs.NbConstraints, cs.NbInternalWires, cs.NbPublicVariables)
 }
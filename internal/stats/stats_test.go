@@ -1,6 +1,7 @@
 package stats
 
 import (
+	"sort"
 	"testing"
 
 	"github.com/consensys/gnark/backend"
@@ -27,6 +28,9 @@ func TestCircuitStatistics(t *testing.T) {
 		}
 		for _, curve := range c.Curves {
 			for _, b := range backend.Implemented() {
+				if !Supported(b, curve) {
+					continue
+				}
 				curve := curve
 				backendID := b
 				name := name
@@ -48,3 +52,30 @@ func TestCircuitStatistics(t *testing.T) {
 	}
 
 }
+
+func TestBudgets(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	snippets := GetSnippets()
+	names := make([]string, 0, len(snippets))
+	for name, c := range snippets {
+		if c.Budget != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var reports []Report
+	regression := false
+	for _, name := range names {
+		r, err := Check(name, snippets[name].Circuit, snippets[name].Budget)
+		assert.NoError(err)
+		for _, report := range r {
+			regression = regression || report.Regression()
+		}
+		reports = append(reports, r...)
+	}
+	if regression {
+		assert.Fail("constraint budgets exceeded", "\n%s", Diff(reports))
+	}
+}
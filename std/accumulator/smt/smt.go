@@ -0,0 +1,80 @@
+// Package smt provides ZKP-circuit functions to verify sparse Merkle tree proofs.
+//
+// A sparse Merkle tree of depth d commits to a map from keys in [0, 2ᵈ) to field elements.
+// The leaf at position key is 0 if key is not in the map, and H(key, value) otherwise;
+// an internal node is H(left, right). The path of a key is given by its bits, from the
+// least significant one (leaf level) to the most significant one (just below the root).
+//
+// Since only the leaf changes when a key is inserted or updated, a single list of siblings
+// proves both the old and the new root of a transition.
+//
+// The hash function is a generic std/hash.Hash; the native tree (see Tree) uses the
+// corresponding hash.Hash from the standard library, for instance a gnark-crypto MiMC or
+// std/hash/poseidon.NewNative.
+package smt
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+)
+
+// hashPair returns H(a, b), starting from a fresh hash state.
+func hashPair(h hash.Hash, a, b frontend.Variable) frontend.Variable {
+	h.Reset()
+	h.Write(a, b)
+	return h.Sum()
+}
+
+// LeafHash returns the leaf committing to (key, value).
+func LeafHash(h hash.Hash, key, value frontend.Variable) frontend.Variable {
+	return hashPair(h, key, value)
+}
+
+// ComputeRoot returns the root of the tree in which the leaf at position key is leaf,
+// given the siblings of the path from the leaf (siblings[0]) to the root.
+// The depth of the tree is len(siblings), and key is constrained to be in [0, 2^depth).
+func ComputeRoot(api frontend.API, h hash.Hash, key, leaf frontend.Variable, siblings []frontend.Variable) frontend.Variable {
+	path := api.ToBinary(key, len(siblings))
+	return computeRoot(api, h, path, leaf, siblings)
+}
+
+func computeRoot(api frontend.API, h hash.Hash, path []frontend.Variable, leaf frontend.Variable, siblings []frontend.Variable) frontend.Variable {
+	node := leaf
+	for i := 0; i < len(siblings); i++ {
+		// if path[i] is set, the current node is the right child
+		left := api.Select(path[i], siblings[i], node)
+		right := api.Select(path[i], node, siblings[i])
+		node = hashPair(h, left, right)
+	}
+	return node
+}
+
+// VerifyInclusion asserts that (key, value) belongs to the tree of the given root.
+func VerifyInclusion(api frontend.API, h hash.Hash, root, key, value frontend.Variable, siblings []frontend.Variable) {
+	leaf := LeafHash(h, key, value)
+	api.AssertIsEqual(ComputeRoot(api, h, key, leaf, siblings), root)
+}
+
+// VerifyExclusion asserts that key is not in the tree of the given root.
+func VerifyExclusion(api frontend.API, h hash.Hash, root, key frontend.Variable, siblings []frontend.Variable) {
+	api.AssertIsEqual(ComputeRoot(api, h, key, 0, siblings), root)
+}
+
+// VerifyInsert asserts that key is not in the tree of root oldRoot, and that inserting
+// (key, value) in it yields the tree of root newRoot.
+func VerifyInsert(api frontend.API, h hash.Hash, oldRoot, newRoot, key, value frontend.Variable, siblings []frontend.Variable) {
+	verifyTransition(api, h, oldRoot, newRoot, key, 0, LeafHash(h, key, value), siblings)
+}
+
+// VerifyUpdate asserts that (key, oldValue) belongs to the tree of root oldRoot, and that
+// setting key to newValue yields the tree of root newRoot.
+func VerifyUpdate(api frontend.API, h hash.Hash, oldRoot, newRoot, key, oldValue, newValue frontend.Variable, siblings []frontend.Variable) {
+	verifyTransition(api, h, oldRoot, newRoot, key, LeafHash(h, key, oldValue), LeafHash(h, key, newValue), siblings)
+}
+
+// verifyTransition checks both roots against the same path, decomposing the key only once.
+func verifyTransition(api frontend.API, h hash.Hash, oldRoot, newRoot, key, oldLeaf, newLeaf frontend.Variable, siblings []frontend.Variable) {
+	path := api.ToBinary(key, len(siblings))
+	api.AssertIsEqual(computeRoot(api, h, path, oldLeaf, siblings), oldRoot)
+	api.AssertIsEqual(computeRoot(api, h, path, newLeaf, siblings), newRoot)
+}
@@ -0,0 +1,185 @@
+package smt
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/hash/poseidon"
+	"github.com/consensys/gnark/test"
+)
+
+const testDepth = 8
+
+type transitionCircuit struct {
+	OldRoot, NewRoot frontend.Variable `gnark:",public"`
+
+	// key inserted in the tree
+	InsertedKey, InsertedValue frontend.Variable
+	InsertSiblings             [testDepth]frontend.Variable
+
+	// key updated in the tree
+	UpdatedKey, OldValue, NewValue frontend.Variable
+	UpdateSiblings                 [testDepth]frontend.Variable
+	MidRoot                        frontend.Variable
+
+	// key which is not in the final tree
+	AbsentKey       frontend.Variable
+	AbsentSiblings  [testDepth]frontend.Variable
+	IncludedKey     frontend.Variable
+	IncludedValue   frontend.Variable
+	IncludeSiblings [testDepth]frontend.Variable
+
+	usePoseidon bool
+}
+
+func (circuit *transitionCircuit) Define(api frontend.API) error {
+	var h hash.Hash
+	if circuit.usePoseidon {
+		p, err := poseidon.NewPoseidon(api)
+		if err != nil {
+			return err
+		}
+		h = &p
+	} else {
+		m, err := mimc.NewMiMC(api)
+		if err != nil {
+			return err
+		}
+		h = &m
+	}
+
+	VerifyInsert(api, h, circuit.OldRoot, circuit.MidRoot, circuit.InsertedKey, circuit.InsertedValue, circuit.InsertSiblings[:])
+	VerifyUpdate(api, h, circuit.MidRoot, circuit.NewRoot, circuit.UpdatedKey, circuit.OldValue, circuit.NewValue, circuit.UpdateSiblings[:])
+	VerifyExclusion(api, h, circuit.NewRoot, circuit.AbsentKey, circuit.AbsentSiblings[:])
+	VerifyInclusion(api, h, circuit.NewRoot, circuit.IncludedKey, circuit.IncludedValue, circuit.IncludeSiblings[:])
+	return nil
+}
+
+func copySiblings(dst []frontend.Variable, src []*big.Int) {
+	for i := range src {
+		dst[i] = src[i]
+	}
+}
+
+func testTransitions(t *testing.T, tree *Tree, usePoseidon bool) {
+	assert := test.NewAssert(t)
+
+	// populate the tree
+	for i := int64(0); i < 20; i++ {
+		_, err := tree.Set(big.NewInt(i*7+3), big.NewInt(i*i+1))
+		assert.NoError(err)
+	}
+
+	var witness transitionCircuit
+	witness.OldRoot = tree.Root()
+
+	insert, err := tree.Set(big.NewInt(200), big.NewInt(42))
+	assert.NoError(err)
+	assert.Nil(insert.OldValue)
+	witness.InsertedKey = insert.Key
+	witness.InsertedValue = insert.NewValue
+	copySiblings(witness.InsertSiblings[:], insert.Siblings)
+	witness.MidRoot = insert.NewRoot
+
+	update, err := tree.Set(big.NewInt(10), big.NewInt(1234))
+	assert.NoError(err)
+	assert.Equal(insert.NewRoot, update.OldRoot)
+	witness.UpdatedKey = update.Key
+	witness.OldValue = update.OldValue
+	witness.NewValue = update.NewValue
+	copySiblings(witness.UpdateSiblings[:], update.Siblings)
+	witness.NewRoot = update.NewRoot
+
+	absent, err := tree.Prove(big.NewInt(255))
+	assert.NoError(err)
+	assert.Nil(absent.Value)
+	witness.AbsentKey = absent.Key
+	copySiblings(witness.AbsentSiblings[:], absent.Siblings)
+
+	included, err := tree.Prove(big.NewInt(200))
+	assert.NoError(err)
+	witness.IncludedKey = included.Key
+	witness.IncludedValue = included.Value
+	copySiblings(witness.IncludeSiblings[:], included.Siblings)
+
+	circuit := transitionCircuit{usePoseidon: usePoseidon}
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	// an included key can't be proven absent
+	wrongWitness := witness
+	wrongWitness.AbsentKey = 200
+	copySiblings(wrongWitness.AbsentSiblings[:], included.Siblings)
+	assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(ecc.BN254))
+
+	// an existing key can't be inserted
+	wrongWitness = witness
+	wrongWitness.InsertedKey = 10
+	assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(ecc.BN254))
+
+	// the old value of an update must be correct
+	wrongWitness = witness
+	wrongWitness.OldValue = 0
+	assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(ecc.BN254))
+
+	// keys must fit in the depth of the tree
+	_, err = tree.Prove(big.NewInt(1 << testDepth))
+	assert.ErrorIs(err, ErrKeyOutOfRange)
+}
+
+func TestTransitionsMiMC(t *testing.T) {
+	tree, err := New(bn254.NewMiMC(), ecc.BN254.ScalarField(), testDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testTransitions(t, tree, false)
+}
+
+func TestTransitionsPoseidon(t *testing.T) {
+	h, err := poseidon.NewNative(ecc.BN254)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := New(h, ecc.BN254.ScalarField(), testDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testTransitions(t, tree, true)
+}
+
+func TestSetSameValue(t *testing.T) {
+	tree, err := New(bn254.NewMiMC(), ecc.BN254.ScalarField(), testDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tree.Root()
+
+	// setting the same value twice must not change the root
+	p1, err := tree.Set(big.NewInt(5), big.NewInt(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := tree.Set(big.NewInt(5), big.NewInt(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p1.OldRoot.Cmp(root) != 0 || p1.NewRoot.Cmp(p2.NewRoot) != 0 || p2.OldRoot.Cmp(p2.NewRoot) != 0 {
+		t.Fatal("unexpected roots")
+	}
+}
+
+func TestInvalidDepth(t *testing.T) {
+	modulus := ecc.BN254.ScalarField()
+	for _, depth := range []int{0, modulus.BitLen(), 8 * bn254.NewMiMC().BlockSize()} {
+		if _, err := New(bn254.NewMiMC(), modulus, depth); err == nil {
+			t.Fatalf("depth %d: expected an error", depth)
+		}
+	}
+	if _, err := New(bn254.NewMiMC(), modulus, modulus.BitLen()-1); err != nil {
+		t.Fatal(err)
+	}
+}
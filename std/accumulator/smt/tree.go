@@ -0,0 +1,202 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+	"math/big"
+)
+
+// Tree is a native sparse Merkle tree producing the witnesses of the circuit functions
+// of this package. Only non-empty nodes are stored.
+type Tree struct {
+	depth   int
+	h       hash.Hash
+	modulus *big.Int
+	empty   [][]byte            // empty[i] is the root of an empty subtree of height i
+	nodes   []map[string][]byte // nodes[i][index] is a non-empty node at height i
+	values  map[string]*big.Int // values of the keys in the tree
+}
+
+// Proof is a path in a Tree.
+type Proof struct {
+	Key      *big.Int
+	Value    *big.Int   // value of Key, nil if Key is not in the tree
+	Root     *big.Int   // root of the tree the proof was generated for
+	Siblings []*big.Int // siblings from the leaf level to the root
+}
+
+// UpdateProof proves the transition of a Tree when a key is set.
+type UpdateProof struct {
+	Key      *big.Int
+	OldValue *big.Int // nil if Key was not in the tree (insertion)
+	NewValue *big.Int
+	OldRoot  *big.Int
+	NewRoot  *big.Int
+	Siblings []*big.Int // siblings from the leaf level to the root, for both roots
+}
+
+// ErrKeyOutOfRange is returned when a key doesn't fit in the depth of the tree
+var ErrKeyOutOfRange = errors.New("smt: key out of range")
+
+// New returns an empty sparse Merkle tree of the given depth, hashing with h.
+// h is expected to take elements of the field of the given modulus as BlockSize()
+// big endian bytes, as the gnark-crypto MiMC implementations do. Keys are field
+// elements in the circuits, so depth must be smaller than the bit length of modulus.
+func New(h hash.Hash, modulus *big.Int, depth int) (*Tree, error) {
+	if depth < 1 || depth >= 8*h.BlockSize() || depth >= modulus.BitLen() {
+		return nil, errors.New("smt: invalid depth")
+	}
+	t := &Tree{
+		depth:   depth,
+		h:       h,
+		modulus: new(big.Int).Set(modulus),
+		empty:   make([][]byte, depth+1),
+		nodes:   make([]map[string][]byte, depth+1),
+		values:  make(map[string]*big.Int),
+	}
+	t.empty[0] = make([]byte, h.BlockSize())
+	for i := 0; i < depth; i++ {
+		e, err := t.hashPair(t.empty[i], t.empty[i])
+		if err != nil {
+			return nil, err
+		}
+		t.empty[i+1] = e
+	}
+	for i := range t.nodes {
+		t.nodes[i] = make(map[string][]byte)
+	}
+	return t, nil
+}
+
+// Depth returns the depth of the tree, which is also the number of siblings of a path.
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// Root returns the root of the tree.
+func (t *Tree) Root() *big.Int {
+	return new(big.Int).SetBytes(t.node(t.depth, new(big.Int)))
+}
+
+// Get returns the value of key, and whether key is in the tree.
+func (t *Tree) Get(key *big.Int) (*big.Int, bool) {
+	v, ok := t.values[string(key.Bytes())]
+	if !ok {
+		return nil, false
+	}
+	return new(big.Int).Set(v), true
+}
+
+// Prove returns the path of key. It is an inclusion proof if key is in the tree,
+// and an exclusion proof otherwise.
+func (t *Tree) Prove(key *big.Int) (Proof, error) {
+	if err := t.checkKey(key); err != nil {
+		return Proof{}, err
+	}
+	p := Proof{
+		Key:      new(big.Int).Set(key),
+		Root:     t.Root(),
+		Siblings: t.siblings(key),
+	}
+	p.Value, _ = t.Get(key)
+	return p, nil
+}
+
+// Set sets key to value, and returns the proof of the transition.
+func (t *Tree) Set(key, value *big.Int) (UpdateProof, error) {
+	if err := t.checkKey(key); err != nil {
+		return UpdateProof{}, err
+	}
+	if value.Sign() < 0 || value.Cmp(t.modulus) >= 0 {
+		return UpdateProof{}, errors.New("smt: value out of range")
+	}
+	p := UpdateProof{
+		Key:      new(big.Int).Set(key),
+		NewValue: new(big.Int).Set(value),
+		OldRoot:  t.Root(),
+		Siblings: t.siblings(key),
+	}
+	p.OldValue, _ = t.Get(key)
+
+	leaf, err := t.hashPair(t.toBytes(key), t.toBytes(value))
+	if err != nil {
+		return UpdateProof{}, err
+	}
+
+	// update the path from the leaf to the root
+	index := new(big.Int).Set(key)
+	t.setNode(0, index, leaf)
+	node := leaf
+	for i := 0; i < t.depth; i++ {
+		sibling := t.node(i, new(big.Int).Xor(index, big.NewInt(1)))
+		if index.Bit(0) == 1 {
+			node, err = t.hashPair(sibling, node)
+		} else {
+			node, err = t.hashPair(node, sibling)
+		}
+		if err != nil {
+			return UpdateProof{}, err
+		}
+		index.Rsh(index, 1)
+		t.setNode(i+1, index, node)
+	}
+	t.values[string(key.Bytes())] = new(big.Int).Set(value)
+
+	p.NewRoot = t.Root()
+	return p, nil
+}
+
+func (t *Tree) checkKey(key *big.Int) error {
+	if key.Sign() < 0 || key.BitLen() > t.depth {
+		return ErrKeyOutOfRange
+	}
+	return nil
+}
+
+// siblings returns the siblings of the path of key, from the leaf level to the root.
+func (t *Tree) siblings(key *big.Int) []*big.Int {
+	res := make([]*big.Int, t.depth)
+	index := new(big.Int).Set(key)
+	for i := 0; i < t.depth; i++ {
+		sibling := t.node(i, new(big.Int).Xor(index, big.NewInt(1)))
+		res[i] = new(big.Int).SetBytes(sibling)
+		index.Rsh(index, 1)
+	}
+	return res
+}
+
+// node returns the node at height i and position index.
+func (t *Tree) node(i int, index *big.Int) []byte {
+	if n, ok := t.nodes[i][string(index.Bytes())]; ok {
+		return n
+	}
+	return t.empty[i]
+}
+
+func (t *Tree) setNode(i int, index *big.Int, n []byte) {
+	k := string(index.Bytes())
+	if bytes.Equal(n, t.empty[i]) {
+		delete(t.nodes[i], k)
+		return
+	}
+	t.nodes[i][k] = n
+}
+
+func (t *Tree) toBytes(v *big.Int) []byte {
+	res := make([]byte, t.h.BlockSize())
+	v.FillBytes(res)
+	return res
+}
+
+// hashPair returns H(a, b), starting from a fresh hash state.
+func (t *Tree) hashPair(a, b []byte) ([]byte, error) {
+	t.h.Reset()
+	if _, err := t.h.Write(a); err != nil {
+		return nil, err
+	}
+	if _, err := t.h.Write(b); err != nil {
+		return nil, err
+	}
+	return t.h.Sum(nil), nil
+}
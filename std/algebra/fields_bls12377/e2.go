@@ -155,7 +155,7 @@ var InverseE2Hint = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) error
 }
 
 func init() {
-	hint.Register(InverseE2Hint)
+	hint.RegisterVersioned(InverseE2Hint, "github.com/consensys/gnark/std/algebra/fields_bls12377.InverseE2Hint", 1)
 }
 
 // Inverse e2 elmts
@@ -197,7 +197,7 @@ var DivE2Hint = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) error {
 }
 
 func init() {
-	hint.Register(DivE2Hint)
+	hint.RegisterVersioned(DivE2Hint, "github.com/consensys/gnark/std/algebra/fields_bls12377.DivE2Hint", 1)
 }
 
 // DivUnchecked e2 elmts
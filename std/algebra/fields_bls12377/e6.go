@@ -199,7 +199,7 @@ var DivE6Hint = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) error {
 }
 
 func init() {
-	hint.Register(DivE6Hint)
+	hint.RegisterVersioned(DivE6Hint, "github.com/consensys/gnark/std/algebra/fields_bls12377.DivE6Hint", 1)
 }
 
 // DivUnchecked e6 elmts
@@ -247,7 +247,7 @@ var InverseE6Hint = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) error
 }
 
 func init() {
-	hint.Register(InverseE6Hint)
+	hint.RegisterVersioned(InverseE6Hint, "github.com/consensys/gnark/std/algebra/fields_bls12377.InverseE6Hint", 1)
 }
 
 // Inverse e6 elmts
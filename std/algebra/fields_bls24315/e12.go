@@ -210,7 +210,7 @@ var InverseE12Hint = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) error
 }
 
 func init() {
-	hint.Register(InverseE12Hint)
+	hint.RegisterVersioned(InverseE12Hint, "github.com/consensys/gnark/std/algebra/fields_bls24315.InverseE12Hint", 1)
 }
 
 // Inverse e12 elmts
@@ -283,7 +283,7 @@ var DivE12Hint = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) error {
 }
 
 func init() {
-	hint.Register(DivE12Hint)
+	hint.RegisterVersioned(DivE12Hint, "github.com/consensys/gnark/std/algebra/fields_bls24315.DivE12Hint", 1)
 }
 
 // DivUnchecked e12 elmts
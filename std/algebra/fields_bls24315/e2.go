@@ -161,7 +161,7 @@ var DivE2Hint = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) error {
 }
 
 func init() {
-	hint.Register(DivE2Hint)
+	hint.RegisterVersioned(DivE2Hint, "github.com/consensys/gnark/std/algebra/fields_bls24315.DivE2Hint", 1)
 }
 
 // DivUnchecked e2 elmts
@@ -200,7 +200,7 @@ var InverseE2Hint = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) error
 }
 
 func init() {
-	hint.Register(InverseE2Hint)
+	hint.RegisterVersioned(InverseE2Hint, "github.com/consensys/gnark/std/algebra/fields_bls24315.InverseE2Hint", 1)
 }
 
 // Inverse e2 elmts
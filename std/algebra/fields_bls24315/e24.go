@@ -448,7 +448,7 @@ var InverseE24Hint = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) error
 }
 
 func init() {
-	hint.Register(InverseE24Hint)
+	hint.RegisterVersioned(InverseE24Hint, "github.com/consensys/gnark/std/algebra/fields_bls24315.InverseE24Hint", 1)
 }
 
 // Inverse e24 elmts
@@ -558,7 +558,7 @@ var DivE24Hint = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) error {
 }
 
 func init() {
-	hint.Register(DivE24Hint)
+	hint.RegisterVersioned(DivE24Hint, "github.com/consensys/gnark/std/algebra/fields_bls24315.DivE24Hint", 1)
 }
 
 // DivUnchecked e24 elmts
@@ -166,7 +166,7 @@ var DivE4Hint = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) error {
 }
 
 func init() {
-	hint.Register(DivE4Hint)
+	hint.RegisterVersioned(DivE4Hint, "github.com/consensys/gnark/std/algebra/fields_bls24315.DivE4Hint", 1)
 }
 
 // DivUnchecked e4 elmts
@@ -209,7 +209,7 @@ var InverseE4Hint = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) error
 }
 
 func init() {
-	hint.Register(InverseE4Hint)
+	hint.RegisterVersioned(InverseE4Hint, "github.com/consensys/gnark/std/algebra/fields_bls24315.InverseE4Hint", 1)
 }
 
 // Inverse e4 elmts
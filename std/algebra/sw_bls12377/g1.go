@@ -228,7 +228,7 @@ var DecomposeScalar = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) erro
 }
 
 func init() {
-	hint.Register(DecomposeScalar)
+	hint.RegisterVersioned(DecomposeScalar, "github.com/consensys/gnark/std/algebra/sw_bls12377.DecomposeScalar", 1)
 }
 
 // varScalarMul sets P = [s] Q and returns P.
@@ -243,7 +243,7 @@ var DecomposeScalarG2 = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) er
 }
 
 func init() {
-	hint.Register(DecomposeScalarG2)
+	hint.RegisterVersioned(DecomposeScalarG2, "github.com/consensys/gnark/std/algebra/sw_bls12377.DecomposeScalarG2", 1)
 }
 
 // varScalarMul sets P = [s] Q and returns P.
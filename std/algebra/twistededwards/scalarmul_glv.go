@@ -82,7 +82,7 @@ var DecomposeScalar = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) erro
 }
 
 func init() {
-	hint.Register(DecomposeScalar)
+	hint.RegisterVersioned(DecomposeScalar, "github.com/consensys/gnark/std/algebra/twistededwards.DecomposeScalar", 1)
 }
 
 // ScalarMul computes the scalar multiplication of a point on a twisted Edwards curve
@@ -0,0 +1,225 @@
+// Package weierstrass implements the arithmetic of short Weierstrass curves whose base field
+// is emulated in the circuit, such as secp256k1.
+//
+// The points are in affine coordinates and the formulas are incomplete: Add(p, q) requires
+// p ≠ ±q, and the point at infinity can't be represented. The scalar multiplications start
+// from a fixed point of unknown discrete logarithm and remove it at the end, so that the
+// exceptional cases are only hit with negligible probability for honestly generated inputs;
+// the solver fails when they are.
+package weierstrass
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/emulated"
+)
+
+// AffinePoint is a point of the curve in affine coordinates, with coordinates in the field Base.
+type AffinePoint[Base emulated.FieldParams] struct {
+	X, Y emulated.Element[Base]
+}
+
+// NewAffinePoint returns the point (x, y). If x and y are nil, the coordinates are left
+// unassigned, which is how a point is declared in a circuit.
+func NewAffinePoint[Base emulated.FieldParams](x, y interface{}) AffinePoint[Base] {
+	return AffinePoint[Base]{
+		X: emulated.NewElement[Base](x),
+		Y: emulated.NewElement[Base](y),
+	}
+}
+
+// Curve performs the arithmetic of the points of a curve in a circuit, the coordinates being in
+// the field Base and the scalars in the field Scalars.
+type Curve[Base, Scalars emulated.FieldParams] struct {
+	api         frontend.API
+	params      CurveParams
+	baseField   *emulated.Field[Base]
+	scalarField *emulated.Field[Scalars]
+
+	// offset of the scalar multiplications, and its negation multiplied by 2^nbBits
+	offset, offsetCorrection AffinePoint[Base]
+}
+
+// New returns a Curve for the curve described by params, whose moduli must be the ones of
+// Base and Scalars.
+func New[Base, Scalars emulated.FieldParams](api frontend.API, params CurveParams) (*Curve[Base, Scalars], error) {
+	baseField, err := emulated.NewField[Base](api)
+	if err != nil {
+		return nil, err
+	}
+	scalarField, err := emulated.NewField[Scalars](api)
+	if err != nil {
+		return nil, err
+	}
+	if baseField.Modulus().Cmp(params.P) != 0 || scalarField.Modulus().Cmp(params.N) != 0 {
+		return nil, errors.New("weierstrass: the emulated fields don't match the curve parameters")
+	}
+
+	// the scalar multiplications double the offset once per bit of the scalar
+	var fr Scalars
+	hx, hy := params.offset()
+	cx, cy := hx, hy
+	for i := uint(0); i < fr.NbLimbs()*fr.BitsPerLimb(); i++ {
+		cx, cy = params.Double(cx, cy)
+	}
+	cy = new(big.Int).Neg(cy)
+
+	return &Curve[Base, Scalars]{
+		api:              api,
+		params:           params,
+		baseField:        baseField,
+		scalarField:      scalarField,
+		offset:           NewAffinePoint[Base](hx, hy),
+		offsetCorrection: NewAffinePoint[Base](cx, cy),
+	}, nil
+}
+
+// API returns the underlying frontend.API.
+func (c *Curve[Base, Scalars]) API() frontend.API {
+	return c.api
+}
+
+// Params returns the parameters of the curve.
+func (c *Curve[Base, Scalars]) Params() CurveParams {
+	return c.params
+}
+
+// BaseField returns the emulated field of the coordinates.
+func (c *Curve[Base, Scalars]) BaseField() *emulated.Field[Base] {
+	return c.baseField
+}
+
+// ScalarField returns the emulated field of the scalars.
+func (c *Curve[Base, Scalars]) ScalarField() *emulated.Field[Scalars] {
+	return c.scalarField
+}
+
+// Generator returns the generator of the curve.
+func (c *Curve[Base, Scalars]) Generator() *AffinePoint[Base] {
+	g := NewAffinePoint[Base](c.params.Gx, c.params.Gy)
+	return &g
+}
+
+// Neg returns -p.
+func (c *Curve[Base, Scalars]) Neg(p *AffinePoint[Base]) *AffinePoint[Base] {
+	return &AffinePoint[Base]{
+		X: p.X,
+		Y: *c.baseField.Neg(&p.Y),
+	}
+}
+
+// Add returns p + q. p and q must be different and not opposite, that is q.x - p.x ≠ 0: this is
+// asserted, and the circuit is not satisfiable otherwise (use Double to add a point to itself).
+func (c *Curve[Base, Scalars]) Add(p, q *AffinePoint[Base]) *AffinePoint[Base] {
+	f := c.baseField
+
+	// λ = (q.y - p.y) / (q.x - p.x)
+	// the inverse asserts q.x - p.x ≠ 0, where a division would accept any λ for 0/0
+	lambda := f.Mul(f.Sub(&q.Y, &p.Y), f.Inverse(f.Sub(&q.X, &p.X)))
+	return c.finish(lambda, p, &q.X)
+}
+
+// Double returns 2·p.
+func (c *Curve[Base, Scalars]) Double(p *AffinePoint[Base]) *AffinePoint[Base] {
+	f := c.baseField
+
+	// λ = (3x² + a) / 2y
+	xx := f.Mul(&p.X, &p.X)
+	num := f.Add(f.Add(xx, xx), xx)
+	if c.params.A.Sign() != 0 {
+		num = f.Add(num, f.Constant(c.params.A))
+	}
+	lambda := f.Div(num, f.Add(&p.Y, &p.Y))
+	return c.finish(lambda, p, &p.X)
+}
+
+// finish returns the sum of p and of a point of abscissa x, given the slope λ.
+func (c *Curve[Base, Scalars]) finish(lambda *emulated.Element[Base], p *AffinePoint[Base], x *emulated.Element[Base]) *AffinePoint[Base] {
+	f := c.baseField
+
+	// x₃ = λ² - p.x - x
+	x3 := f.Sub(f.Sub(f.Mul(lambda, lambda), &p.X), x)
+
+	// y₃ = λ(p.x - x₃) - p.y
+	y3 := f.Sub(f.Mul(lambda, f.Sub(&p.X, x3)), &p.Y)
+
+	return &AffinePoint[Base]{X: *x3, Y: *y3}
+}
+
+// Select returns p if b is 1 and q if b is 0. b must be boolean.
+func (c *Curve[Base, Scalars]) Select(b frontend.Variable, p, q *AffinePoint[Base]) *AffinePoint[Base] {
+	return &AffinePoint[Base]{
+		X: *c.baseField.Select(b, &p.X, &q.X),
+		Y: *c.baseField.Select(b, &p.Y, &q.Y),
+	}
+}
+
+// AssertIsEqual asserts that p and q are the same point.
+func (c *Curve[Base, Scalars]) AssertIsEqual(p, q *AffinePoint[Base]) {
+	c.baseField.AssertIsEqual(&p.X, &q.X)
+	c.baseField.AssertIsEqual(&p.Y, &q.Y)
+}
+
+// AssertIsOnCurve asserts that p is on the curve.
+func (c *Curve[Base, Scalars]) AssertIsOnCurve(p *AffinePoint[Base]) {
+	f := c.baseField
+
+	// y² = x³ + a·x + b
+	rhs := f.Mul(f.Mul(&p.X, &p.X), &p.X)
+	if c.params.A.Sign() != 0 {
+		rhs = f.Add(rhs, f.Mul(f.Constant(c.params.A), &p.X))
+	}
+	rhs = f.Add(rhs, f.Constant(c.params.B))
+	f.AssertIsEqual(f.Mul(&p.Y, &p.Y), rhs)
+}
+
+// ScalarMul returns s·p.
+func (c *Curve[Base, Scalars]) ScalarMul(p *AffinePoint[Base], s *emulated.Element[Scalars]) *AffinePoint[Base] {
+	bits := c.scalarField.ToBits(s)
+
+	acc := &c.offset
+	for i := len(bits) - 1; i >= 0; i-- {
+		acc = c.Double(acc)
+		acc = c.Select(bits[i], c.Add(acc, p), acc)
+	}
+	return c.Add(acc, &c.offsetCorrection)
+}
+
+// ScalarMulBase returns s·G, G being the generator of the curve.
+func (c *Curve[Base, Scalars]) ScalarMulBase(s *emulated.Element[Scalars]) *AffinePoint[Base] {
+	return c.ScalarMul(c.Generator(), s)
+}
+
+// JointScalarMul returns s·p + t·q, sharing the doublings of both multiplications.
+// p and q must be different and not opposite.
+func (c *Curve[Base, Scalars]) JointScalarMul(p, q *AffinePoint[Base], s, t *emulated.Element[Scalars]) *AffinePoint[Base] {
+	sBits := c.scalarField.ToBits(s)
+	tBits := c.scalarField.ToBits(t)
+	pq := c.Add(p, q)
+
+	acc := &c.offset
+	for i := len(sBits) - 1; i >= 0; i-- {
+		acc = c.Double(acc)
+
+		// add p, q or p+q depending on the bits; when both are 0 the sum is discarded
+		addend := c.lookup2(sBits[i], tBits[i], p, p, q, pq)
+		acc = c.Select(c.api.Or(sBits[i], tBits[i]), c.Add(acc, addend), acc)
+	}
+	return c.Add(acc, &c.offsetCorrection)
+}
+
+// JointScalarMulBase returns s·G + t·q, G being the generator of the curve.
+func (c *Curve[Base, Scalars]) JointScalarMulBase(q *AffinePoint[Base], s, t *emulated.Element[Scalars]) *AffinePoint[Base] {
+	return c.JointScalarMul(c.Generator(), q, s, t)
+}
+
+// lookup2 returns the point of index b0 + 2·b1.
+func (c *Curve[Base, Scalars]) lookup2(b0, b1 frontend.Variable, p0, p1, p2, p3 *AffinePoint[Base]) *AffinePoint[Base] {
+	f := c.baseField
+	return &AffinePoint[Base]{
+		X: *f.Lookup2(b0, b1, &p0.X, &p1.X, &p2.X, &p3.X),
+		Y: *f.Lookup2(b0, b1, &p0.Y, &p1.Y, &p2.Y, &p3.Y),
+	}
+}
@@ -0,0 +1,137 @@
+package weierstrass
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/test"
+)
+
+type addCircuit struct {
+	P, Q        AffinePoint[emulated.Secp256k1Fp]
+	Sum, Double AffinePoint[emulated.Secp256k1Fp] `gnark:",public"`
+}
+
+func (circuit *addCircuit) Define(api frontend.API) error {
+	curve, err := New[emulated.Secp256k1Fp, emulated.Secp256k1Fr](api, GetSecp256k1Params())
+	if err != nil {
+		return err
+	}
+	curve.AssertIsOnCurve(&circuit.P)
+	curve.AssertIsEqual(curve.Add(&circuit.P, &circuit.Q), &circuit.Sum)
+	curve.AssertIsEqual(curve.Double(&circuit.P), &circuit.Double)
+	return nil
+}
+
+func TestAdd(t *testing.T) {
+	assert := test.NewAssert(t)
+	params := GetSecp256k1Params()
+
+	px, py := params.ScalarBaseMul(randomScalar(params))
+	qx, qy := params.ScalarBaseMul(randomScalar(params))
+	sx, sy := params.Add(px, py, qx, qy)
+	dx, dy := params.Double(px, py)
+
+	circuit := addCircuit{
+		P:      newPoint(nil, nil),
+		Q:      newPoint(nil, nil),
+		Sum:    newPoint(nil, nil),
+		Double: newPoint(nil, nil),
+	}
+	witness := addCircuit{
+		P:      newPoint(px, py),
+		Q:      newPoint(qx, qy),
+		Sum:    newPoint(sx, sy),
+		Double: newPoint(dx, dy),
+	}
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	wrongWitness := witness
+	wrongWitness.Sum = newPoint(sx, new(big.Int).Neg(sy))
+	assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(ecc.BN254))
+
+	// P is not on the curve
+	wrongWitness = witness
+	wrongWitness.P = newPoint(px, new(big.Int).Add(py, big.NewInt(1)))
+	assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(ecc.BN254))
+
+	// Q = P and Q = -P: q.x - p.x = 0 is rejected, whatever the sum
+	for _, q := range []AffinePoint[emulated.Secp256k1Fp]{
+		newPoint(px, py),
+		newPoint(px, new(big.Int).Sub(params.P, py)),
+	} {
+		wrongWitness = witness
+		wrongWitness.Q = q
+		wrongWitness.Sum = newPoint(dx, dy)
+		assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(ecc.BN254))
+	}
+}
+
+type scalarMulCircuit struct {
+	P            AffinePoint[emulated.Secp256k1Fp]
+	S, T         emulated.Element[emulated.Secp256k1Fr]
+	SP, SGPlusTP AffinePoint[emulated.Secp256k1Fp] `gnark:",public"`
+}
+
+func (circuit *scalarMulCircuit) Define(api frontend.API) error {
+	curve, err := New[emulated.Secp256k1Fp, emulated.Secp256k1Fr](api, GetSecp256k1Params())
+	if err != nil {
+		return err
+	}
+	curve.AssertIsEqual(curve.ScalarMul(&circuit.P, &circuit.S), &circuit.SP)
+	curve.AssertIsEqual(curve.JointScalarMulBase(&circuit.P, &circuit.S, &circuit.T), &circuit.SGPlusTP)
+	return nil
+}
+
+func TestScalarMul(t *testing.T) {
+	params := GetSecp256k1Params()
+
+	px, py := params.ScalarBaseMul(randomScalar(params))
+	s, u := randomScalar(params), randomScalar(params)
+	spx, spy := params.ScalarMul(px, py, s)
+	sgx, sgy := params.ScalarBaseMul(s)
+	upx, upy := params.ScalarMul(px, py, u)
+	jx, jy := params.Add(sgx, sgy, upx, upy)
+
+	circuit := scalarMulCircuit{
+		P:        newPoint(nil, nil),
+		S:        emulated.NewElement[emulated.Secp256k1Fr](nil),
+		T:        emulated.NewElement[emulated.Secp256k1Fr](nil),
+		SP:       newPoint(nil, nil),
+		SGPlusTP: newPoint(nil, nil),
+	}
+	witness := scalarMulCircuit{
+		P:        newPoint(px, py),
+		S:        emulated.NewElement[emulated.Secp256k1Fr](s),
+		T:        emulated.NewElement[emulated.Secp256k1Fr](u),
+		SP:       newPoint(spx, spy),
+		SGPlusTP: newPoint(jx, jy),
+	}
+
+	// the circuit is too large to be compiled in a unit test, so it is only run by the test engine
+	if err := test.IsSolved(&circuit, &witness, ecc.BN254, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+
+	witness.SGPlusTP = newPoint(sgx, sgy)
+	if err := test.IsSolved(&circuit, &witness, ecc.BN254, backend.UNKNOWN); err == nil {
+		t.Fatal("expected the solver to fail")
+	}
+}
+
+func randomScalar(params CurveParams) *big.Int {
+	s, err := rand.Int(rand.Reader, params.N)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func newPoint(x, y interface{}) AffinePoint[emulated.Secp256k1Fp] {
+	return NewAffinePoint[emulated.Secp256k1Fp](x, y)
+}
@@ -0,0 +1,119 @@
+package weierstrass
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/std/math/emulated"
+)
+
+// CurveParams describes a short Weierstrass curve y² = x³ + A·x + B of prime order N over
+// the field of modulus P. The fields are emulated in the circuit, as given by the type
+// parameters of Curve.
+type CurveParams struct {
+	A, B   *big.Int
+	Gx, Gy *big.Int // generator
+	P, N   *big.Int // base field modulus and order of the curve
+}
+
+// GetSecp256k1Params returns the parameters of the secp256k1 curve.
+func GetSecp256k1Params() CurveParams {
+	gx, _ := new(big.Int).SetString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", 16)
+	gy, _ := new(big.Int).SetString("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", 16)
+	return CurveParams{
+		A:  big.NewInt(0),
+		B:  big.NewInt(7),
+		Gx: gx,
+		Gy: gy,
+		P:  new(big.Int).Set(emulated.Secp256k1Fp{}.Modulus()),
+		N:  new(big.Int).Set(emulated.Secp256k1Fr{}.Modulus()),
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// native arithmetic, the point at infinity being (nil, nil)
+
+// IsOnCurve reports whether (x, y) is on the curve.
+func (c CurveParams) IsOnCurve(x, y *big.Int) bool {
+	if x == nil || y == nil {
+		return x == nil && y == nil
+	}
+	var lhs, rhs big.Int
+	lhs.Mul(y, y).Mod(&lhs, c.P)
+	rhs.Mul(x, x).Add(&rhs, c.A).Mul(&rhs, x).Add(&rhs, c.B).Mod(&rhs, c.P)
+	return lhs.Cmp(&rhs) == 0
+}
+
+// Add returns (x1, y1) + (x2, y2).
+func (c CurveParams) Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
+	p := c.P
+	if x1 == nil {
+		return x2, y2
+	}
+	if x2 == nil {
+		return x1, y1
+	}
+	if x1.Cmp(x2) == 0 {
+		var sum big.Int
+		if sum.Add(y1, y2).Mod(&sum, p).Sign() == 0 {
+			return nil, nil
+		}
+		return c.Double(x1, y1)
+	}
+	// λ = (y2 - y1) / (x2 - x1)
+	var lambda, den big.Int
+	den.Sub(x2, x1).Mod(&den, p)
+	den.ModInverse(&den, p)
+	lambda.Sub(y2, y1).Mul(&lambda, &den).Mod(&lambda, p)
+	return c.finish(&lambda, x1, y1, x2)
+}
+
+// Double returns 2·(x1, y1).
+func (c CurveParams) Double(x1, y1 *big.Int) (x, y *big.Int) {
+	p := c.P
+	if x1 == nil || y1.Sign() == 0 {
+		return nil, nil
+	}
+	// λ = (3x² + A) / 2y
+	var lambda, den big.Int
+	den.Lsh(y1, 1).Mod(&den, p)
+	den.ModInverse(&den, p)
+	lambda.Mul(x1, x1).Mul(&lambda, big.NewInt(3)).Add(&lambda, c.A).Mul(&lambda, &den).Mod(&lambda, p)
+	return c.finish(&lambda, x1, y1, x1)
+}
+
+// finish returns the sum of (x1, y1) and a point of abscissa x2, given the slope λ.
+func (c CurveParams) finish(lambda, x1, y1, x2 *big.Int) (x, y *big.Int) {
+	p := c.P
+	x, y = new(big.Int), new(big.Int)
+	x.Mul(lambda, lambda).Sub(x, x1).Sub(x, x2).Mod(x, p)
+	y.Sub(x1, x).Mul(y, lambda).Sub(y, y1).Mod(y, p)
+	return x, y
+}
+
+// ScalarMul returns k·(x1, y1).
+func (c CurveParams) ScalarMul(x1, y1, k *big.Int) (x, y *big.Int) {
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		x, y = c.Double(x, y)
+		if k.Bit(i) == 1 {
+			x, y = c.Add(x, y, x1, y1)
+		}
+	}
+	return x, y
+}
+
+// ScalarBaseMul returns k·G.
+func (c CurveParams) ScalarBaseMul(k *big.Int) (x, y *big.Int) {
+	return c.ScalarMul(c.Gx, c.Gy, k)
+}
+
+// offset returns a point of unknown discrete logarithm, the one of smallest abscissa.
+func (c CurveParams) offset() (x, y *big.Int) {
+	p := c.P
+	for x = big.NewInt(1); ; x.Add(x, big.NewInt(1)) {
+		var rhs big.Int
+		rhs.Mul(x, x).Add(&rhs, c.A).Mul(&rhs, x).Add(&rhs, c.B).Mod(&rhs, p)
+		if y = new(big.Int).ModSqrt(&rhs, p); y != nil {
+			return x, y
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2022 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poseidon
+
+import (
+	"errors"
+	"hash"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// NativeHash computes natively Poseidon(inputs...) on the scalar field of curve, with a state of
+// width len(inputs)+1. On BN254 the result is the same as circomlib's Poseidon template.
+func NativeHash(curve ecc.ID, inputs ...*big.Int) (*big.Int, error) {
+	if len(inputs) == 0 || len(inputs) > MaxInputs {
+		return nil, errors.New("poseidon: number of inputs must be between 1 and 16")
+	}
+	p, err := getParameters(curve, len(inputs)+1)
+	if err != nil {
+		return nil, err
+	}
+	modulus := curve.ScalarField()
+
+	state := make([]big.Int, p.t)
+	for i, in := range inputs {
+		state[i+1].Mod(in, modulus)
+	}
+	p.permute(state, modulus)
+
+	return new(big.Int).Set(&state[0]), nil
+}
+
+// permute applies the Poseidon permutation to state, in place.
+func (p *parameters) permute(state []big.Int, modulus *big.Int) {
+	var alpha big.Int
+	alpha.SetUint64(p.alpha)
+	tmp := make([]big.Int, p.t)
+	var prod big.Int
+
+	for r := 0; r < p.rF+p.rP; r++ {
+		for i := range state {
+			state[i].Add(&state[i], &p.c[r*p.t+i]).Mod(&state[i], modulus)
+		}
+		if r < p.rF/2 || r >= p.rF/2+p.rP {
+			for i := range state {
+				state[i].Exp(&state[i], &alpha, modulus)
+			}
+		} else {
+			state[0].Exp(&state[0], &alpha, modulus)
+		}
+		for i := range tmp {
+			tmp[i].SetUint64(0)
+			for j := range state {
+				prod.Mul(&p.m[i][j], &state[j])
+				tmp[i].Add(&tmp[i], &prod)
+			}
+			tmp[i].Mod(&tmp[i], modulus)
+		}
+		for i := range state {
+			state[i].Set(&tmp[i])
+		}
+	}
+}
+
+// digest is the native counterpart of the Poseidon gadget; it implements hash.Hash.
+type digest struct {
+	curve     ecc.ID
+	modulus   *big.Int
+	h         big.Int   // current digest
+	hasDigest bool      // false until data has been absorbed
+	data      []big.Int // data to hash at the next call to Sum
+}
+
+// NewNative returns a hash.Hash computing natively the same digest as the Poseidon gadget.
+//
+// Bytes are written by blocks of BlockSize(), each block being the big endian encoding
+// of a field element, as with the gnark-crypto MiMC implementations.
+func NewNative(curve ecc.ID) (hash.Hash, error) {
+	if _, ok := supportedCurves[curve]; !ok {
+		return nil, errUnknownCurve
+	}
+	return &digest{curve: curve, modulus: curve.ScalarField()}, nil
+}
+
+// Reset resets the Hash to its initial state.
+func (d *digest) Reset() {
+	d.data = nil
+	d.h.SetUint64(0)
+	d.hasDigest = false
+}
+
+// Size returns the number of bytes Sum will return.
+func (d *digest) Size() int {
+	return d.BlockSize()
+}
+
+// BlockSize returns the number of bytes of a field element.
+func (d *digest) BlockSize() int {
+	return (d.modulus.BitLen() + 7) / 8
+}
+
+// Write buffers p, which must be a sequence of big endian encoded field elements.
+func (d *digest) Write(p []byte) (int, error) {
+	bs := d.BlockSize()
+	if len(p)%bs != 0 {
+		return 0, errors.New("poseidon: invalid buffer size, must be a multiple of BlockSize()")
+	}
+	for start := 0; start < len(p); start += bs {
+		var e big.Int
+		e.SetBytes(p[start : start+bs])
+		if e.Cmp(d.modulus) >= 0 {
+			return 0, errors.New("poseidon: block is not a canonical field element")
+		}
+		d.data = append(d.data, e)
+	}
+	return len(p), nil
+}
+
+// Sum appends the current digest to b and returns the resulting slice.
+// The buffered data is absorbed, but the running digest is not reset.
+func (d *digest) Sum(b []byte) []byte {
+	for len(d.data) > 0 {
+		inputs := make([]*big.Int, 0, MaxInputs)
+		if d.hasDigest {
+			inputs = append(inputs, &d.h)
+		}
+		for len(inputs) < MaxInputs && len(d.data) > 0 {
+			inputs = append(inputs, &d.data[0])
+			d.data = d.data[1:]
+		}
+		h, err := NativeHash(d.curve, inputs...)
+		if err != nil {
+			panic(err) // unreachable, curve and number of inputs are checked
+		}
+		d.h.Set(h)
+		d.hasDigest = true
+	}
+	d.data = nil
+
+	res := make([]byte, d.BlockSize())
+	d.h.FillBytes(res)
+	return append(b, res...)
+}
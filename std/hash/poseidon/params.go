@@ -0,0 +1,222 @@
+/*
+Copyright © 2022 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poseidon
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// MaxInputs is the largest number of field elements absorbed by a single
+// permutation, as in circomlib (state width t = MaxInputs + 1).
+const MaxInputs = 16
+
+// fullRounds is the number of full rounds R_F, for every state width.
+const fullRounds = 8
+
+// sboxExponent is the exponent α of the s-box x ↦ x^α.
+const sboxExponent = 5
+
+// partialRounds[t-2] is the number of partial rounds R_P for a state of width t.
+// These are the values used by circomlib for 128 bits of security with x⁵ on the
+// BN254 scalar field; they don't carry over to other fields or exponents.
+var partialRounds = [MaxInputs]int{56, 57, 56, 60, 60, 63, 64, 63, 60, 66, 60, 65, 70, 60, 64, 68}
+
+// supportedCurves lists the curves on which the Poseidon gadget is implemented.
+// The round numbers are only known to be secure on BN254, see partialRounds.
+var supportedCurves = map[ecc.ID]struct{}{
+	ecc.BN254: {},
+}
+
+var errUnknownCurve = errors.New("unknown curve id")
+
+// parameters of the Poseidon permutation for a given curve and state width
+type parameters struct {
+	t     int         // state width (number of inputs + 1)
+	rF    int         // number of full rounds
+	rP    int         // number of partial rounds
+	alpha uint64      // s-box exponent
+	c     []big.Int   // round constants, c[r*t+i] is added to the i-th cell at round r
+	m     [][]big.Int // MDS matrix, the new i-th cell is Σⱼ m[i][j]*state[j]
+}
+
+type paramsKey struct {
+	curve ecc.ID
+	t     int
+}
+
+var (
+	paramsLock  sync.Mutex
+	paramsCache = make(map[paramsKey]*parameters)
+)
+
+// getParameters returns the (cached) parameters of the permutation of width t on curve.
+func getParameters(curve ecc.ID, t int) (*parameters, error) {
+	if _, ok := supportedCurves[curve]; !ok {
+		return nil, errUnknownCurve
+	}
+	if t < 2 || t > MaxInputs+1 {
+		return nil, errors.New("poseidon: state width must be between 2 and 17")
+	}
+
+	paramsLock.Lock()
+	defer paramsLock.Unlock()
+
+	key := paramsKey{curve, t}
+	if p, ok := paramsCache[key]; ok {
+		return p, nil
+	}
+	p := newParameters(curve.ScalarField(), t)
+	paramsCache[key] = p
+	return p, nil
+}
+
+// newParameters generates the round constants and the MDS matrix with the Grain LFSR,
+// following the reference script of the Poseidon paper (generate_parameters_grain.sage).
+// For BN254 this yields the constants hard-coded in circomlib.
+func newParameters(modulus *big.Int, t int) *parameters {
+	p := &parameters{
+		t:     t,
+		rF:    fullRounds,
+		rP:    partialRounds[t-2],
+		alpha: sboxExponent,
+	}
+	n := modulus.BitLen()
+	g := newGrain(n, t, p.rF, p.rP)
+
+	// round constants, sampled by rejection
+	p.c = make([]big.Int, (p.rF+p.rP)*t)
+	for i := range p.c {
+		for {
+			g.randomBits(&p.c[i], n)
+			if p.c[i].Cmp(modulus) < 0 {
+				break
+			}
+		}
+	}
+
+	// Cauchy MDS matrix m[i][j] = 1 / (xᵢ + yⱼ)
+	p.m = make([][]big.Int, t)
+	for i := range p.m {
+		p.m[i] = make([]big.Int, t)
+	}
+	for !p.sampleMDS(g, modulus, n) {
+	}
+
+	return p
+}
+
+// sampleMDS draws 2t distinct field elements from g and sets p.m to the corresponding
+// Cauchy matrix. It returns false if the sample doesn't define a valid matrix.
+func (p *parameters) sampleMDS(g *grain, modulus *big.Int, n int) bool {
+	t := p.t
+	xy := make([]big.Int, 2*t)
+	for {
+		for i := range xy {
+			g.randomBits(&xy[i], n)
+			xy[i].Mod(&xy[i], modulus)
+		}
+		if distinct(xy) {
+			break
+		}
+	}
+	xs, ys := xy[:t], xy[t:]
+	for i := 0; i < t; i++ {
+		for j := 0; j < t; j++ {
+			p.m[i][j].Add(&xs[i], &ys[j]).Mod(&p.m[i][j], modulus)
+			if p.m[i][j].Sign() == 0 {
+				return false
+			}
+			p.m[i][j].ModInverse(&p.m[i][j], modulus)
+		}
+	}
+	return true
+}
+
+func distinct(s []big.Int) bool {
+	for i := range s {
+		for j := i + 1; j < len(s); j++ {
+			if s[i].Cmp(&s[j]) == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// grain is the self-shrinking Grain LFSR used to derive the Poseidon constants.
+type grain struct {
+	state [80]uint8
+	pos   int
+}
+
+func newGrain(n, t, rF, rP int) *grain {
+	g := new(grain)
+	i := 0
+	push := func(v, nbBits int) {
+		for j := nbBits - 1; j >= 0; j-- {
+			g.state[i] = uint8((v >> j) & 1)
+			i++
+		}
+	}
+	push(1, 2)  // prime field
+	push(0, 4)  // s-box x^α
+	push(n, 12) // field size
+	push(t, 12)
+	push(rF, 10)
+	push(rP, 10)
+	push((1<<30)-1, 30)
+
+	for j := 0; j < 160; j++ {
+		g.clock()
+	}
+	return g
+}
+
+// clock shifts the register once and returns the new bit.
+func (g *grain) clock() uint8 {
+	at := func(k int) uint8 { return g.state[(g.pos+k)%80] }
+	b := at(62) ^ at(51) ^ at(38) ^ at(23) ^ at(13) ^ at(0)
+	g.state[g.pos] = b
+	g.pos = (g.pos + 1) % 80
+	return b
+}
+
+// next returns the next output bit: bits are read in pairs, and the second one is
+// kept only if the first one is set.
+func (g *grain) next() uint8 {
+	for {
+		if g.clock() == 1 {
+			return g.clock()
+		}
+		g.clock()
+	}
+}
+
+// randomBits sets z to the integer made of the next n output bits, msb first.
+func (g *grain) randomBits(z *big.Int, n int) {
+	z.SetUint64(0)
+	for i := 0; i < n; i++ {
+		z.Lsh(z, 1)
+		if g.next() == 1 {
+			z.SetBit(z, 0, 1)
+		}
+	}
+}
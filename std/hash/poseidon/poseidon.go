@@ -0,0 +1,139 @@
+/*
+Copyright © 2022 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package poseidon provides a ZKP-circuit function to compute a Poseidon hash.
+//
+// The permutation uses x⁵ as s-box, 8 full rounds and the number of partial rounds of
+// circomlib; constants are derived with the Grain LFSR of the Poseidon reference
+// implementation. The digests are the same as circomlib's Poseidon template, which makes
+// circuits imported from circom interoperable. Only BN254 is supported, as these round
+// numbers are not secure on arbitrary fields.
+package poseidon
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+// Poseidon contains the state of a Poseidon hash in a circuit.
+//
+// Data is absorbed by chunks: the first chunk contains up to MaxInputs elements, the
+// next ones contain the previous digest followed by up to MaxInputs-1 elements. Hashing at
+// most MaxInputs elements is thus the same as circomlib's Poseidon(data...).
+type Poseidon struct {
+	h         frontend.Variable   // current digest
+	hasDigest bool                // false until data has been absorbed
+	data      []frontend.Variable // state storage. data is updated when Write() is called. Sum sums the data.
+	api       frontend.API        // underlying constraint system
+}
+
+// NewPoseidon returns a Poseidon instance, that can be used in a gnark circuit
+func NewPoseidon(api frontend.API) (Poseidon, error) {
+	if _, ok := supportedCurves[api.Compiler().Curve()]; !ok {
+		return Poseidon{}, errUnknownCurve
+	}
+	return Poseidon{h: 0, api: api}, nil
+}
+
+// Write adds more data to the running hash.
+func (h *Poseidon) Write(data ...frontend.Variable) {
+	h.data = append(h.data, data...)
+}
+
+// Reset resets the Hash to its initial state.
+func (h *Poseidon) Reset() {
+	h.data = nil
+	h.h = 0
+	h.hasDigest = false
+}
+
+// Sum absorbs the data written so far and returns the current digest.
+func (h *Poseidon) Sum() frontend.Variable {
+	for len(h.data) > 0 {
+		inputs := make([]frontend.Variable, 0, MaxInputs)
+		if h.hasDigest {
+			inputs = append(inputs, h.h)
+		}
+		n := MaxInputs - len(inputs)
+		if n > len(h.data) {
+			n = len(h.data)
+		}
+		inputs = append(inputs, h.data[:n]...)
+		h.data = h.data[n:]
+
+		h.h = Hash(h.api, inputs...)
+		h.hasDigest = true
+	}
+
+	h.data = nil // flush the data already hashed
+
+	return h.h
+}
+
+// Hash returns Poseidon(inputs...) computed with a state of width len(inputs)+1.
+// It panics if the curve is not supported or if len(inputs) is not in [1, MaxInputs].
+func Hash(api frontend.API, inputs ...frontend.Variable) frontend.Variable {
+	p, err := getParameters(api.Compiler().Curve(), len(inputs)+1)
+	if err != nil {
+		panic(err)
+	}
+
+	state := make([]frontend.Variable, p.t)
+	state[0] = 0
+	copy(state[1:], inputs)
+	p.permuteCircuit(api, state)
+
+	return state[0]
+}
+
+// permuteCircuit applies the Poseidon permutation to state, in place.
+func (p *parameters) permuteCircuit(api frontend.API, state []frontend.Variable) {
+	for r := 0; r < p.rF+p.rP; r++ {
+		for i := range state {
+			state[i] = api.Add(state[i], p.c[r*p.t+i])
+		}
+		if r < p.rF/2 || r >= p.rF/2+p.rP {
+			for i := range state {
+				state[i] = p.sbox(api, state[i])
+			}
+		} else {
+			state[0] = p.sbox(api, state[0])
+		}
+		mixed := make([]frontend.Variable, p.t)
+		for i := range mixed {
+			terms := make([]frontend.Variable, p.t)
+			for j := range state {
+				terms[j] = api.Mul(p.m[i][j], state[j])
+			}
+			mixed[i] = api.Add(terms[0], terms[1], terms[2:]...)
+		}
+		copy(state, mixed)
+	}
+}
+
+// sbox returns x^α
+func (p *parameters) sbox(api frontend.API, x frontend.Variable) frontend.Variable {
+	var res frontend.Variable = 1
+	acc := x
+	for e := p.alpha; e != 0; e >>= 1 {
+		if e&1 == 1 {
+			res = api.Mul(res, acc)
+		}
+		if e > 1 {
+			acc = api.Mul(acc, acc)
+		}
+	}
+	return res
+}
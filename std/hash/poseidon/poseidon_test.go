@@ -0,0 +1,135 @@
+/*
+Copyright © 2022 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poseidon
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+type poseidonCircuit struct {
+	ExpectedResult frontend.Variable `gnark:"data,public"`
+	Data           [20]frontend.Variable
+}
+
+func (circuit *poseidonCircuit) Define(api frontend.API) error {
+	poseidon, err := NewPoseidon(api)
+	if err != nil {
+		return err
+	}
+	poseidon.Write(circuit.Data[:]...)
+	result := poseidon.Sum()
+	api.AssertIsEqual(result, circuit.ExpectedResult)
+	return nil
+}
+
+func TestPoseidonAll(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	for curve := range supportedCurves {
+
+		// minimal cs res = hash(data)
+		var circuit, witness, wrongWitness poseidonCircuit
+
+		modulus := curve.ScalarField()
+		var data [20]big.Int
+		data[0].Sub(modulus, big.NewInt(1))
+		for i := 1; i < 20; i++ {
+			data[i].Add(&data[i-1], &data[i-1]).Mod(&data[i], modulus)
+		}
+
+		// running Poseidon (Go)
+		goPoseidon, err := NewNative(curve)
+		assert.NoError(err)
+		bs := goPoseidon.BlockSize()
+		for i := 0; i < 20; i++ {
+			b := make([]byte, bs)
+			data[i].FillBytes(b)
+			_, err = goPoseidon.Write(b)
+			assert.NoError(err)
+		}
+		expectedh := goPoseidon.Sum(nil)
+
+		// assert correctness against correct witness
+		for i := 0; i < 20; i++ {
+			witness.Data[i] = data[i].String()
+		}
+		witness.ExpectedResult = expectedh
+		assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(curve))
+
+		// assert failure against wrong witness
+		for i := 0; i < 20; i++ {
+			wrongWitness.Data[i] = data[i].Sub(&data[i], big.NewInt(1)).String()
+		}
+		wrongWitness.ExpectedResult = expectedh
+		assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(curve))
+	}
+
+}
+
+type poseidonFixedCircuit struct {
+	ExpectedResult frontend.Variable `gnark:"data,public"`
+	Data           [4]frontend.Variable
+}
+
+func (circuit *poseidonFixedCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(Hash(api, circuit.Data[:]...), circuit.ExpectedResult)
+	return nil
+}
+
+// TestCircomlibVectors checks the BN254 digests against the ones of circomlib.
+func TestCircomlibVectors(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	vectors := []struct {
+		inputs   []int64
+		expected string
+	}{
+		{[]int64{1}, "18586133768512220936620570745912940619677854269274689475585506675881198879027"},
+		{[]int64{1, 2}, "7853200120776062878684798364095072458815029376092732009249414926327459813530"},
+		{[]int64{1, 2, 3, 4}, "18821383157269793795438455681495246036402687001665670618754263018637548127333"},
+	}
+	for _, v := range vectors {
+		inputs := make([]*big.Int, len(v.inputs))
+		for i := range inputs {
+			inputs[i] = big.NewInt(v.inputs[i])
+		}
+		h, err := NativeHash(ecc.BN254, inputs...)
+		assert.NoError(err)
+		assert.Equal(v.expected, h.String())
+	}
+
+	var circuit, witness poseidonFixedCircuit
+	for i := range witness.Data {
+		witness.Data[i] = i + 1
+	}
+	witness.ExpectedResult = vectors[2].expected
+	assert.ProverSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+}
+
+func TestUnsupportedCurve(t *testing.T) {
+	if _, err := NativeHash(ecc.BLS12_381, big.NewInt(1)); err != errUnknownCurve {
+		t.Fatal("expected an error on BLS12-381, got", err)
+	}
+	if _, err := NewNative(ecc.BLS12_381); err != errUnknownCurve {
+		t.Fatal("expected an error on BLS12-381, got", err)
+	}
+}
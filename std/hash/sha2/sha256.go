@@ -0,0 +1,223 @@
+// Package sha2 provides a ZKP-circuit function to compute a SHA-256 digest.
+//
+// The gadget works on bytes: inputs are variables in [0, 256), which is enforced by the
+// circuit, and the digest is returned as 32 byte variables. Words are handled as slices
+// of bits, so that rotations and shifts are free and boolean functions cost at most one
+// constraint per bit; modular additions are done on field elements and decomposed once.
+package sha2
+
+import (
+	mbits "math/bits"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// Size is the size of a SHA-256 digest in bytes.
+const Size = 32
+
+// BlockSize is the block size of SHA-256 in bytes.
+const BlockSize = 64
+
+var _K = [64]uint32{
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+}
+
+var _H0 = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+// word is a 32-bit word, as a slice of bits (lsb first).
+type word [32]frontend.Variable
+
+// Sum256 returns the SHA-256 digest of data, each element of data being a byte.
+func Sum256(api frontend.API, data []frontend.Variable) [Size]frontend.Variable {
+	// decompose the input and pad it with 0x80 || 0x00... || len(data) in bits (big endian)
+	msg := make([][8]frontend.Variable, 0, len(data)+2*BlockSize)
+	for i := range data {
+		msg = append(msg, byteBits(api, data[i]))
+	}
+	msg = append(msg, constByte(0x80))
+	for len(msg)%BlockSize != BlockSize-8 {
+		msg = append(msg, constByte(0))
+	}
+	bitLen := uint64(len(data)) * 8
+	for i := 7; i >= 0; i-- {
+		msg = append(msg, constByte(uint8(bitLen>>(8*i))))
+	}
+
+	var h [8]word
+	for i := range h {
+		h[i] = constWord(_H0[i])
+	}
+	for start := 0; start < len(msg); start += BlockSize {
+		compress(api, &h, msg[start:start+BlockSize])
+	}
+
+	var res [Size]frontend.Variable
+	for i := range h {
+		for j := 0; j < 4; j++ {
+			// big endian: the first byte holds the most significant bits
+			res[4*i+j] = fromBits(api, h[i][24-8*j:32-8*j])
+		}
+	}
+	return res
+}
+
+// compress updates h with a 64 bytes block.
+func compress(api frontend.API, h *[8]word, block [][8]frontend.Variable) {
+	var w [64]word
+	for i := 0; i < 16; i++ {
+		for j := 0; j < 4; j++ {
+			copy(w[i][8*j:8*j+8], block[4*i+3-j][:])
+		}
+	}
+	for i := 16; i < 64; i++ {
+		s0 := xor3(api, rotr(w[i-15], 7), rotr(w[i-15], 18), shr(w[i-15], 3))
+		s1 := xor3(api, rotr(w[i-2], 17), rotr(w[i-2], 19), shr(w[i-2], 10))
+		w[i] = add(api, w[i-16], s0, w[i-7], s1)
+	}
+
+	a, b, c, d, e, f, g, hh := h[0], h[1], h[2], h[3], h[4], h[5], h[6], h[7]
+	for i := 0; i < 64; i++ {
+		S1 := xor3(api, rotr(e, 6), rotr(e, 11), rotr(e, 25))
+		S0 := xor3(api, rotr(a, 2), rotr(a, 13), rotr(a, 22))
+		chEFG := ch(api, e, f, g)
+		majABC := maj(api, a, b, c)
+		k := constWord(_K[i])
+
+		// with t1 = hh + S1 + ch + k + w[i] and t2 = S0 + maj, the new values d + t1 and
+		// t1 + t2 are reduced directly to save the decomposition of t1
+		newE := add(api, d, hh, S1, chEFG, k, w[i])
+		newA := add(api, hh, S1, chEFG, k, w[i], S0, majABC)
+
+		hh, g, f, e, d, c, b, a = g, f, e, newE, c, b, a, newA
+	}
+
+	h[0] = add(api, h[0], a)
+	h[1] = add(api, h[1], b)
+	h[2] = add(api, h[2], c)
+	h[3] = add(api, h[3], d)
+	h[4] = add(api, h[4], e)
+	h[5] = add(api, h[5], f)
+	h[6] = add(api, h[6], g)
+	h[7] = add(api, h[7], hh)
+}
+
+// -------------------------------------------------------------------------------------------------
+// bit operations
+
+// byteBits decomposes b in 8 bits, which constrains b to be a byte.
+func byteBits(api frontend.API, b frontend.Variable) [8]frontend.Variable {
+	var res [8]frontend.Variable
+	copy(res[:], api.ToBinary(b, 8))
+	return res
+}
+
+func constByte(b uint8) [8]frontend.Variable {
+	var res [8]frontend.Variable
+	for i := range res {
+		res[i] = (b >> i) & 1
+	}
+	return res
+}
+
+func constWord(v uint32) word {
+	var res word
+	for i := range res {
+		res[i] = (v >> i) & 1
+	}
+	return res
+}
+
+// fromBits packs bits which are known to be booleans.
+func fromBits(api frontend.API, b []frontend.Variable) frontend.Variable {
+	return bits.FromBinary(api, b, bits.WithUnconstrainedInputs())
+}
+
+// add returns the sum of the words modulo 2³²
+func add(api frontend.API, words ...word) word {
+	terms := make([]frontend.Variable, len(words))
+	for i := range words {
+		terms[i] = fromBits(api, words[i][:])
+	}
+	var sum frontend.Variable
+	if len(terms) == 1 {
+		sum = terms[0]
+	} else {
+		sum = api.Add(terms[0], terms[1], terms[2:]...)
+	}
+
+	// the sum fits on 32 + ⌈log₂(len(words))⌉ bits, we keep the lowest 32
+	nbBits := 32 + mbits.Len(uint(len(words)-1))
+	var res word
+	copy(res[:], api.ToBinary(sum, nbBits))
+	return res
+}
+
+func rotr(w word, n int) word {
+	var res word
+	for i := range res {
+		res[i] = w[(i+n)%32]
+	}
+	return res
+}
+
+func shr(w word, n int) word {
+	var res word
+	for i := range res {
+		if i+n < 32 {
+			res[i] = w[i+n]
+		} else {
+			res[i] = 0
+		}
+	}
+	return res
+}
+
+func xor3(api frontend.API, a, b, c word) word {
+	var res word
+	for i := range res {
+		res[i] = xor(api, xor(api, a[i], b[i]), c[i])
+	}
+	return res
+}
+
+// ch returns (e ∧ f) ⊕ (¬e ∧ g), that is f if e is set, g otherwise
+func ch(api frontend.API, e, f, g word) word {
+	var res word
+	for i := range res {
+		res[i] = api.Select(e[i], f[i], g[i])
+	}
+	return res
+}
+
+// maj returns (a ∧ b) ⊕ (a ∧ c) ⊕ (b ∧ c), that is c if a ≠ b, a otherwise
+func maj(api frontend.API, a, b, c word) word {
+	var res word
+	for i := range res {
+		res[i] = api.Select(xor(api, a[i], b[i]), c[i], a[i])
+	}
+	return res
+}
+
+// xor returns a ⊕ b, without recording a constraint if one of the bits is constant.
+func xor(api frontend.API, a, b frontend.Variable) frontend.Variable {
+	if _, ok := api.Compiler().ConstantValue(a); ok {
+		a, b = b, a
+	}
+	if v, ok := api.Compiler().ConstantValue(b); ok {
+		if v.Sign() == 0 {
+			return a
+		}
+		return api.Sub(1, a)
+	}
+	return api.Xor(a, b)
+}
@@ -0,0 +1,52 @@
+package sha2
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+type sha256Circuit struct {
+	Data   []frontend.Variable
+	Digest [Size]frontend.Variable `gnark:",public"`
+}
+
+func (circuit *sha256Circuit) Define(api frontend.API) error {
+	digest := Sum256(api, circuit.Data)
+	for i := range digest {
+		api.AssertIsEqual(digest[i], circuit.Digest[i])
+	}
+	return nil
+}
+
+func TestSum256(t *testing.T) {
+	// lengths around the padding boundaries
+	for _, n := range []int{0, 3, 55, 56, 64, 119} {
+		// the compiled circuits are cached by the assert object, which can't tell apart
+		// circuits of different sizes
+		assert := test.NewAssert(t)
+
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i*31 + 7)
+		}
+		expected := sha256.Sum256(data)
+
+		circuit := sha256Circuit{Data: make([]frontend.Variable, n)}
+		witness := sha256Circuit{Data: make([]frontend.Variable, n)}
+		for i := range data {
+			witness.Data[i] = data[i]
+		}
+		for i := range expected {
+			witness.Digest[i] = expected[i]
+		}
+		assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+		wrongWitness := witness
+		wrongWitness.Digest[0] = expected[0] ^ 1
+		assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(ecc.BN254))
+	}
+}
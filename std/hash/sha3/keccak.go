@@ -0,0 +1,178 @@
+// Package sha3 provides ZKP-circuit functions to compute Keccak-256 and SHA3-256 digests.
+//
+// The gadgets work on bytes: inputs are variables in [0, 256), which is enforced by the
+// circuit, and the digests are returned as 32 byte variables. Lanes are handled as slices
+// of bits, so that rotations and permutations of the state are free; per bit of the state,
+// θ costs two XORs on average and χ one XOR and one multiplication.
+package sha3
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// Size is the size of a Keccak-256 or SHA3-256 digest in bytes.
+const Size = 32
+
+// rate of the sponge for a 256 bits digest, in bytes
+const rate = 136
+
+var _RC = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808A, 0x8000000080008000,
+	0x000000000000808B, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008A, 0x0000000000000088, 0x0000000080008009, 0x000000008000000A,
+	0x000000008000808B, 0x800000000000008B, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800A, 0x800000008000000A,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// rotation offsets of ρ, _R[x][y] is the offset of the lane (x, y)
+var _R = [5][5]int{
+	{0, 36, 3, 41, 18},
+	{1, 44, 10, 45, 2},
+	{62, 6, 43, 15, 61},
+	{28, 55, 25, 21, 56},
+	{27, 20, 39, 8, 14},
+}
+
+// lane is a 64-bit lane of the state, as a slice of bits (lsb first).
+type lane [64]frontend.Variable
+
+// Keccak256 returns the legacy Keccak-256 digest of data, as used by Ethereum.
+// Each element of data is a byte.
+func Keccak256(api frontend.API, data []frontend.Variable) [Size]frontend.Variable {
+	return sum(api, data, 0x01)
+}
+
+// Sum256 returns the SHA3-256 digest of data (FIPS 202). Each element of data is a byte.
+func Sum256(api frontend.API, data []frontend.Variable) [Size]frontend.Variable {
+	return sum(api, data, 0x06)
+}
+
+func sum(api frontend.API, data []frontend.Variable, dsByte uint8) [Size]frontend.Variable {
+	// decompose the input and apply the multi-rate padding
+	msg := make([][8]frontend.Variable, 0, len(data)+rate)
+	for i := range data {
+		var b [8]frontend.Variable
+		copy(b[:], api.ToBinary(data[i], 8))
+		msg = append(msg, b)
+	}
+	padding := make([]uint8, rate-len(data)%rate)
+	padding[0] = dsByte
+	padding[len(padding)-1] |= 0x80
+	for _, p := range padding {
+		msg = append(msg, constByte(p))
+	}
+
+	var state [25]lane
+	for i := range state {
+		state[i] = constLane(0)
+	}
+	for start := 0; start < len(msg); start += rate {
+		// lanes are little endian
+		for i := 0; i < rate/8; i++ {
+			for j := 0; j < 8; j++ {
+				for k := 0; k < 8; k++ {
+					state[i][8*j+k] = xor(api, state[i][8*j+k], msg[start+8*i+j][k])
+				}
+			}
+		}
+		keccakF(api, &state)
+	}
+
+	var res [Size]frontend.Variable
+	for i := range res {
+		res[i] = bits.FromBinary(api, state[i/8][8*(i%8):8*(i%8)+8], bits.WithUnconstrainedInputs())
+	}
+	return res
+}
+
+// keccakF applies the Keccak-f[1600] permutation to the state, where lane (x, y) is a[x+5y].
+func keccakF(api frontend.API, a *[25]lane) {
+	for round := 0; round < 24; round++ {
+		// θ
+		var c, d [5]lane
+		for x := 0; x < 5; x++ {
+			for i := 0; i < 64; i++ {
+				c[x][i] = xor(api, xor(api, a[x][i], a[x+5][i]), xor(api, xor(api, a[x+10][i], a[x+15][i]), a[x+20][i]))
+			}
+		}
+		for x := 0; x < 5; x++ {
+			r := rotl(c[(x+1)%5], 1)
+			for i := 0; i < 64; i++ {
+				d[x][i] = xor(api, c[(x+4)%5][i], r[i])
+			}
+		}
+		for j := range a {
+			for i := 0; i < 64; i++ {
+				a[j][i] = xor(api, a[j][i], d[j%5][i])
+			}
+		}
+
+		// ρ and π
+		var b [25]lane
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				b[y+5*((2*x+3*y)%5)] = rotl(a[x+5*y], _R[x][y])
+			}
+		}
+
+		// χ
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				for i := 0; i < 64; i++ {
+					// ¬b₁ ∧ b₂ = (1 - b₁) b₂
+					nand := api.Mul(api.Sub(1, b[(x+1)%5+5*y][i]), b[(x+2)%5+5*y][i])
+					a[x+5*y][i] = xor(api, b[x+5*y][i], nand)
+				}
+			}
+		}
+
+		// ι
+		rc := constLane(_RC[round])
+		for i := 0; i < 64; i++ {
+			a[0][i] = xor(api, a[0][i], rc[i])
+		}
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// bit operations
+
+func constByte(b uint8) [8]frontend.Variable {
+	var res [8]frontend.Variable
+	for i := range res {
+		res[i] = (b >> i) & 1
+	}
+	return res
+}
+
+func constLane(v uint64) lane {
+	var res lane
+	for i := range res {
+		res[i] = (v >> i) & 1
+	}
+	return res
+}
+
+func rotl(l lane, n int) lane {
+	var res lane
+	for i := range res {
+		res[(i+n)%64] = l[i]
+	}
+	return res
+}
+
+// xor returns a ⊕ b, without recording a constraint if one of the bits is constant.
+func xor(api frontend.API, a, b frontend.Variable) frontend.Variable {
+	if _, ok := api.Compiler().ConstantValue(a); ok {
+		a, b = b, a
+	}
+	if v, ok := api.Compiler().ConstantValue(b); ok {
+		if v.Sign() == 0 {
+			return a
+		}
+		return api.Sub(1, a)
+	}
+	return api.Xor(a, b)
+}
@@ -0,0 +1,66 @@
+package sha3
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"golang.org/x/crypto/sha3"
+)
+
+type keccakCircuit struct {
+	Data   []frontend.Variable
+	Digest [Size]frontend.Variable `gnark:",public"`
+	fips   bool
+}
+
+func (circuit *keccakCircuit) Define(api frontend.API) error {
+	var digest [Size]frontend.Variable
+	if circuit.fips {
+		digest = Sum256(api, circuit.Data)
+	} else {
+		digest = Keccak256(api, circuit.Data)
+	}
+	for i := range digest {
+		api.AssertIsEqual(digest[i], circuit.Digest[i])
+	}
+	return nil
+}
+
+func TestKeccak256(t *testing.T) {
+	// lengths around the padding boundaries
+	for _, n := range []int{0, 3, 135, 136, 200} {
+		// the compiled circuits are cached by the assert object, which can't tell apart
+		// circuits of different sizes
+		assert := test.NewAssert(t)
+
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i*31 + 7)
+		}
+
+		for _, fips := range []bool{false, true} {
+			h := sha3.NewLegacyKeccak256()
+			if fips {
+				h = sha3.New256()
+			}
+			h.Write(data)
+			expected := h.Sum(nil)
+
+			circuit := keccakCircuit{Data: make([]frontend.Variable, n), fips: fips}
+			witness := keccakCircuit{Data: make([]frontend.Variable, n)}
+			for i := range data {
+				witness.Data[i] = data[i]
+			}
+			for i := range expected {
+				witness.Digest[i] = expected[i]
+			}
+			assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+			wrongWitness := witness
+			wrongWitness.Digest[Size-1] = expected[Size-1] ^ 0x80
+			assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(ecc.BN254))
+		}
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/std/algebra/sw_bls12377"
 	"github.com/consensys/gnark/std/algebra/sw_bls24315"
+	"github.com/consensys/gnark/std/math/bigint"
 	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/emulated"
 )
 
 var registerOnce sync.Once
@@ -20,11 +22,19 @@ func RegisterHints() {
 }
 
 func registerHints() {
-	// note that importing these packages may already triggers a call to hint.Register(...)
+	// note that importing these packages already registers their hints, with
+	// hint.RegisterVersioned under the name of the Go function: their IDs in the compiled
+	// circuits don't depend on the order of the registrations below
 	hint.Register(sw_bls24315.DecomposeScalar)
 	hint.Register(sw_bls12377.DecomposeScalar)
 	hint.Register(bits.NTrits)
 	hint.Register(bits.NNAF)
 	hint.Register(bits.IthBit)
 	hint.Register(bits.NBits)
+	hint.Register(emulated.QuoRem)
+	hint.Register(emulated.Carries)
+	hint.Register(emulated.InverseHint)
+	hint.Register(emulated.DivHint)
+	hint.Register(bigint.LimbsHint)
+	hint.Register(bigint.CmpHint)
 }
@@ -1,10 +1,31 @@
 package std
 
 import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/frontend"
 )
 
+// TestRegisterHints checks that the hints of gnark/std have a stable identifier, the name of
+// their Go function in version 1.
+func TestRegisterHints(t *testing.T) {
+	RegisterHints()
+	for _, fn := range hint.GetRegistered() {
+		goName := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+		if !strings.HasPrefix(goName, "github.com/consensys/gnark/std/") {
+			continue
+		}
+		if name := hint.Name(fn); name != hint.Identifier(goName, 1) {
+			t.Errorf("hint %s is registered as %s", goName, name)
+		}
+	}
+}
+
 func ExampleRegisterHints() {
 	// this constraint system correspond to a circuit using gnark/std components which rely on hints
 	// like bits.ToNAF(...)
@@ -0,0 +1,409 @@
+// Package bigint provides arbitrary-precision arithmetic on non-negative integers in a circuit,
+// for values much wider than the native field (RSA moduli, foreign chain headers...).
+//
+// An integer is split in limbs of LimbBits bits, each limb being a native variable; unlike
+// in std/math/emulated, there is no modulus and the number of limbs of a result grows with
+// the operation (one more limb for a sum, the sum of the numbers of limbs for a product).
+// Results are computed by hints and constrained with their limbs range checked: an identity
+// over the integers is seen as a polynomial in 2^LimbBits whose coefficients don't wrap
+// around the native modulus, and the polynomial is shown to vanish at 2^LimbBits by
+// propagating the carries between its coefficients.
+package bigint
+
+import (
+	"math/big"
+	mbits "math/bits"
+
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/emulated"
+)
+
+// LimbBits is the width of the limbs.
+const LimbBits = 64
+
+// Int is a non-negative integer, given by its limbs (least significant first), see NewInt.
+type Int struct {
+	Limbs []frontend.Variable
+}
+
+// NewInt returns an integer of nbLimbs limbs.
+//
+// If v is nil, the limbs are left unassigned, which is how an integer is declared in a
+// circuit. Otherwise v must be non-negative and fit in the limbs.
+func NewInt(v *big.Int, nbLimbs int) Int {
+	res := Int{Limbs: make([]frontend.Variable, nbLimbs)}
+	if v == nil {
+		return res
+	}
+	if v.Sign() < 0 || v.BitLen() > nbLimbs*LimbBits {
+		panic("bigint: value doesn't fit in the limbs")
+	}
+	for i, l := range split(v, nbLimbs, LimbBits) {
+		res.Limbs[i] = l
+	}
+	return res
+}
+
+// API performs the arithmetic on integers in a circuit.
+type API struct {
+	api frontend.API
+
+	// integers whose limbs are known to fit in LimbBits, by the address of their limbs. The
+	// flag can't be stored in the integers, as the ones of the circuit are reused when it is
+	// compiled again.
+	checked map[*frontend.Variable]struct{}
+}
+
+// New returns an API for integer arithmetic.
+func New(api frontend.API) *API {
+	return &API{
+		api:     api,
+		checked: make(map[*frontend.Variable]struct{}),
+	}
+}
+
+// Constant returns the integer v, on the smallest number of limbs.
+func (b *API) Constant(v *big.Int) *Int {
+	n := (v.BitLen() + LimbBits - 1) / LimbBits
+	if n == 0 {
+		n = 1
+	}
+	res := NewInt(v, n)
+	return b.newChecked(res.Limbs)
+}
+
+// Add returns a + b.
+func (b *API) Add(x, y *Int) *Int {
+	b.enforceWidth(x, y)
+	e := b.addPoly(x.Limbs, y.Limbs)
+	return b.normalize(e, max(len(x.Limbs), len(y.Limbs))+1, LimbBits+1)
+}
+
+// Sub returns x - y, and the solver fails if x < y.
+func (b *API) Sub(x, y *Int) *Int {
+	b.enforceWidth(x, y)
+	e := b.subPoly(x.Limbs, y.Limbs)
+	return b.normalize(e, len(x.Limbs), LimbBits+1)
+}
+
+// Mul returns x·y.
+func (b *API) Mul(x, y *Int) *Int {
+	b.enforceWidth(x, y)
+	e := b.mulPoly(x.Limbs, y.Limbs)
+	return b.normalize(e, len(x.Limbs)+len(y.Limbs), productBits(len(x.Limbs), len(y.Limbs)))
+}
+
+// DivMod returns the quotient and the remainder of the division of x by m, and the solver
+// fails if m is zero.
+func (b *API) DivMod(x, m *Int) (quo, rem *Int) {
+	b.enforceWidth(x, m)
+	return b.quoRem(x.Limbs, m, len(x.Limbs), LimbBits)
+}
+
+// Mod returns x mod m.
+func (b *API) Mod(x, m *Int) *Int {
+	_, rem := b.DivMod(x, m)
+	return rem
+}
+
+// ModMul returns x·y mod m.
+func (b *API) ModMul(x, y, m *Int) *Int {
+	b.enforceWidth(x, y, m)
+	e := b.mulPoly(x.Limbs, y.Limbs)
+	_, rem := b.quoRem(e, m, len(x.Limbs)+len(y.Limbs), productBits(len(x.Limbs), len(y.Limbs)))
+	return rem
+}
+
+// ModExp returns x^e mod m, the bits of the exponent being processed from the most
+// significant one.
+func (b *API) ModExp(x, e, m *Int) *Int {
+	b.enforceWidth(x, e, m)
+	x = b.Mod(x, m)
+	res := b.newChecked(pad([]frontend.Variable{1}, len(m.Limbs)))
+	eBits := b.toBits(e)
+	for i := len(eBits) - 1; i >= 0; i-- {
+		res = b.ModMul(res, res, m)
+		res = b.Select(eBits[i], b.ModMul(res, x, m), res)
+	}
+	return res
+}
+
+// Cmp returns 1 if x > y, 0 if x = y and -1 if x < y.
+func (b *API) Cmp(x, y *Int) frontend.Variable {
+	b.enforceWidth(x, y)
+	n := max(len(x.Limbs), len(y.Limbs))
+	inputs := []frontend.Variable{LimbBits, len(x.Limbs)}
+	inputs = append(inputs, x.Limbs...)
+	inputs = append(inputs, y.Limbs...)
+	outputs, err := b.api.Compiler().NewHint(CmpHint, 1+n, inputs...)
+	if err != nil {
+		panic(err)
+	}
+
+	// c ∈ {-1, 0, 1} and x - y = c·(1 + d) with d ≥ 0 gives the sign of x - y
+	c, d := outputs[0], outputs[1:]
+	b.api.AssertIsEqual(b.api.Mul(c, b.api.Sub(b.api.Mul(c, c), 1)), 0)
+	b.rangeCheck(d)
+	cd := make([]frontend.Variable, len(d))
+	for i := range d {
+		cd[i] = b.api.Mul(c, d[i])
+	}
+	cd[0] = b.api.Add(cd[0], c)
+	b.checkZero(b.subPoly(b.subPoly(x.Limbs, y.Limbs), cd), LimbBits+2)
+	return c
+}
+
+// IsLess returns 1 if x < y, 0 otherwise.
+func (b *API) IsLess(x, y *Int) frontend.Variable {
+	return b.api.IsZero(b.api.Add(b.Cmp(x, y), 1))
+}
+
+// AssertIsLessOrEqual asserts that x ≤ y.
+func (b *API) AssertIsLessOrEqual(x, y *Int) {
+	// y - x = d with d ≥ 0
+	b.Sub(y, x)
+}
+
+// AssertIsLess asserts that x < y.
+func (b *API) AssertIsLess(x, y *Int) {
+	b.enforceWidth(x, y)
+	// y - x - 1 = d with d ≥ 0
+	e := b.subPoly(b.subPoly(y.Limbs, x.Limbs), []frontend.Variable{1})
+	b.normalize(e, len(y.Limbs), LimbBits+2)
+}
+
+// AssertIsEqual asserts that x = y.
+func (b *API) AssertIsEqual(x, y *Int) {
+	b.enforceWidth(x, y)
+	b.checkZero(b.subPoly(x.Limbs, y.Limbs), LimbBits+1)
+}
+
+// Select returns x if sel is 1 and y if sel is 0. sel must be boolean.
+func (b *API) Select(sel frontend.Variable, x, y *Int) *Int {
+	b.enforceWidth(x, y)
+	n := max(len(x.Limbs), len(y.Limbs))
+	xl, yl := pad(x.Limbs, n), pad(y.Limbs, n)
+	res := make([]frontend.Variable, n)
+	for i := range res {
+		res[i] = b.api.Select(sel, xl[i], yl[i])
+	}
+	return b.newChecked(res)
+}
+
+// ToBits returns the bits of x, least significant first.
+func (b *API) ToBits(x *Int) []frontend.Variable {
+	b.enforceWidth(x)
+	return b.toBits(x)
+}
+
+// FromBits returns the integer Σ b[i]·2^i. The b[i] are constrained to be booleans.
+func (b *API) FromBits(v []frontend.Variable) *Int {
+	n := (len(v) + LimbBits - 1) / LimbBits
+	if n == 0 {
+		return b.Constant(new(big.Int))
+	}
+	res := make([]frontend.Variable, n)
+	for i := range res {
+		res[i] = bits.FromBinary(b.api, v[i*LimbBits:min((i+1)*LimbBits, len(v))])
+	}
+	return b.newChecked(res)
+}
+
+// -------------------------------------------------------------------------------------------------
+// reduction
+
+// normalize returns the integer E(2^k) on n limbs, where the coefficients of E are e. The
+// coefficients may be negative but are less than 2^coeffBits in absolute value, and the
+// solver fails if E(2^k) is negative or doesn't fit in n limbs.
+func (b *API) normalize(e []frontend.Variable, n int, coeffBits uint) *Int {
+	inputs := append([]frontend.Variable{LimbBits}, e...)
+	res := b.hint(LimbsHint, n, inputs...)
+
+	// E - res must vanish at 2^k
+	b.checkZero(b.subPoly(e, res.Limbs), coeffBits+1)
+	return res
+}
+
+// quoRem returns the quotient on nbQuoLimbs limbs and the remainder of the division of E(2^k)
+// by m, where the coefficients of E are e and are less than 2^coeffBits.
+func (b *API) quoRem(e []frontend.Variable, m *Int, nbQuoLimbs int, coeffBits uint) (quo, rem *Int) {
+	n := len(m.Limbs)
+	inputs := []frontend.Variable{LimbBits, n, nbQuoLimbs}
+	inputs = append(inputs, m.Limbs...)
+	inputs = append(inputs, e...)
+	outputs, err := b.api.Compiler().NewHint(emulated.QuoRem, nbQuoLimbs+n, inputs...)
+	if err != nil {
+		panic(err)
+	}
+	b.rangeCheck(outputs)
+	quo, rem = b.newChecked(outputs[:nbQuoLimbs]), b.newChecked(outputs[nbQuoLimbs:])
+
+	// E - q·m - r must vanish at 2^k, with r < m
+	d := b.subPoly(b.subPoly(e, b.mulPoly(quo.Limbs, m.Limbs)), rem.Limbs)
+	b.checkZero(d, max(coeffBits, productBits(nbQuoLimbs, n))+2)
+	b.AssertIsLess(rem, m)
+	return quo, rem
+}
+
+// checkZero asserts that D(2^k) = 0 over the integers, where the coefficients of D are d and
+// are less than 2^coeffBits in absolute value.
+//
+// With dᵢ + cᵢ₋₁ = cᵢ·2^k for the carries cᵢ given by a hint, we get |cᵢ| < 2^(coeffBits-k+1);
+// the carries are range checked accordingly, so that no relation wraps around the native modulus.
+func (b *API) checkZero(d []frontend.Variable, coeffBits uint) {
+	if int(coeffBits)+4 >= b.api.Compiler().Curve().ScalarField().BitLen() {
+		panic("bigint: coefficients overflow the native field")
+	}
+	if len(d) == 1 {
+		b.api.AssertIsEqual(d[0], 0)
+		return
+	}
+
+	inputs := append([]frontend.Variable{LimbBits}, d...)
+	carries, err := b.api.Compiler().NewHint(emulated.Carries, len(d)-1, inputs...)
+	if err != nil {
+		panic(err)
+	}
+
+	carryBits := coeffBits - LimbBits + 1
+	shift := new(big.Int).Lsh(big.NewInt(1), carryBits)
+	for i := range carries {
+		// cᵢ + 2^carryBits is in [0, 2^(carryBits+1))
+		bits.ToBinary(b.api, b.api.Add(carries[i], shift), bits.WithNbDigits(int(carryBits)+1))
+	}
+
+	base := new(big.Int).Lsh(big.NewInt(1), LimbBits)
+	prev := frontend.Variable(0)
+	for i := 0; i < len(d)-1; i++ {
+		b.api.AssertIsEqual(b.api.Add(d[i], prev), b.api.Mul(carries[i], base))
+		prev = carries[i]
+	}
+	b.api.AssertIsEqual(b.api.Add(d[len(d)-1], prev), 0)
+}
+
+// hint returns the integer of n limbs computed by fn, with its limbs range checked.
+func (b *API) hint(fn hint.Function, n int, inputs ...frontend.Variable) *Int {
+	res, err := b.api.Compiler().NewHint(fn, n, inputs...)
+	if err != nil {
+		panic(err)
+	}
+	b.rangeCheck(res)
+	return b.newChecked(res)
+}
+
+// toBits decomposes the limbs of x, which also range checks them.
+func (b *API) toBits(x *Int) []frontend.Variable {
+	res := make([]frontend.Variable, 0, len(x.Limbs)*LimbBits)
+	for i := range x.Limbs {
+		res = append(res, bits.ToBinary(b.api, x.Limbs[i], bits.WithNbDigits(LimbBits))...)
+	}
+	return res
+}
+
+// enforceWidth range checks the limbs of the integers which were not yet.
+func (b *API) enforceWidth(integers ...*Int) {
+	for _, x := range integers {
+		if len(x.Limbs) == 0 {
+			panic("bigint: integer without limbs")
+		}
+		if _, ok := b.checked[&x.Limbs[0]]; !ok {
+			b.rangeCheck(x.Limbs)
+			b.checked[&x.Limbs[0]] = struct{}{}
+		}
+	}
+}
+
+// newChecked returns an integer with the given limbs, which fit in LimbBits.
+func (b *API) newChecked(limbs []frontend.Variable) *Int {
+	b.checked[&limbs[0]] = struct{}{}
+	return &Int{Limbs: limbs}
+}
+
+// rangeCheck asserts that the limbs fit in LimbBits.
+func (b *API) rangeCheck(limbs []frontend.Variable) {
+	for i := range limbs {
+		bits.ToBinary(b.api, limbs[i], bits.WithNbDigits(LimbBits))
+	}
+}
+
+// productBits bounds the coefficients of the product of integers of n and m limbs.
+func productBits(n, m int) uint {
+	return 2*LimbBits + uint(mbits.Len(uint(min(n, m))))
+}
+
+// pad returns the limbs completed with zeros up to n limbs.
+func pad(limbs []frontend.Variable, n int) []frontend.Variable {
+	res := make([]frontend.Variable, n)
+	copy(res, limbs)
+	for i := len(limbs); i < n; i++ {
+		res[i] = 0
+	}
+	return res
+}
+
+// -------------------------------------------------------------------------------------------------
+// polynomials, given by their coefficients (constant first)
+
+func (b *API) addPoly(x, y []frontend.Variable) []frontend.Variable {
+	return b.combine(x, y, false)
+}
+
+func (b *API) subPoly(x, y []frontend.Variable) []frontend.Variable {
+	return b.combine(x, y, true)
+}
+
+func (b *API) combine(x, y []frontend.Variable, sub bool) []frontend.Variable {
+	res := make([]frontend.Variable, max(len(x), len(y)))
+	for i := range res {
+		switch {
+		case i >= len(y):
+			res[i] = x[i]
+		case i >= len(x) && sub:
+			res[i] = b.api.Neg(y[i])
+		case i >= len(x):
+			res[i] = y[i]
+		case sub:
+			res[i] = b.api.Sub(x[i], y[i])
+		default:
+			res[i] = b.api.Add(x[i], y[i])
+		}
+	}
+	return res
+}
+
+func (b *API) mulPoly(x, y []frontend.Variable) []frontend.Variable {
+	if len(x) == 0 || len(y) == 0 {
+		return nil
+	}
+	terms := make([][]frontend.Variable, len(x)+len(y)-1)
+	for i := range x {
+		for j := range y {
+			terms[i+j] = append(terms[i+j], b.api.Mul(x[i], y[j]))
+		}
+	}
+	res := make([]frontend.Variable, len(terms))
+	for i := range terms {
+		if len(terms[i]) == 1 {
+			res[i] = terms[i][0]
+		} else {
+			res[i] = b.api.Add(terms[i][0], terms[i][1], terms[i][2:]...)
+		}
+	}
+	return res
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max[I int | uint](a, b I) I {
+	if a > b {
+		return a
+	}
+	return b
+}
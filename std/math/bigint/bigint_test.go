@@ -0,0 +1,106 @@
+package bigint
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+type arithmeticCircuit struct {
+	A, B, M        Int
+	Sum, Diff      Int `gnark:",public"`
+	Prod, Quo, Rem Int `gnark:",public"`
+	ModProd        Int `gnark:",public"`
+	Cmp            frontend.Variable
+}
+
+func (c *arithmeticCircuit) Define(api frontend.API) error {
+	b := New(api)
+	b.AssertIsEqual(b.Add(&c.A, &c.B), &c.Sum)
+	b.AssertIsEqual(b.Sub(&c.A, &c.B), &c.Diff)
+	b.AssertIsEqual(b.Mul(&c.A, &c.B), &c.Prod)
+	quo, rem := b.DivMod(&c.A, &c.M)
+	b.AssertIsEqual(quo, &c.Quo)
+	b.AssertIsEqual(rem, &c.Rem)
+	b.AssertIsEqual(b.ModMul(&c.A, &c.B, &c.M), &c.ModProd)
+
+	api.AssertIsEqual(b.Cmp(&c.A, &c.B), c.Cmp)
+	api.AssertIsEqual(b.Cmp(&c.B, &c.A), api.Neg(c.Cmp))
+	api.AssertIsEqual(b.Cmp(&c.A, &c.A), 0)
+	api.AssertIsEqual(b.IsLess(&c.B, &c.A), 1)
+	b.AssertIsLess(&c.Rem, &c.M)
+	b.AssertIsLessOrEqual(&c.B, &c.A)
+	return nil
+}
+
+func TestArithmetic(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	bound := new(big.Int).Lsh(big.NewInt(1), 4*LimbBits)
+	a, _ := rand.Int(rand.Reader, bound)
+	b, _ := rand.Int(rand.Reader, a)
+	m, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 2*LimbBits))
+	m.SetBit(m, 2*LimbBits-1, 1)
+
+	var sum, diff, prod, quo, rem, modProd big.Int
+	sum.Add(a, b)
+	diff.Sub(a, b)
+	prod.Mul(a, b)
+	quo.DivMod(a, m, &rem)
+	modProd.Mod(&prod, m)
+
+	circuit := arithmeticCircuit{
+		A: NewInt(nil, 4), B: NewInt(nil, 4), M: NewInt(nil, 2),
+		Sum: NewInt(nil, 5), Diff: NewInt(nil, 4), Prod: NewInt(nil, 8),
+		Quo: NewInt(nil, 4), Rem: NewInt(nil, 2), ModProd: NewInt(nil, 2),
+	}
+	witness := arithmeticCircuit{
+		A: NewInt(a, 4), B: NewInt(b, 4), M: NewInt(m, 2),
+		Sum: NewInt(&sum, 5), Diff: NewInt(&diff, 4), Prod: NewInt(&prod, 8),
+		Quo: NewInt(&quo, 4), Rem: NewInt(&rem, 2), ModProd: NewInt(&modProd, 2),
+		Cmp: 1,
+	}
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	wrongWitness := witness
+	wrongWitness.Prod = NewInt(new(big.Int).Add(&prod, big.NewInt(1)), 8)
+	assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(ecc.BN254))
+
+	// a ≥ b is required by Sub
+	wrongWitness = witness
+	wrongWitness.A, wrongWitness.B = witness.B, witness.A
+	assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(ecc.BN254))
+}
+
+type modExpCircuit struct {
+	X, M Int
+	E    Int
+	Res  Int `gnark:",public"`
+}
+
+func (c *modExpCircuit) Define(api frontend.API) error {
+	b := New(api)
+	b.AssertIsEqual(b.ModExp(&c.X, &c.E, &c.M), &c.Res)
+	return nil
+}
+
+func TestModExp(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	m, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 2*LimbBits))
+	m.SetBit(m, 0, 1)
+	x, _ := rand.Int(rand.Reader, m)
+	e := big.NewInt(65537)
+	res := new(big.Int).Exp(x, e, m)
+
+	circuit := modExpCircuit{X: NewInt(nil, 2), M: NewInt(nil, 2), E: NewInt(nil, 1), Res: NewInt(nil, 2)}
+	witness := modExpCircuit{X: NewInt(x, 2), M: NewInt(m, 2), E: NewInt(e, 1), Res: NewInt(res, 2)}
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	witness.Res = NewInt(new(big.Int).Exp(x, big.NewInt(65539), m), 2)
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BN254))
+}
@@ -0,0 +1,104 @@
+package bigint
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/hint"
+)
+
+func init() {
+	hint.RegisterVersioned(LimbsHint, "github.com/consensys/gnark/std/math/bigint.LimbsHint", 1)
+	hint.RegisterVersioned(CmpHint, "github.com/consensys/gnark/std/math/bigint.CmpHint", 1)
+}
+
+// LimbsHint computes the limbs of a polynomial evaluated at 2^k.
+//
+// The inputs are k followed by the (signed) coefficients of the polynomial; the outputs are
+// the limbs of its value, which must be non-negative and fit in the outputs.
+func LimbsHint(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) < 1 {
+		return errors.New("bigint: missing inputs")
+	}
+	k := uint(inputs[0].Uint64())
+	v := recompose(toSigned(curveID, inputs[1:]), k)
+	if v.Sign() < 0 {
+		return errors.New("bigint: negative value")
+	}
+	if v.BitLen() > int(k)*len(outputs) {
+		return errors.New("bigint: value too large for its limbs")
+	}
+	for i, l := range split(v, len(outputs), k) {
+		outputs[i].Set(l)
+	}
+	return nil
+}
+
+// CmpHint compares two integers a and b given by their limbs.
+//
+// The inputs are k, the number of limbs of a, the limbs of a and the limbs of b. The first
+// output is the sign of a - b (modulo the native modulus), the others are the limbs of
+// |a - b| - 1, or 0 if a = b.
+func CmpHint(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) < 2 || len(outputs) < 1 {
+		return errors.New("bigint: missing inputs or outputs")
+	}
+	k, n := uint(inputs[0].Uint64()), int(inputs[1].Uint64())
+	if len(inputs) < 2+n {
+		return errors.New("bigint: invalid number of inputs")
+	}
+	a := recompose(inputs[2:2+n], k)
+	b := recompose(inputs[2+n:], k)
+
+	var d big.Int
+	d.Sub(a, b)
+	sign := d.Sign()
+	outputs[0].Mod(big.NewInt(int64(sign)), curveID.ScalarField())
+	if sign != 0 {
+		d.Abs(&d).Sub(&d, big.NewInt(1))
+	}
+	if d.BitLen() > int(k)*(len(outputs)-1) {
+		return errors.New("bigint: difference too large for its limbs")
+	}
+	for i, l := range split(&d, len(outputs)-1, k) {
+		outputs[1+i].Set(l)
+	}
+	return nil
+}
+
+// split returns the n limbs of k bits of v, least significant first.
+func split(v *big.Int, n int, k uint) []*big.Int {
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), k), big.NewInt(1))
+	res := make([]*big.Int, n)
+	var tmp big.Int
+	tmp.Set(v)
+	for i := range res {
+		res[i] = new(big.Int).And(&tmp, mask)
+		tmp.Rsh(&tmp, k)
+	}
+	return res
+}
+
+// recompose returns Σ limbs[i]·2^(i·k).
+func recompose(limbs []*big.Int, k uint) *big.Int {
+	res := new(big.Int)
+	for i := len(limbs) - 1; i >= 0; i-- {
+		res.Lsh(res, k).Add(res, limbs[i])
+	}
+	return res
+}
+
+// toSigned maps the native field elements in inputs to (-r/2, r/2].
+func toSigned(curveID ecc.ID, inputs []*big.Int) []*big.Int {
+	r := curveID.ScalarField()
+	half := new(big.Int).Rsh(r, 1)
+	res := make([]*big.Int, len(inputs))
+	for i := range inputs {
+		res[i] = new(big.Int).Set(inputs[i])
+		if res[i].Cmp(half) > 0 {
+			res[i].Sub(res[i], r)
+		}
+	}
+	return res
+}
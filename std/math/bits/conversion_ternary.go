@@ -14,7 +14,7 @@ import (
 var NTrits = nTrits
 
 func init() {
-	hint.Register(NTrits)
+	hint.RegisterVersioned(NTrits, "github.com/consensys/gnark/std/math/bits.NTrits", 1)
 }
 
 // ToTernary is an alias of ToBase(api, Ternary, v, opts...)
@@ -14,7 +14,7 @@ import (
 var NNAF = nNaf
 
 func init() {
-	hint.Register(NNAF)
+	hint.RegisterVersioned(NNAF, "github.com/consensys/gnark/std/math/bits.NNAF", 1)
 }
 
 // ToNAF returns the NAF decomposition of given input.
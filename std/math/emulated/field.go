@@ -0,0 +1,533 @@
+// Package emulated provides arithmetic modulo a prime which is not the native field of the circuit.
+//
+// An element is split in limbs of a few bits, each limb being a native variable. Results of
+// the operations are computed by hints and constrained with their limbs range checked: to
+// check an identity E = q·p + r over the integers, E - q·p - r is seen as a polynomial in 2^k
+// (k being the limb width) whose coefficients are small enough not to wrap around the native
+// modulus, and the polynomial is shown to vanish at 2^k by propagating the carries between
+// its coefficients.
+//
+// The reduction is lazy: additions and subtractions only add the limbs, which then exceed
+// the limb width by a few bits (the overflow of the element). Multiplications accept such
+// inputs and return reduced elements; an input is only reduced first when the coefficients of
+// the product would get too large for the native field.
+//
+// Elements are not necessarily smaller than the modulus; they are equal when they are
+// congruent. Reduce and AssertIsInRange give the canonical representative when it matters.
+package emulated
+
+import (
+	"errors"
+	"math/big"
+	mbits "math/bits"
+
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/utils"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// Element is an element of the emulated field T, see NewElement.
+type Element[T FieldParams] struct {
+	Limbs []frontend.Variable
+
+	// the limbs are less than 2^(BitsPerLimb+overflow)
+	overflow uint
+}
+
+// NewElement returns an element of the field T.
+//
+// If v is nil, the limbs are left unassigned, which is how an element is declared in a
+// circuit. Otherwise v is a constant (any type accepted by frontend.Variable), which is
+// reduced modulo the modulus and split in limbs.
+func NewElement[T FieldParams](v interface{}) Element[T] {
+	var params T
+	res := Element[T]{Limbs: make([]frontend.Variable, params.NbLimbs())}
+	if v == nil {
+		return res
+	}
+	b := utils.FromInterface(v)
+	b.Mod(&b, params.Modulus())
+	for i, l := range split(&b, params.NbLimbs(), params.BitsPerLimb()) {
+		res.Limbs[i] = l
+	}
+	return res
+}
+
+// Field performs the arithmetic of the emulated field T in a circuit.
+type Field[T FieldParams] struct {
+	api frontend.API
+
+	nbLimbs, nbBits uint
+	p               *big.Int
+
+	// maximal overflow of an element, such that the product of an element of maximal overflow
+	// and of a reduced element does not wrap around the native modulus
+	maxOverflow uint
+
+	// limbs of the modulus and of modulus-1
+	modulus, maxElement []frontend.Variable
+
+	// multiples of the modulus whose limbs are at least 2^(nbBits+overflow), by overflow
+	paddings map[uint][]frontend.Variable
+
+	// elements whose limbs are known to be bounded by their overflow, by the address of their
+	// limbs. The flag can't be stored in the elements, as the ones of the circuit are reused
+	// when it is compiled again.
+	checked map[*frontend.Variable]struct{}
+}
+
+// NewField returns a Field emulating the field T.
+func NewField[T FieldParams](api frontend.API) (*Field[T], error) {
+	var params T
+	p := params.Modulus()
+	if p == nil || p.Cmp(big.NewInt(1)) <= 0 {
+		return nil, errors.New("emulated: invalid modulus")
+	}
+	nbLimbs, nbBits := params.NbLimbs(), params.BitsPerLimb()
+	if nbLimbs == 0 || nbBits == 0 || nbLimbs*nbBits < uint(p.BitLen()) {
+		return nil, errors.New("emulated: the limbs are too small to hold the modulus")
+	}
+	// the coefficients of a product of reduced elements, plus a margin for the reduction
+	nativeBits := uint(api.Compiler().Curve().ScalarField().BitLen())
+	productBits := 2*nbBits + uint(mbits.Len(nbLimbs))
+	if productBits+16 >= nativeBits {
+		return nil, errors.New("emulated: the limbs are too large for the native field")
+	}
+
+	f := &Field[T]{
+		api:         api,
+		nbLimbs:     nbLimbs,
+		nbBits:      nbBits,
+		p:           new(big.Int).Set(p),
+		maxOverflow: nativeBits - productBits - 8,
+		paddings:    make(map[uint][]frontend.Variable),
+		checked:     make(map[*frontend.Variable]struct{}),
+	}
+	f.modulus = f.constantLimbs(f.p)
+	f.maxElement = f.constantLimbs(new(big.Int).Sub(f.p, big.NewInt(1)))
+
+	return f, nil
+}
+
+// Modulus returns the modulus of the emulated field.
+func (f *Field[T]) Modulus() *big.Int {
+	return new(big.Int).Set(f.p)
+}
+
+// Constant returns the element v mod p.
+func (f *Field[T]) Constant(v *big.Int) *Element[T] {
+	res := NewElement[T](v)
+	return f.newChecked(res.Limbs, 0)
+}
+
+// Zero returns the element 0.
+func (f *Field[T]) Zero() *Element[T] {
+	return f.Constant(big.NewInt(0))
+}
+
+// One returns the element 1.
+func (f *Field[T]) One() *Element[T] {
+	return f.Constant(big.NewInt(1))
+}
+
+// Add returns a + b mod p. The result is not reduced.
+func (f *Field[T]) Add(a, b *Element[T]) *Element[T] {
+	f.enforceWidth(a, b)
+	a, b = f.reduceForSum(a, b, 1)
+	return f.newChecked(f.addPoly(a.Limbs, b.Limbs), max(a.overflow, b.overflow)+1)
+}
+
+// Sub returns a - b mod p. The result is not reduced.
+func (f *Field[T]) Sub(a, b *Element[T]) *Element[T] {
+	f.enforceWidth(a, b)
+	a, b = f.reduceForSum(a, b, 2)
+
+	// a + padding - b has non-negative limbs, less than 2^(nbBits+overflow+2)
+	e := f.subPoly(f.addPoly(a.Limbs, f.padding(b.overflow)), b.Limbs)
+	return f.newChecked(e, max(a.overflow, b.overflow)+2)
+}
+
+// Neg returns -a mod p. The result is not reduced.
+func (f *Field[T]) Neg(a *Element[T]) *Element[T] {
+	return f.Sub(f.Zero(), a)
+}
+
+// Mul returns a·b mod p. The result is reduced.
+func (f *Field[T]) Mul(a, b *Element[T]) *Element[T] {
+	f.enforceWidth(a, b)
+	a, b = f.reduceForProduct(a, b)
+	e := f.mulPoly(a.Limbs, b.Limbs)
+	return f.reduce(e, f.productBits(a, b), false)
+}
+
+// Reduce returns an element congruent to a whose limbs fit in the limb width, which is the
+// canonical representative of a if the prover is honest; use AssertIsInRange to enforce it.
+func (f *Field[T]) Reduce(a *Element[T]) *Element[T] {
+	f.enforceWidth(a)
+	if a.overflow == 0 {
+		return a
+	}
+	return f.reduce(a.Limbs, f.nbBits+a.overflow, false)
+}
+
+// Inverse returns 1/a mod p. The result is reduced, and the solver fails if a is not invertible.
+func (f *Field[T]) Inverse(a *Element[T]) *Element[T] {
+	f.enforceWidth(a)
+	a, _ = f.reduceForProduct(a, f.One())
+	res := f.hint(InverseHint, a.Limbs)
+
+	// a·res - 1 ≡ 0
+	e := f.subPoly(f.mulPoly(a.Limbs, res.Limbs), []frontend.Variable{1})
+	f.reduce(e, f.productBits(a, res), true)
+	return res
+}
+
+// Div returns a/b mod p. The result is reduced, and the solver fails if b is not invertible.
+func (f *Field[T]) Div(a, b *Element[T]) *Element[T] {
+	f.enforceWidth(a, b)
+	b, _ = f.reduceForProduct(b, f.One())
+	res := f.hint(DivHint, a.Limbs, b.Limbs)
+
+	// b·res + padding - a ≡ 0
+	e := f.subPoly(f.addPoly(f.mulPoly(b.Limbs, res.Limbs), f.padding(a.overflow)), a.Limbs)
+	f.reduce(e, max(f.productBits(b, res), f.nbBits+a.overflow+1)+1, true)
+	return res
+}
+
+// AssertIsEqual asserts that a ≡ b mod p.
+func (f *Field[T]) AssertIsEqual(a, b *Element[T]) {
+	f.enforceWidth(a, b)
+	a, b = f.reduceForSum(a, b, 2)
+
+	e := f.subPoly(f.addPoly(a.Limbs, f.padding(b.overflow)), b.Limbs)
+	f.reduce(e, f.nbBits+max(a.overflow, b.overflow)+2, true)
+}
+
+// AssertIsInRange asserts that a < p, that is, that a is the canonical representative of its class.
+func (f *Field[T]) AssertIsInRange(a *Element[T]) {
+	f.enforceWidth(a)
+	// (p-1) - a = q·p + r with q, r ≥ 0 holds only if a ≤ p-1
+	e := f.subPoly(f.maxElement, a.Limbs)
+	f.reduce(e, f.nbBits+a.overflow+1, false)
+}
+
+// Select returns a if sel is 1 and b if sel is 0. sel must be boolean.
+func (f *Field[T]) Select(sel frontend.Variable, a, b *Element[T]) *Element[T] {
+	f.enforceWidth(a, b)
+	res := make([]frontend.Variable, f.nbLimbs)
+	for i := range res {
+		res[i] = f.api.Select(sel, a.Limbs[i], b.Limbs[i])
+	}
+	return f.newChecked(res, max(a.overflow, b.overflow))
+}
+
+// Lookup2 returns a[b0 + 2·b1]. b0 and b1 must be boolean.
+func (f *Field[T]) Lookup2(b0, b1 frontend.Variable, a0, a1, a2, a3 *Element[T]) *Element[T] {
+	f.enforceWidth(a0, a1, a2, a3)
+	res := make([]frontend.Variable, f.nbLimbs)
+	for i := range res {
+		res[i] = f.api.Lookup2(b0, b1, a0.Limbs[i], a1.Limbs[i], a2.Limbs[i], a3.Limbs[i])
+	}
+	return f.newChecked(res, max(max(a0.overflow, a1.overflow), max(a2.overflow, a3.overflow)))
+}
+
+// ToBits returns the NbLimbs·BitsPerLimb bits of a reduced representative of a, least significant
+// first. They are the bits of the canonical representative only if it is enforced with AssertIsInRange.
+func (f *Field[T]) ToBits(a *Element[T]) []frontend.Variable {
+	f.checkLength(a)
+	if a.overflow > 0 {
+		a = f.Reduce(a)
+	}
+	res := make([]frontend.Variable, 0, f.nbLimbs*f.nbBits)
+	for i := range a.Limbs {
+		res = append(res, bits.ToBinary(f.api, a.Limbs[i], bits.WithNbDigits(int(f.nbBits)))...)
+	}
+	f.checked[&a.Limbs[0]] = struct{}{}
+	return res
+}
+
+// FromBits returns the element Σ b[i]·2^i mod p, least significant bit first. The b[i] are
+// constrained to be booleans.
+func (f *Field[T]) FromBits(b []frontend.Variable) *Element[T] {
+	k := int(f.nbBits)
+	if len(b) > int(f.nbLimbs)*k {
+		panic("emulated: too many bits")
+	}
+	res := make([]frontend.Variable, f.nbLimbs)
+	for i := range res {
+		res[i] = 0
+		if i*k < len(b) {
+			end := (i + 1) * k
+			if end > len(b) {
+				end = len(b)
+			}
+			res[i] = bits.FromBinary(f.api, b[i*k:end])
+		}
+	}
+	return f.newChecked(res, 0)
+}
+
+// -------------------------------------------------------------------------------------------------
+// reduction
+
+// reduceForSum reduces a or b until the overflow of their sum, which is max(overflows)+extra,
+// is acceptable.
+func (f *Field[T]) reduceForSum(a, b *Element[T], extra uint) (*Element[T], *Element[T]) {
+	for max(a.overflow, b.overflow)+extra > f.maxOverflow {
+		if a.overflow > b.overflow {
+			a = f.Reduce(a)
+		} else {
+			b = f.Reduce(b)
+		}
+	}
+	return a, b
+}
+
+// reduceForProduct reduces a or b until the coefficients of their product fit in the native field.
+func (f *Field[T]) reduceForProduct(a, b *Element[T]) (*Element[T], *Element[T]) {
+	for a.overflow+b.overflow > f.maxOverflow {
+		if a.overflow > b.overflow {
+			a = f.Reduce(a)
+		} else {
+			b = f.Reduce(b)
+		}
+	}
+	return a, b
+}
+
+// reduce returns r ≡ E(2^k) mod p, where the coefficients of E are e. The coefficients may be
+// negative but are less than 2^coeffBits in absolute value, and E(2^k) must be non-negative.
+// If zero is set, r is asserted to be 0 instead of being returned.
+func (f *Field[T]) reduce(e []frontend.Variable, coeffBits uint, zero bool) *Element[T] {
+	k, n := f.nbBits, f.nbLimbs
+
+	// E(2^k) < 2^valueBits, and p ≥ 2^(bitLen(p)-1) gives the size of the quotient
+	valueBits := int(coeffBits) + (len(e)-1)*int(k) + 1
+	nbQuoLimbs := 0
+	if quoBits := valueBits - f.p.BitLen() + 1; quoBits > 0 {
+		nbQuoLimbs = (quoBits + int(k) - 1) / int(k)
+	}
+
+	nbOutputs := nbQuoLimbs
+	if !zero {
+		nbOutputs += int(n)
+	}
+	inputs := []frontend.Variable{k, n, nbQuoLimbs}
+	inputs = append(inputs, f.modulus...)
+	inputs = append(inputs, e...)
+	outputs, err := f.api.Compiler().NewHint(QuoRem, nbOutputs, inputs...)
+	if err != nil {
+		panic(err)
+	}
+
+	// E - q·p - r must vanish at 2^k
+	quo := outputs[:nbQuoLimbs]
+	f.rangeCheck(quo)
+	d := f.subPoly(e, f.mulPoly(quo, f.modulus))
+	dBits := max(coeffBits, 2*k+uint(mbits.Len(uint(min(nbQuoLimbs, int(n))))))
+	dBits++
+
+	var res *Element[T]
+	if !zero {
+		f.rangeCheck(outputs[nbQuoLimbs:])
+		res = f.newChecked(outputs[nbQuoLimbs:], 0)
+		d = f.subPoly(d, res.Limbs)
+		dBits++
+	}
+	f.checkZero(d, dBits)
+
+	return res
+}
+
+// checkZero asserts that D(2^k) = 0 over the integers, where the coefficients of D are d and
+// are less than 2^coeffBits in absolute value.
+//
+// With dᵢ + cᵢ₋₁ = cᵢ·2^k for the carries cᵢ given by a hint, we get |cᵢ| < 2^(coeffBits-k+1);
+// the carries are range checked accordingly, so that no relation wraps around the native modulus.
+func (f *Field[T]) checkZero(d []frontend.Variable, coeffBits uint) {
+	k := f.nbBits
+	if coeffBits+4 >= uint(f.api.Compiler().Curve().ScalarField().BitLen()) {
+		panic("emulated: coefficients overflow the native field")
+	}
+	if len(d) == 1 {
+		f.api.AssertIsEqual(d[0], 0)
+		return
+	}
+
+	inputs := append([]frontend.Variable{k}, d...)
+	carries, err := f.api.Compiler().NewHint(Carries, len(d)-1, inputs...)
+	if err != nil {
+		panic(err)
+	}
+
+	carryBits := coeffBits - k + 1
+	shift := new(big.Int).Lsh(big.NewInt(1), carryBits)
+	for i := range carries {
+		// cᵢ + 2^carryBits is in [0, 2^(carryBits+1))
+		bits.ToBinary(f.api, f.api.Add(carries[i], shift), bits.WithNbDigits(int(carryBits)+1))
+	}
+
+	base := new(big.Int).Lsh(big.NewInt(1), k)
+	prev := frontend.Variable(0)
+	for i := 0; i < len(d)-1; i++ {
+		f.api.AssertIsEqual(f.api.Add(d[i], prev), f.api.Mul(carries[i], base))
+		prev = carries[i]
+	}
+	f.api.AssertIsEqual(f.api.Add(d[len(d)-1], prev), 0)
+}
+
+// hint returns the reduced element computed by fn from the limbs of the modulus and of the inputs.
+func (f *Field[T]) hint(fn hint.Function, limbs ...[]frontend.Variable) *Element[T] {
+	inputs := []frontend.Variable{f.nbBits, f.nbLimbs}
+	inputs = append(inputs, f.modulus...)
+	for i := range limbs {
+		inputs = append(inputs, limbs[i]...)
+	}
+	res, err := f.api.Compiler().NewHint(fn, int(f.nbLimbs), inputs...)
+	if err != nil {
+		panic(err)
+	}
+	f.rangeCheck(res)
+	return f.newChecked(res, 0)
+}
+
+// padding returns the limbs of a multiple of p whose limbs are at least 2^(nbBits+overflow),
+// so that adding it to a - b keeps the limbs non-negative for any b of this overflow.
+func (f *Field[T]) padding(overflow uint) []frontend.Variable {
+	if res, ok := f.paddings[overflow]; ok {
+		return res
+	}
+	limbs := make([]*big.Int, f.nbLimbs)
+	for i := range limbs {
+		limbs[i] = new(big.Int).Lsh(big.NewInt(1), f.nbBits+overflow)
+	}
+	// add p - (Σ limbs[i]·2^(i·nbBits) mod p) to the limbs
+	var m big.Int
+	m.Mod(recompose(limbs, f.nbBits), f.p).Sub(f.p, &m)
+	for i, l := range split(&m, f.nbLimbs, f.nbBits) {
+		limbs[i].Add(limbs[i], l)
+	}
+
+	res := make([]frontend.Variable, f.nbLimbs)
+	for i := range res {
+		res[i] = limbs[i]
+	}
+	f.paddings[overflow] = res
+	return res
+}
+
+// enforceWidth range checks the limbs of the elements which were not yet.
+func (f *Field[T]) enforceWidth(elements ...*Element[T]) {
+	for _, a := range elements {
+		f.checkLength(a)
+		if _, ok := f.checked[&a.Limbs[0]]; !ok {
+			if a.overflow != 0 {
+				panic("emulated: element with overflow created outside of its field")
+			}
+			f.rangeCheck(a.Limbs)
+			f.checked[&a.Limbs[0]] = struct{}{}
+		}
+	}
+}
+
+// newChecked returns an element with the given limbs, which are bounded by the overflow.
+func (f *Field[T]) newChecked(limbs []frontend.Variable, overflow uint) *Element[T] {
+	f.checked[&limbs[0]] = struct{}{}
+	return &Element[T]{Limbs: limbs, overflow: overflow}
+}
+
+func (f *Field[T]) checkLength(a *Element[T]) {
+	if len(a.Limbs) != int(f.nbLimbs) {
+		panic("emulated: invalid number of limbs")
+	}
+}
+
+// rangeCheck asserts that the limbs fit in the limb width.
+func (f *Field[T]) rangeCheck(limbs []frontend.Variable) {
+	for i := range limbs {
+		bits.ToBinary(f.api, limbs[i], bits.WithNbDigits(int(f.nbBits)))
+	}
+}
+
+// productBits bounds the coefficients of the product of a and b.
+func (f *Field[T]) productBits(a, b *Element[T]) uint {
+	return 2*f.nbBits + a.overflow + b.overflow + uint(mbits.Len(f.nbLimbs))
+}
+
+func (f *Field[T]) constantLimbs(v *big.Int) []frontend.Variable {
+	res := make([]frontend.Variable, f.nbLimbs)
+	for i, l := range split(v, f.nbLimbs, f.nbBits) {
+		res[i] = l
+	}
+	return res
+}
+
+// -------------------------------------------------------------------------------------------------
+// polynomials, given by their coefficients (constant first)
+
+func (f *Field[T]) addPoly(a, b []frontend.Variable) []frontend.Variable {
+	return f.combine(a, b, false)
+}
+
+func (f *Field[T]) subPoly(a, b []frontend.Variable) []frontend.Variable {
+	return f.combine(a, b, true)
+}
+
+func (f *Field[T]) combine(a, b []frontend.Variable, sub bool) []frontend.Variable {
+	res := make([]frontend.Variable, max(len(a), len(b)))
+	for i := range res {
+		switch {
+		case i >= len(b):
+			res[i] = a[i]
+		case i >= len(a) && sub:
+			res[i] = f.api.Neg(b[i])
+		case i >= len(a):
+			res[i] = b[i]
+		case sub:
+			res[i] = f.api.Sub(a[i], b[i])
+		default:
+			res[i] = f.api.Add(a[i], b[i])
+		}
+	}
+	return res
+}
+
+func (f *Field[T]) mulPoly(a, b []frontend.Variable) []frontend.Variable {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	terms := make([][]frontend.Variable, len(a)+len(b)-1)
+	for i := range a {
+		for j := range b {
+			terms[i+j] = append(terms[i+j], f.api.Mul(a[i], b[j]))
+		}
+	}
+	res := make([]frontend.Variable, len(terms))
+	for i := range terms {
+		if len(terms[i]) == 1 {
+			res[i] = terms[i][0]
+		} else {
+			res[i] = f.api.Add(terms[i][0], terms[i][1], terms[i][2:]...)
+		}
+	}
+	return res
+}
+
+type integer interface {
+	~int | ~uint
+}
+
+func min[I integer](a, b I) I {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max[I integer](a, b I) I {
+	if a > b {
+		return a
+	}
+	return b
+}
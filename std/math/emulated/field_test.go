@@ -0,0 +1,175 @@
+package emulated
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+type arithmeticCircuit[T FieldParams] struct {
+	A, B            Element[T]
+	Sum, Diff, Prod Element[T] `gnark:",public"`
+	Quo, Inv, Neg   Element[T] `gnark:",public"`
+}
+
+func (circuit *arithmeticCircuit[T]) Define(api frontend.API) error {
+	f, err := NewField[T](api)
+	if err != nil {
+		return err
+	}
+	f.AssertIsEqual(f.Add(&circuit.A, &circuit.B), &circuit.Sum)
+	f.AssertIsEqual(f.Sub(&circuit.A, &circuit.B), &circuit.Diff)
+	f.AssertIsEqual(f.Mul(&circuit.A, &circuit.B), &circuit.Prod)
+	f.AssertIsEqual(f.Div(&circuit.A, &circuit.B), &circuit.Quo)
+	f.AssertIsEqual(f.Inverse(&circuit.B), &circuit.Inv)
+	f.AssertIsEqual(f.Neg(&circuit.A), &circuit.Neg)
+
+	// the results are reduced
+	f.AssertIsInRange(&circuit.Prod)
+	return nil
+}
+
+func TestArithmetic(t *testing.T) {
+	testArithmetic[Secp256k1Fp](t)
+	testArithmetic[Secp256k1Fr](t)
+	testArithmetic[BN254Fp](t)
+}
+
+func testArithmetic[T FieldParams](t *testing.T) {
+	assert := test.NewAssert(t)
+	var params T
+	p := params.Modulus()
+
+	a, _ := rand.Int(rand.Reader, p)
+	b, _ := rand.Int(rand.Reader, p)
+	var sum, diff, prod, quo, inv, neg big.Int
+	sum.Add(a, b).Mod(&sum, p)
+	diff.Sub(a, b).Mod(&diff, p)
+	prod.Mul(a, b).Mod(&prod, p)
+	inv.ModInverse(b, p)
+	quo.Mul(a, &inv).Mod(&quo, p)
+	neg.Neg(a).Mod(&neg, p)
+
+	circuit := arithmeticCircuit[T]{
+		A:    NewElement[T](nil),
+		B:    NewElement[T](nil),
+		Sum:  NewElement[T](nil),
+		Diff: NewElement[T](nil),
+		Prod: NewElement[T](nil),
+		Quo:  NewElement[T](nil),
+		Inv:  NewElement[T](nil),
+		Neg:  NewElement[T](nil),
+	}
+	witness := arithmeticCircuit[T]{
+		A:    NewElement[T](a),
+		B:    NewElement[T](b),
+		Sum:  NewElement[T](&sum),
+		Diff: NewElement[T](&diff),
+		Prod: NewElement[T](&prod),
+		Quo:  NewElement[T](&quo),
+		Inv:  NewElement[T](&inv),
+		Neg:  NewElement[T](&neg),
+	}
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	wrongWitness := witness
+	wrongWitness.Prod = NewElement[T](new(big.Int).Add(&prod, big.NewInt(1)))
+	assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(ecc.BN254))
+
+	// prod + p is congruent to the product, but not reduced
+	var unreduced big.Int
+	unreduced.Add(&prod, p)
+	if unreduced.BitLen() <= int(params.NbLimbs()*params.BitsPerLimb()) {
+		wrongWitness.Prod = Element[T]{Limbs: make([]frontend.Variable, params.NbLimbs())}
+		for i, l := range split(&unreduced, params.NbLimbs(), params.BitsPerLimb()) {
+			wrongWitness.Prod.Limbs[i] = l
+		}
+		assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(ecc.BN254))
+	}
+}
+
+// lazyCircuit accumulates sums and differences before multiplying them, which exercises the
+// reduction of elements whose limbs overflow.
+type lazyCircuit struct {
+	A, B Element[BN254Fp]
+	Res  Element[BN254Fp] `gnark:",public"`
+}
+
+func (circuit *lazyCircuit) Define(api frontend.API) error {
+	f, err := NewField[BN254Fp](api)
+	if err != nil {
+		return err
+	}
+	acc := &circuit.A
+	for i := 0; i < 40; i++ {
+		acc = f.Sub(f.Add(acc, &circuit.A), &circuit.B)
+	}
+	f.AssertIsEqual(f.Mul(acc, acc), &circuit.Res)
+	return nil
+}
+
+func TestLazyReduction(t *testing.T) {
+	assert := test.NewAssert(t)
+	p := BN254Fp{}.Modulus()
+
+	a, _ := rand.Int(rand.Reader, p)
+	b, _ := rand.Int(rand.Reader, p)
+
+	// (41a - 40b)²
+	var res, tmp big.Int
+	res.Mul(a, big.NewInt(41))
+	tmp.Mul(b, big.NewInt(40))
+	res.Sub(&res, &tmp).Mul(&res, &res).Mod(&res, p)
+
+	circuit := lazyCircuit{
+		A:   NewElement[BN254Fp](nil),
+		B:   NewElement[BN254Fp](nil),
+		Res: NewElement[BN254Fp](nil),
+	}
+	witness := lazyCircuit{
+		A:   NewElement[BN254Fp](a),
+		B:   NewElement[BN254Fp](b),
+		Res: NewElement[BN254Fp](&res),
+	}
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	witness.Res = NewElement[BN254Fp](new(big.Int).Add(&res, big.NewInt(1)))
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BN254))
+}
+
+type bitsCircuit struct {
+	A    Element[Secp256k1Fr]
+	Bits []frontend.Variable `gnark:",public"`
+}
+
+func (circuit *bitsCircuit) Define(api frontend.API) error {
+	f, err := NewField[Secp256k1Fr](api)
+	if err != nil {
+		return err
+	}
+	b := f.ToBits(&circuit.A)
+	for i := range b {
+		api.AssertIsEqual(b[i], circuit.Bits[i])
+	}
+	f.AssertIsEqual(f.FromBits(circuit.Bits), &circuit.A)
+	return nil
+}
+
+func TestBits(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	a, _ := rand.Int(rand.Reader, Secp256k1Fr{}.Modulus())
+	circuit := bitsCircuit{A: NewElement[Secp256k1Fr](nil), Bits: make([]frontend.Variable, 256)}
+	witness := bitsCircuit{A: NewElement[Secp256k1Fr](a), Bits: make([]frontend.Variable, 256)}
+	for i := range witness.Bits {
+		witness.Bits[i] = a.Bit(i)
+	}
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	witness.Bits[0] = 1 - a.Bit(0)
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BN254))
+}
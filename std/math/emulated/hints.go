@@ -0,0 +1,134 @@
+package emulated
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/hint"
+)
+
+func init() {
+	hint.RegisterVersioned(QuoRem, "github.com/consensys/gnark/std/math/emulated.QuoRem", 1)
+	hint.RegisterVersioned(Carries, "github.com/consensys/gnark/std/math/emulated.Carries", 1)
+	hint.RegisterVersioned(InverseHint, "github.com/consensys/gnark/std/math/emulated.InverseHint", 1)
+	hint.RegisterVersioned(DivHint, "github.com/consensys/gnark/std/math/emulated.DivHint", 1)
+}
+
+// QuoRem computes the quotient and the remainder of the division of a polynomial evaluated
+// at 2^k by a modulus.
+//
+// The inputs are k, the number of limbs n of the modulus, the number of limbs of the
+// quotient, the n limbs of the modulus and the (signed) coefficients of the polynomial.
+// The outputs are the limbs of the quotient, followed by the n limbs of the remainder if
+// there are enough outputs.
+func QuoRem(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) < 3 {
+		return errors.New("emulated: missing inputs")
+	}
+	k, n, nbQuoLimbs := uint(inputs[0].Uint64()), int(inputs[1].Uint64()), int(inputs[2].Uint64())
+	if len(inputs) < 3+n || (len(outputs) != nbQuoLimbs && len(outputs) != nbQuoLimbs+n) {
+		return errors.New("emulated: invalid number of inputs or outputs")
+	}
+	p := recompose(inputs[3:3+n], k)
+	if p.Sign() == 0 {
+		return errors.New("emulated: zero modulus")
+	}
+	v := recompose(toSigned(curveID, inputs[3+n:]), k)
+
+	var q, r big.Int
+	q.DivMod(v, p, &r)
+	toNative(curveID, outputs[:nbQuoLimbs], split(&q, uint(nbQuoLimbs), k))
+	if len(outputs) > nbQuoLimbs {
+		toNative(curveID, outputs[nbQuoLimbs:], split(&r, uint(n), k))
+	}
+	return nil
+}
+
+// Carries computes the carries cᵢ such that dᵢ + cᵢ₋₁ = cᵢ·2^k, where the dᵢ are the
+// coefficients of a polynomial vanishing at 2^k.
+//
+// The inputs are k followed by the (signed) coefficients dᵢ. There is one carry less than
+// coefficients, the last relation being d + c = 0.
+func Carries(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) != len(outputs)+2 {
+		return errors.New("emulated: invalid number of inputs or outputs")
+	}
+	k := uint(inputs[0].Uint64())
+	d := toSigned(curveID, inputs[1:])
+	c := new(big.Int)
+	for i := range outputs {
+		// the division is exact when the polynomial vanishes at 2^k
+		c.Add(c, d[i]).Rsh(c, k)
+		toNative(curveID, outputs[i:i+1], []*big.Int{c})
+	}
+	return nil
+}
+
+// InverseHint computes the inverse of an element modulo a modulus.
+//
+// The inputs are k, the number of limbs n, the n limbs of the modulus and the n limbs
+// of the element; the outputs are the n limbs of the inverse.
+func InverseHint(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) < 2 {
+		return errors.New("emulated: missing inputs")
+	}
+	k, n := uint(inputs[0].Uint64()), int(inputs[1].Uint64())
+	if len(inputs) != 2+2*n || len(outputs) != n {
+		return errors.New("emulated: invalid number of inputs or outputs")
+	}
+	p := recompose(inputs[2:2+n], k)
+	a := recompose(inputs[2+n:], k)
+	var res big.Int
+	if res.ModInverse(a, p) == nil {
+		return errors.New("emulated: element is not invertible")
+	}
+	toNative(curveID, outputs, split(&res, uint(n), k))
+	return nil
+}
+
+// DivHint computes a/b modulo a modulus.
+//
+// The inputs are k, the number of limbs n, the n limbs of the modulus, of a and of b; the
+// outputs are the n limbs of the quotient.
+func DivHint(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) < 2 {
+		return errors.New("emulated: missing inputs")
+	}
+	k, n := uint(inputs[0].Uint64()), int(inputs[1].Uint64())
+	if len(inputs) != 2+3*n || len(outputs) != n {
+		return errors.New("emulated: invalid number of inputs or outputs")
+	}
+	p := recompose(inputs[2:2+n], k)
+	a := recompose(inputs[2+n:2+2*n], k)
+	b := recompose(inputs[2+2*n:], k)
+	var res big.Int
+	if res.ModInverse(b, p) == nil {
+		return errors.New("emulated: division by a non invertible element")
+	}
+	res.Mul(&res, a).Mod(&res, p)
+	toNative(curveID, outputs, split(&res, uint(n), k))
+	return nil
+}
+
+// toSigned maps the native field elements in inputs to (-r/2, r/2].
+func toSigned(curveID ecc.ID, inputs []*big.Int) []*big.Int {
+	r := curveID.ScalarField()
+	half := new(big.Int).Rsh(r, 1)
+	res := make([]*big.Int, len(inputs))
+	for i := range inputs {
+		res[i] = new(big.Int).Set(inputs[i])
+		if res[i].Cmp(half) > 0 {
+			res[i].Sub(res[i], r)
+		}
+	}
+	return res
+}
+
+// toNative sets outputs to the (possibly negative) values modulo the native modulus.
+func toNative(curveID ecc.ID, outputs []*big.Int, values []*big.Int) {
+	r := curveID.ScalarField()
+	for i := range outputs {
+		outputs[i].Mod(values[i], r)
+	}
+}
@@ -0,0 +1,83 @@
+package emulated
+
+import (
+	"math/big"
+)
+
+// FieldParams describes an emulated field: its modulus and how its elements are split in limbs.
+//
+// An element is stored as NbLimbs limbs of BitsPerLimb bits (least significant limb first), so
+// NbLimbs·BitsPerLimb must be at least the bit length of the modulus. Any modulus can be
+// emulated by implementing this interface on an empty struct, as done for the fields below.
+type FieldParams interface {
+	NbLimbs() uint
+	BitsPerLimb() uint
+	Modulus() *big.Int
+}
+
+var (
+	qSecp256k1 = newModulus("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+	rSecp256k1 = newModulus("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141")
+	qBN254     = newModulus("30644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd47")
+	rBN254     = newModulus("30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000001")
+)
+
+// Secp256k1Fp is the base field of the secp256k1 curve.
+type Secp256k1Fp struct{}
+
+func (Secp256k1Fp) NbLimbs() uint     { return 4 }
+func (Secp256k1Fp) BitsPerLimb() uint { return 64 }
+func (Secp256k1Fp) Modulus() *big.Int { return qSecp256k1 }
+
+// Secp256k1Fr is the scalar field of the secp256k1 curve.
+type Secp256k1Fr struct{}
+
+func (Secp256k1Fr) NbLimbs() uint     { return 4 }
+func (Secp256k1Fr) BitsPerLimb() uint { return 64 }
+func (Secp256k1Fr) Modulus() *big.Int { return rSecp256k1 }
+
+// BN254Fp is the base field of the BN254 curve, which allows to verify BN254 proofs in a
+// BN254 circuit.
+type BN254Fp struct{}
+
+func (BN254Fp) NbLimbs() uint     { return 4 }
+func (BN254Fp) BitsPerLimb() uint { return 64 }
+func (BN254Fp) Modulus() *big.Int { return qBN254 }
+
+// BN254Fr is the scalar field of the BN254 curve.
+type BN254Fr struct{}
+
+func (BN254Fr) NbLimbs() uint     { return 4 }
+func (BN254Fr) BitsPerLimb() uint { return 64 }
+func (BN254Fr) Modulus() *big.Int { return rBN254 }
+
+func newModulus(hex string) *big.Int {
+	p, ok := new(big.Int).SetString(hex, 16)
+	if !ok {
+		panic("invalid modulus " + hex)
+	}
+	return p
+}
+
+// split decomposes v in nbLimbs limbs of nbBits bits. The bits of v beyond nbLimbs·nbBits are ignored.
+func split(v *big.Int, nbLimbs, nbBits uint) []*big.Int {
+	res := make([]*big.Int, nbLimbs)
+	mask := new(big.Int).Lsh(big.NewInt(1), nbBits)
+	mask.Sub(mask, big.NewInt(1))
+	tmp := new(big.Int).Set(v)
+	for i := range res {
+		res[i] = new(big.Int).And(tmp, mask)
+		tmp.Rsh(tmp, nbBits)
+	}
+	return res
+}
+
+// recompose returns Σ limbs[i]·2^(i·nbBits), the limbs being possibly negative or wider than nbBits.
+func recompose(limbs []*big.Int, nbBits uint) *big.Int {
+	res := new(big.Int)
+	for i := len(limbs) - 1; i >= 0; i-- {
+		res.Lsh(res, nbBits)
+		res.Add(res, limbs[i])
+	}
+	return res
+}
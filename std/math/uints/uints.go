@@ -0,0 +1,331 @@
+// Package uints provides fixed-width unsigned integers in a circuit, with the wrap-around
+// arithmetic and the bitwise operations of their native Go counterparts.
+//
+// A value is kept either packed in a single variable, as a slice of bits (least significant
+// first), or both; each form is only computed when an operation needs it and is then cached
+// in the value. Bitwise operations work on the bits, rotations and shifts are free, and
+// additions work on the packed values: a sum is not reduced modulo 2^w (w being the width)
+// until its bits are needed, so that a chain of additions costs a single decomposition.
+//
+// This is the base layer for circuits mimicking CPU or hash logic (SHA-2, Keccak, Blake...).
+package uints
+
+import (
+	"math/big"
+	mbits "math/bits"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// Width gives the number of bits of a word type.
+type Width interface {
+	NbBits() int
+}
+
+// W8 is the width of U8.
+type W8 struct{}
+
+// W32 is the width of U32.
+type W32 struct{}
+
+// W64 is the width of U64.
+type W64 struct{}
+
+func (W8) NbBits() int  { return 8 }
+func (W32) NbBits() int { return 32 }
+func (W64) NbBits() int { return 64 }
+
+// U8 is a byte.
+type U8 = Uint[W8]
+
+// U32 is a 32-bit word.
+type U32 = Uint[W32]
+
+// U64 is a 64-bit word.
+type U64 = Uint[W64]
+
+// Uint is an unsigned integer of width T, see BinaryField.
+type Uint[T Width] struct {
+	// packed value, less than 2^(w+overflow) and congruent to the word modulo 2^w; nil if the
+	// value has not been packed yet
+	val      frontend.Variable
+	overflow uint
+
+	// bits of the word, lsb first; nil if the value has not been decomposed yet
+	bits []frontend.Variable
+}
+
+// BinaryField performs the operations on words of width T in a circuit.
+type BinaryField[T Width] struct {
+	api    frontend.API
+	nbBits int
+
+	// maximal overflow of a packed value, such that sums don't wrap around the native modulus
+	maxOverflow uint
+}
+
+// New returns a BinaryField for words of width T.
+func New[T Width](api frontend.API) *BinaryField[T] {
+	var width T
+	nbBits := width.NbBits()
+	nativeBits := api.Compiler().Curve().ScalarField().BitLen()
+	if nbBits <= 0 || nbBits+8 >= nativeBits {
+		panic("uints: the width doesn't fit in the native field")
+	}
+	return &BinaryField[T]{
+		api:         api,
+		nbBits:      nbBits,
+		maxOverflow: uint(nativeBits - nbBits - 2),
+	}
+}
+
+// ValueOf returns v as a word, constraining it to be less than 2^w.
+func (bf *BinaryField[T]) ValueOf(v frontend.Variable) *Uint[T] {
+	return &Uint[T]{
+		val:  v,
+		bits: bits.ToBinary(bf.api, v, bits.WithNbDigits(bf.nbBits)),
+	}
+}
+
+// Constant returns the word v mod 2^w.
+func (bf *BinaryField[T]) Constant(v uint64) *Uint[T] {
+	res := &Uint[T]{bits: make([]frontend.Variable, bf.nbBits)}
+	for i := range res.bits {
+		res.bits[i] = (v >> i) & 1
+	}
+	if bf.nbBits < 64 {
+		v &= 1<<bf.nbBits - 1
+	}
+	res.val = new(big.Int).SetUint64(v)
+	return res
+}
+
+// ToValue returns the value of a, in [0, 2^w).
+func (bf *BinaryField[T]) ToValue(a *Uint[T]) frontend.Variable {
+	bf.reduce(a)
+	return bf.pack(a)
+}
+
+// ToBits returns the bits of a, least significant first.
+func (bf *BinaryField[T]) ToBits(a *Uint[T]) []frontend.Variable {
+	res := make([]frontend.Variable, bf.nbBits)
+	copy(res, bf.decompose(a))
+	return res
+}
+
+// FromBits returns the word made of the bits b, least significant first. The bits are
+// constrained to be booleans; missing high bits are zeros.
+func (bf *BinaryField[T]) FromBits(b []frontend.Variable) *Uint[T] {
+	if len(b) > bf.nbBits {
+		panic("uints: too many bits")
+	}
+	res := &Uint[T]{bits: make([]frontend.Variable, bf.nbBits)}
+	for i := range res.bits {
+		if i < len(b) {
+			bf.api.AssertIsBoolean(b[i])
+			res.bits[i] = b[i]
+		} else {
+			res.bits[i] = 0
+		}
+	}
+	return res
+}
+
+// ToBytes returns the bytes of a in little-endian order; reverse them for big-endian.
+func (bf *BinaryField[T]) ToBytes(a *Uint[T]) []frontend.Variable {
+	b := bf.decompose(a)
+	res := make([]frontend.Variable, (bf.nbBits+7)/8)
+	for i := range res {
+		res[i] = bits.FromBinary(bf.api, b[8*i:min(8*i+8, bf.nbBits)], bits.WithUnconstrainedInputs())
+	}
+	return res
+}
+
+// FromBytes returns the word whose little-endian bytes are b, constraining each element of b
+// to be a byte. Missing high bytes are zeros.
+func (bf *BinaryField[T]) FromBytes(b []frontend.Variable) *Uint[T] {
+	if 8*len(b) > bf.nbBits+7 {
+		panic("uints: too many bytes")
+	}
+	res := make([]frontend.Variable, 0, 8*len(b))
+	for i := range b {
+		res = append(res, bits.ToBinary(bf.api, b[i], bits.WithNbDigits(8))...)
+	}
+	return bf.FromBits(res[:min(len(res), bf.nbBits)])
+}
+
+// AssertIsEqual asserts that a == b.
+func (bf *BinaryField[T]) AssertIsEqual(a, b *Uint[T]) {
+	bf.api.AssertIsEqual(bf.ToValue(a), bf.ToValue(b))
+}
+
+// Add returns the sum of the words modulo 2^w. The result is not reduced.
+func (bf *BinaryField[T]) Add(a, b *Uint[T], others ...*Uint[T]) *Uint[T] {
+	words := append([]*Uint[T]{a, b}, others...)
+
+	// the sum of n values of overflow at most o has an overflow of at most o + ⌈log₂(n)⌉
+	extra := uint(mbits.Len(uint(len(words) - 1)))
+	var overflow uint
+	for _, w := range words {
+		if w.overflow+extra > bf.maxOverflow {
+			bf.reduce(w)
+		}
+		overflow = max(overflow, w.overflow)
+	}
+
+	terms := make([]frontend.Variable, len(words))
+	for i := range words {
+		terms[i] = bf.pack(words[i])
+	}
+	return &Uint[T]{
+		val:      bf.api.Add(terms[0], terms[1], terms[2:]...),
+		overflow: overflow + extra,
+	}
+}
+
+// AddCarry returns a + b + carry modulo 2^w and the carry out, like math/bits.Add32. carry
+// must be 0 or 1, which is not checked.
+func (bf *BinaryField[T]) AddCarry(a, b *Uint[T], carry frontend.Variable) (sum *Uint[T], carryOut frontend.Variable) {
+	bf.reduce(a)
+	bf.reduce(b)
+	s := bits.ToBinary(bf.api, bf.api.Add(bf.pack(a), bf.pack(b), carry), bits.WithNbDigits(bf.nbBits+1))
+	return &Uint[T]{bits: s[:bf.nbBits]}, s[bf.nbBits]
+}
+
+// Rotl returns a rotated left by k bits; k may be negative to rotate right.
+func (bf *BinaryField[T]) Rotl(a *Uint[T], k int) *Uint[T] {
+	b := bf.decompose(a)
+	k = ((k % bf.nbBits) + bf.nbBits) % bf.nbBits
+	res := &Uint[T]{bits: make([]frontend.Variable, bf.nbBits)}
+	for i := range res.bits {
+		res.bits[(i+k)%bf.nbBits] = b[i]
+	}
+	return res
+}
+
+// Shr returns a shifted right by k bits.
+func (bf *BinaryField[T]) Shr(a *Uint[T], k int) *Uint[T] {
+	b := bf.decompose(a)
+	res := &Uint[T]{bits: make([]frontend.Variable, bf.nbBits)}
+	for i := range res.bits {
+		if i+k < bf.nbBits {
+			res.bits[i] = b[i+k]
+		} else {
+			res.bits[i] = 0
+		}
+	}
+	return res
+}
+
+// Shl returns a shifted left by k bits.
+func (bf *BinaryField[T]) Shl(a *Uint[T], k int) *Uint[T] {
+	b := bf.decompose(a)
+	res := &Uint[T]{bits: make([]frontend.Variable, bf.nbBits)}
+	for i := range res.bits {
+		if i >= k {
+			res.bits[i] = b[i-k]
+		} else {
+			res.bits[i] = 0
+		}
+	}
+	return res
+}
+
+// Xor returns a ⊕ b.
+func (bf *BinaryField[T]) Xor(a, b *Uint[T]) *Uint[T] {
+	return bf.bitwise(a, b, bf.xor)
+}
+
+// And returns a ∧ b.
+func (bf *BinaryField[T]) And(a, b *Uint[T]) *Uint[T] {
+	return bf.bitwise(a, b, bf.and)
+}
+
+// Not returns ¬a.
+func (bf *BinaryField[T]) Not(a *Uint[T]) *Uint[T] {
+	b := bf.decompose(a)
+	res := &Uint[T]{bits: make([]frontend.Variable, bf.nbBits)}
+	for i := range res.bits {
+		res.bits[i] = bf.api.Sub(1, b[i])
+	}
+	return res
+}
+
+func (bf *BinaryField[T]) bitwise(a, b *Uint[T], op func(x, y frontend.Variable) frontend.Variable) *Uint[T] {
+	ba, bb := bf.decompose(a), bf.decompose(b)
+	res := &Uint[T]{bits: make([]frontend.Variable, bf.nbBits)}
+	for i := range res.bits {
+		res.bits[i] = op(ba[i], bb[i])
+	}
+	return res
+}
+
+// xor returns x ⊕ y, without recording a constraint if one of the bits is constant.
+func (bf *BinaryField[T]) xor(x, y frontend.Variable) frontend.Variable {
+	if _, ok := bf.api.Compiler().ConstantValue(x); ok {
+		x, y = y, x
+	}
+	if v, ok := bf.api.Compiler().ConstantValue(y); ok {
+		if v.Sign() == 0 {
+			return x
+		}
+		return bf.api.Sub(1, x)
+	}
+	return bf.api.Xor(x, y)
+}
+
+// and returns x ∧ y, without recording a constraint if one of the bits is constant.
+func (bf *BinaryField[T]) and(x, y frontend.Variable) frontend.Variable {
+	if _, ok := bf.api.Compiler().ConstantValue(x); ok {
+		x, y = y, x
+	}
+	if v, ok := bf.api.Compiler().ConstantValue(y); ok {
+		if v.Sign() == 0 {
+			return 0
+		}
+		return x
+	}
+	return bf.api.And(x, y)
+}
+
+// decompose returns the bits of a, decomposing it if needed.
+func (bf *BinaryField[T]) decompose(a *Uint[T]) []frontend.Variable {
+	bf.reduce(a)
+	return a.bits
+}
+
+// reduce makes sure that a is decomposed and that its packed value, if any, is less than 2^w.
+func (bf *BinaryField[T]) reduce(a *Uint[T]) {
+	if a.bits != nil {
+		return
+	}
+	b := bits.ToBinary(bf.api, a.val, bits.WithNbDigits(bf.nbBits+int(a.overflow)))
+	a.bits = b[:bf.nbBits]
+	if a.overflow != 0 {
+		// the packed value is an unreduced sum, it is packed again from the bits if needed
+		a.val, a.overflow = nil, 0
+	}
+}
+
+// pack returns the packed value of a, computing it from the bits if needed.
+func (bf *BinaryField[T]) pack(a *Uint[T]) frontend.Variable {
+	if a.val == nil {
+		a.val = bits.FromBinary(bf.api, a.bits, bits.WithUnconstrainedInputs())
+	}
+	return a.val
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b uint) uint {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,88 @@
+package uints
+
+import (
+	"math/bits"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+type opsCircuit[T Width] struct {
+	X, Y frontend.Variable
+
+	Sum, SumCarry, Carry, Rotl, Rotr, Shr, Xor, And frontend.Variable `gnark:",public"`
+	Bytes                                           []frontend.Variable
+}
+
+func (c *opsCircuit[T]) Define(api frontend.API) error {
+	bf := New[T](api)
+	x, y := bf.ValueOf(c.X), bf.ValueOf(c.Y)
+
+	// the sum is reduced once, by the equality check
+	bf.AssertIsEqual(bf.Add(x, y, bf.Constant(1), x), bf.ValueOf(c.Sum))
+
+	sum, carry := bf.AddCarry(x, y, 1)
+	api.AssertIsEqual(bf.ToValue(sum), c.SumCarry)
+	api.AssertIsEqual(carry, c.Carry)
+
+	api.AssertIsEqual(bf.ToValue(bf.Rotl(x, 7)), c.Rotl)
+	api.AssertIsEqual(bf.ToValue(bf.Rotl(x, -7)), c.Rotr)
+	api.AssertIsEqual(bf.ToValue(bf.Shr(y, 3)), c.Shr)
+	api.AssertIsEqual(bf.ToValue(bf.Xor(bf.Add(x, y), bf.Constant(0xff))), c.Xor)
+	api.AssertIsEqual(bf.ToValue(bf.And(x, bf.Not(y))), c.And)
+
+	b := bf.ToBytes(x)
+	for i := range b {
+		api.AssertIsEqual(b[i], c.Bytes[i])
+	}
+	bf.AssertIsEqual(bf.FromBytes(c.Bytes), x)
+	return nil
+}
+
+func TestU32(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	x, y := uint32(0xdeadbeef), uint32(0x9abcdef0)
+	sum, carry := bits.Add32(x, y, 1)
+	witness := opsCircuit[W32]{
+		X: x, Y: y,
+		Sum:      x + y + 1 + x,
+		SumCarry: sum, Carry: carry,
+		Rotl: bits.RotateLeft32(x, 7), Rotr: bits.RotateLeft32(x, -7),
+		Shr: y >> 3, Xor: (x + y) ^ 0xff, And: x &^ y,
+		Bytes: []frontend.Variable{0xef, 0xbe, 0xad, 0xde},
+	}
+	circuit := opsCircuit[W32]{Bytes: make([]frontend.Variable, 4)}
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	wrong := witness
+	wrong.Sum = x + y + x
+	assert.SolvingFailed(&circuit, &wrong, test.WithCurves(ecc.BN254))
+
+	wrong = witness
+	wrong.X = uint64(x) + 1<<32
+	assert.SolvingFailed(&circuit, &wrong, test.WithCurves(ecc.BN254))
+}
+
+func TestU64(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	x, y := uint64(0xfedcba9876543210), uint64(0x0123456789abcdef)
+	sum, carry := bits.Add64(x, y, 1)
+	witness := opsCircuit[W64]{
+		X: x, Y: y,
+		Sum:      x + y + 1 + x,
+		SumCarry: sum, Carry: carry,
+		Rotl: bits.RotateLeft64(x, 7), Rotr: bits.RotateLeft64(x, -7),
+		Shr: y >> 3, Xor: (x + y) ^ 0xff, And: x &^ y,
+		Bytes: []frontend.Variable{0x10, 0x32, 0x54, 0x76, 0x98, 0xba, 0xdc, 0xfe},
+	}
+	circuit := opsCircuit[W64]{Bytes: make([]frontend.Variable, 8)}
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	wrong := witness
+	wrong.Carry = 0
+	assert.SolvingFailed(&circuit, &wrong, test.WithCurves(ecc.BN254))
+}
@@ -0,0 +1,70 @@
+// Package ecdsa provides a ZKP-circuit function to verify an ECDSA signature over a short
+// Weierstrass curve whose fields are emulated, such as the secp256k1 signatures of Ethereum.
+package ecdsa
+
+import (
+	"errors"
+
+	"github.com/consensys/gnark/std/algebra/weierstrass"
+	"github.com/consensys/gnark/std/math/emulated"
+)
+
+// PublicKey stores an ecdsa public key (to be used in gnark circuit)
+type PublicKey[Base emulated.FieldParams] struct {
+	Q weierstrass.AffinePoint[Base]
+}
+
+// Signature stores a signature (to be used in gnark circuit)
+// An ECDSA signature is a pair of scalars (R, S), R being the abscissa of the point k·G
+// reduced modulo the order of the curve.
+type Signature[Scalars emulated.FieldParams] struct {
+	R, S emulated.Element[Scalars]
+}
+
+// NewPublicKey returns the public key (x, y). If x and y are nil, the key is left unassigned,
+// which is how it is declared in a circuit.
+func NewPublicKey[Base emulated.FieldParams](x, y interface{}) PublicKey[Base] {
+	return PublicKey[Base]{Q: weierstrass.NewAffinePoint[Base](x, y)}
+}
+
+// NewSignature returns the signature (r, s). If r and s are nil, the signature is left
+// unassigned, which is how it is declared in a circuit.
+func NewSignature[Scalars emulated.FieldParams](r, s interface{}) Signature[Scalars] {
+	return Signature[Scalars]{
+		R: emulated.NewElement[Scalars](r),
+		S: emulated.NewElement[Scalars](s),
+	}
+}
+
+// Verify verifies an ecdsa signature of the message hash msgHash, given as an element of the
+// scalar field (for instance the Keccak-256 digest of the message for Ethereum). It asserts that r
+// and s are in [1, n), n being the order of the curve, as the verification algorithm requires.
+// cf https://en.wikipedia.org/wiki/Elliptic_Curve_Digital_Signature_Algorithm
+func Verify[Base, Scalars emulated.FieldParams](curve *weierstrass.Curve[Base, Scalars], sig Signature[Scalars], msgHash emulated.Element[Scalars], pubKey PublicKey[Base]) error {
+	var base Base
+	var scalars Scalars
+	if base.NbLimbs() != scalars.NbLimbs() || base.BitsPerLimb() != scalars.BitsPerLimb() {
+		return errors.New("ecdsa: the base and scalar fields must have the same limbs")
+	}
+	fp, fr := curve.BaseField(), curve.ScalarField()
+
+	curve.AssertIsOnCurve(&pubKey.Q)
+
+	// r and s are reduced, and not zero: the inverses only exist for non-zero elements
+	fr.AssertIsInRange(&sig.R)
+	fr.AssertIsInRange(&sig.S)
+	fr.Inverse(&sig.R)
+	sInv := fr.Inverse(&sig.S)
+
+	// P = [msgHash/s]G + [r/s]Q
+	u1 := fr.Mul(&msgHash, sInv)
+	u2 := fr.Mul(&sig.R, sInv)
+	P := curve.JointScalarMulBase(&pubKey.Q, u1, u2)
+
+	// r = P.x mod n, P.x being reduced modulo p
+	x := fp.Reduce(&P.X)
+	fp.AssertIsInRange(x)
+	fr.AssertIsEqual(&emulated.Element[Scalars]{Limbs: x.Limbs}, &sig.R)
+
+	return nil
+}
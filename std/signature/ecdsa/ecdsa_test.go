@@ -0,0 +1,111 @@
+package ecdsa
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/weierstrass"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/test"
+	"golang.org/x/crypto/sha3"
+)
+
+type ecdsaCircuit struct {
+	PublicKey PublicKey[emulated.Secp256k1Fp]        `gnark:",public"`
+	Signature Signature[emulated.Secp256k1Fr]        `gnark:",public"`
+	MsgHash   emulated.Element[emulated.Secp256k1Fr] `gnark:",public"`
+}
+
+func (circuit *ecdsaCircuit) Define(api frontend.API) error {
+	curve, err := weierstrass.New[emulated.Secp256k1Fp, emulated.Secp256k1Fr](api, weierstrass.GetSecp256k1Params())
+	if err != nil {
+		return err
+	}
+	return Verify(curve, circuit.Signature, circuit.MsgHash, circuit.PublicKey)
+}
+
+// sign returns an ecdsa signature of msgHash with the private key d.
+func sign(params weierstrass.CurveParams, d, msgHash *big.Int) (r, s *big.Int) {
+	n := params.N
+	for {
+		k, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			panic(err)
+		}
+		if k.Sign() == 0 {
+			continue
+		}
+		x, _ := params.ScalarBaseMul(k)
+		r = new(big.Int).Mod(x, n)
+		if r.Sign() == 0 {
+			continue
+		}
+		// s = (msgHash + r·d) / k
+		s = new(big.Int).Mul(r, d)
+		s.Add(s, msgHash).Mul(s, new(big.Int).ModInverse(k, n)).Mod(s, n)
+		if s.Sign() != 0 {
+			return r, s
+		}
+	}
+}
+
+func TestEcdsa(t *testing.T) {
+	params := weierstrass.GetSecp256k1Params()
+
+	d, err := rand.Int(rand.Reader, params.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qx, qy := params.ScalarBaseMul(d)
+
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte("testing ECDSA in a circuit"))
+	msgHash := new(big.Int).SetBytes(h.Sum(nil))
+	r, s := sign(params, d, msgHash)
+
+	circuit := ecdsaCircuit{
+		PublicKey: NewPublicKey[emulated.Secp256k1Fp](nil, nil),
+		Signature: NewSignature[emulated.Secp256k1Fr](nil, nil),
+		MsgHash:   emulated.NewElement[emulated.Secp256k1Fr](nil),
+	}
+	witness := ecdsaCircuit{
+		PublicKey: NewPublicKey[emulated.Secp256k1Fp](qx, qy),
+		Signature: NewSignature[emulated.Secp256k1Fr](r, s),
+		MsgHash:   emulated.NewElement[emulated.Secp256k1Fr](msgHash),
+	}
+
+	// the circuit is too large to be compiled in a unit test, so it is only run by the test engine
+	if err := test.IsSolved(&circuit, &witness, ecc.BN254, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+
+	// verification with an incorrect message
+	wrongWitness := witness
+	wrongWitness.MsgHash = emulated.NewElement[emulated.Secp256k1Fr](new(big.Int).Add(msgHash, big.NewInt(1)))
+	if err := test.IsSolved(&circuit, &wrongWitness, ecc.BN254, backend.UNKNOWN); err == nil {
+		t.Fatal("expected the verification of an incorrect message to fail")
+	}
+
+	// r and s must not be zero
+	for _, sig := range []Signature[emulated.Secp256k1Fr]{
+		NewSignature[emulated.Secp256k1Fr](0, s),
+		NewSignature[emulated.Secp256k1Fr](r, 0),
+	} {
+		zeroWitness := witness
+		zeroWitness.Signature = sig
+		if err := test.IsSolved(&circuit, &zeroWitness, ecc.BN254, backend.UNKNOWN); err == nil {
+			t.Fatal("expected the verification of a signature with a zero scalar to fail")
+		}
+	}
+
+	// (r, -s) is also a valid signature
+	malleableWitness := witness
+	malleableWitness.Signature = NewSignature[emulated.Secp256k1Fr](r, new(big.Int).Sub(params.N, s))
+	if err := test.IsSolved(&circuit, &malleableWitness, ecc.BN254, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+}
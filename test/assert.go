@@ -26,14 +26,19 @@ import (
 	"github.com/consensys/gnark"
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/cluster"
+	"github.com/consensys/gnark/backend/gpiano"
 	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/piano"
 	"github.com/consensys/gnark/backend/plonk"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/compiled"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/internal/mpisim"
 	"github.com/stretchr/testify/require"
+	"github.com/sunblaze-ucb/simpleMPI/mpi"
 )
 
 var (
@@ -135,7 +140,9 @@ func (assert *Assert) ProverSucceeded(circuit frontend.Circuit, validAssignment
 				err = IsSolved(circuit, validAssignment, curve, backend.UNKNOWN)
 				checkError(err)
 
-				assert.t.Parallel()
+				if !isDistributed(b) || opt.cluster == nil {
+					assert.t.Parallel()
+				}
 
 				switch b {
 				case backend.GROTH16:
@@ -163,6 +170,15 @@ func (assert *Assert) ProverSucceeded(circuit frontend.Circuit, validAssignment
 					err = plonk.Verify(correctProof, vk, validPublicWitness)
 					checkError(err)
 
+				case backend.PIANO, backend.GPIANO:
+					if !isDistributedCurve(curve) {
+						return
+					}
+
+					setupErr, err := opt.proveDistributed(b, ccs, validWitness, validPublicWitness, opt.proverOpts...)
+					checkError(setupErr)
+					checkError(err)
+
 				default:
 					panic("backend not implemented")
 				}
@@ -214,7 +230,9 @@ func (assert *Assert) ProverFailed(circuit frontend.Circuit, invalidAssignment f
 				err = IsSolved(circuit, invalidAssignment, curve, backend.UNKNOWN)
 				mustError(err)
 
-				assert.t.Parallel()
+				if !isDistributed(b) || opt.cluster == nil {
+					assert.t.Parallel()
+				}
 				err = ccs.IsSolved(invalidPublicWitness)
 				mustError(err)
 
@@ -239,6 +257,19 @@ func (assert *Assert) ProverFailed(circuit frontend.Circuit, invalidAssignment f
 					err = plonk.Verify(incorrectProof, vk, invalidPublicWitness)
 					mustError(err)
 
+				case backend.PIANO, backend.GPIANO:
+					if !isDistributedCurve(curve) {
+						return
+					}
+
+					// the master party checks the constraints while proving, so Prove may already fail
+					setupErr, err := opt.proveDistributed(b, ccs, invalidWitness, invalidPublicWitness, popts...)
+					checkError(setupErr)
+					// on a cluster, only the master verifies the proof
+					if opt.cluster == nil || mpi.SelfRank == 0 {
+						mustError(err)
+					}
+
 				default:
 					panic("backend not implemented")
 				}
@@ -411,6 +442,11 @@ func (assert *Assert) compile(circuit frontend.Circuit, curveID ecc.ID, backendI
 		return nil, err
 	}
 
+	// the plonk-like backends share the same constraint system
+	if isDistributed(backendID) {
+		backendID = backend.PLONK
+	}
+
 	key := fmt.Sprintf("%d%d%s%d", curveID, backendID, reflect.TypeOf(circuit).String(), addr)
 
 	// check if we already compiled it
@@ -457,11 +493,19 @@ func (assert *Assert) options(opts ...TestingOption) testingConfig {
 		witnessSerialization: true,
 		backends:             backend.Implemented(),
 		curves:               gnark.Curves(),
+		parties:              []uint64{2, 4},
 	}
 	for _, option := range opts {
 		err := option(&opt)
 		assert.NoError(err, "parsing TestingOption")
 	}
+	if opt.cluster == nil && hasDistributed(opt.backends) {
+		c, err := cluster.FromEnv()
+		if !errors.Is(err, cluster.ErrNoConfig) {
+			assert.NoError(err, "loading the cluster configuration")
+			opt.cluster = c
+		}
+	}
 
 	if testing.Short() {
 		// if curves are all there, we just test with bn254
@@ -472,6 +516,102 @@ func (assert *Assert) options(opts ...TestingOption) testingConfig {
 	return opt
 }
 
+// isDistributed reports whether b is a distributed backend (piano, gpiano).
+//
+// On a cluster, these backends exchange messages with the other parties of the MPI world of the
+// process in the order of the calls: all the parties must run the same sequence of Setup / Prove
+// / Verify, so they are not run in parallel subtests. The simulated worlds have no such constraint.
+func isDistributed(b backend.ID) bool {
+	return b == backend.PIANO || b == backend.GPIANO
+}
+
+func hasDistributed(backends []backend.ID) bool {
+	for _, b := range backends {
+		if isDistributed(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDistributedCurve reports whether the distributed backends (piano, gpiano) are implemented on curve.
+func isDistributedCurve(curve ecc.ID) bool {
+	return curve == ecc.BN254
+}
+
+// proveDistributed runs Setup, Prove and Verify of the distributed backend b, every party holding
+// ccs and the witnesses: on the cluster of the options if any, else on an in-process world of each
+// of the numbers of parties of the options. Only the master verifies the proof.
+//
+// It returns the error of Setup, else the one of Prove or Verify: the error of the master, or of
+// the first party which failed. On a cluster, these are the errors of the party of the process.
+func (opt *testingConfig) proveDistributed(b backend.ID, ccs frontend.CompiledConstraintSystem, fullWitness, publicWitness *witness.Witness, proverOpts ...backend.ProverOption) (setupErr, err error) {
+	var prove func(tr cluster.Transport) (setupErr, err error)
+	switch b {
+	case backend.PIANO:
+		prove = func(tr cluster.Transport) (error, error) {
+			pk, vk, err := piano.SetupWithTransport(ccs, tr, publicWitness)
+			if err != nil {
+				return err, nil
+			}
+			proof, err := piano.ProveWithTransport(ccs, tr, pk, fullWitness, proverOpts...)
+			if err != nil || tr.Rank() != 0 {
+				return nil, err
+			}
+			return nil, piano.Verify(proof, vk, publicWitness)
+		}
+	case backend.GPIANO:
+		prove = func(tr cluster.Transport) (error, error) {
+			pk, vk, err := gpiano.SetupWithTransport(ccs, tr, publicWitness)
+			if err != nil {
+				return err, nil
+			}
+			proof, err := gpiano.ProveWithTransport(ccs, tr, pk, fullWitness, proverOpts...)
+			if err != nil || tr.Rank() != 0 {
+				return nil, err
+			}
+			return nil, gpiano.Verify(proof, vk, publicWitness)
+		}
+	default:
+		panic("not a distributed backend")
+	}
+
+	if opt.cluster != nil {
+		if err := opt.cluster.Init(); err != nil {
+			return err, nil
+		}
+		return prove(cluster.MPI())
+	}
+
+	for _, size := range opt.parties {
+		world, err := mpisim.NewWorld(size)
+		if err != nil {
+			return err, nil
+		}
+		// a party failing closes the world, so that the others fail rather than wait for it
+		setupErrs := make([]error, size)
+		errs := world.Run(func(p *mpisim.Party) error {
+			setupErr, err := prove(p)
+			if setupErr != nil {
+				setupErrs[p.Rank()] = setupErr
+				return setupErr
+			}
+			return err
+		})
+		for i := range setupErrs {
+			if setupErrs[i] != nil {
+				return fmt.Errorf("%d parties: party %d: %w", size, i, setupErrs[i]), nil
+			}
+		}
+		for i := range errs {
+			if errs[i] != nil {
+				return nil, fmt.Errorf("%d parties: party %d: %w", size, i, errs[i])
+			}
+		}
+	}
+	return nil, nil
+}
+
 // ensure the error is set, else fails the test
 func (assert *Assert) mustError(err error, backendID backend.ID, curve ecc.ID, witness *witness.Witness) {
 	if err != nil {
@@ -0,0 +1,563 @@
+package test
+
+import (
+	"fmt"
+	"math/big"
+	mrand "math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/cluster"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/sunblaze-ucb/simpleMPI/mpi"
+)
+
+// Op is an operation of a Program, mapped to a frontend.API method.
+type Op uint8
+
+const (
+	OpAdd Op = iota
+	OpSub
+	OpMul
+	OpNeg
+	OpDiv
+	OpInverse
+	OpAddConst
+	OpMulConst
+	OpIsZero
+	OpSelect
+	OpLookup2
+	OpXor
+	OpOr
+	OpAnd
+	OpCmp
+	OpBinary // ToBinary on Const bits, then FromBinary
+
+	// the assertions don't produce a value
+	OpAssertIsEqual     // the value against a public output
+	OpAssertIsDifferent // the value against a public output
+	OpAssertIsBoolean
+	OpAssertIsLessOrEqual // against Const if there is a single argument
+
+	nbOps
+)
+
+var opNames = [nbOps]string{
+	"Add", "Sub", "Mul", "Neg", "Div", "Inverse", "AddConst", "MulConst", "IsZero", "Select", "Lookup2",
+	"Xor", "Or", "And", "Cmp", "Binary", "AssertIsEqual", "AssertIsDifferent", "AssertIsBoolean",
+	"AssertIsLessOrEqual",
+}
+
+func (op Op) String() string {
+	if op < nbOps {
+		return opNames[op]
+	}
+	return fmt.Sprintf("Op(%d)", op)
+}
+
+// arity returns the number of values read by op.
+func (op Op) arity() int {
+	switch op {
+	case OpNeg, OpInverse, OpAddConst, OpMulConst, OpIsZero, OpBinary, OpAssertIsBoolean,
+		OpAssertIsEqual, OpAssertIsDifferent:
+		return 1
+	case OpSelect:
+		return 3
+	case OpLookup2:
+		return 6
+	default:
+		return 2
+	}
+}
+
+// isAssertion reports whether op doesn't produce a value.
+func (op Op) isAssertion() bool {
+	return op >= OpAssertIsEqual
+}
+
+// Instruction is a call of a Program.
+type Instruction struct {
+	Op Op
+
+	// Args are the indexes of the values read by the operation. OpAssertIsEqual and
+	// OpAssertIsDifferent have the index of their public output as last argument.
+	Args []int
+
+	// Const is the constant operand of OpAddConst, OpMulConst and OpAssertIsLessOrEqual, and the
+	// number of bits of OpBinary.
+	Const int64
+}
+
+// Program is a straight-line sequence of frontend.API calls, used for differential testing of
+// the test engine, the constraint systems of the builders and the provers (see Assert.Differential).
+//
+// The values of a program are its secret inputs x0, x1... followed by the results of its
+// instructions, each instruction reading earlier values only. The values compared by
+// OpAssertIsEqual and OpAssertIsDifferent are the public outputs y0, y1...
+type Program struct {
+	NbInputs, NbOutputs int
+	Instructions        []Instruction
+}
+
+// NewProgram returns a random program of nbInstructions instructions on nbInputs inputs.
+func NewProgram(rng *mrand.Rand, nbInputs, nbInstructions int) *Program {
+	p := &Program{NbInputs: nbInputs}
+	nbValues := nbInputs
+
+	// values which are likely booleans, for the operations expecting some
+	var booleans []int
+	pick := func(boolean bool) int {
+		if boolean && len(booleans) > 0 && rng.Intn(5) != 0 {
+			return booleans[rng.Intn(len(booleans))]
+		}
+		// favor recent values, to get deep programs
+		if rng.Intn(2) == 0 && nbValues > nbInputs {
+			return nbInputs + rng.Intn(nbValues-nbInputs)
+		}
+		return rng.Intn(nbValues)
+	}
+
+	for i := 0; i < nbInstructions; i++ {
+		ins := Instruction{Op: Op(rng.Intn(int(nbOps)))}
+		for j := 0; j < ins.Op.arity(); j++ {
+			boolean := false
+			switch ins.Op {
+			case OpSelect:
+				boolean = j == 0
+			case OpLookup2:
+				boolean = j < 2
+			case OpXor, OpOr, OpAnd, OpAssertIsBoolean:
+				boolean = true
+			}
+			ins.Args = append(ins.Args, pick(boolean))
+		}
+		switch ins.Op {
+		case OpAddConst, OpMulConst:
+			ins.Const = rng.Int63n(7) - 3
+		case OpBinary:
+			ins.Const = []int64{1, 2, 8, 64}[rng.Intn(4)]
+		case OpAssertIsLessOrEqual:
+			if rng.Intn(2) == 0 {
+				ins.Args = ins.Args[:1]
+				ins.Const = rng.Int63n(1 << 10)
+			}
+		case OpAssertIsEqual, OpAssertIsDifferent:
+			ins.Args = append(ins.Args, p.NbOutputs)
+			p.NbOutputs++
+		}
+		p.Instructions = append(p.Instructions, ins)
+
+		if !ins.Op.isAssertion() {
+			switch ins.Op {
+			case OpIsZero, OpXor, OpOr, OpAnd:
+				booleans = append(booleans, nbValues)
+			}
+			nbValues++
+		}
+	}
+
+	// always end with an output
+	p.Instructions = append(p.Instructions, Instruction{Op: OpAssertIsEqual, Args: []int{nbValues - 1, p.NbOutputs}})
+	p.NbOutputs++
+	return p
+}
+
+// String returns the program as a listing, for bug reports.
+func (p *Program) String() string {
+	var sbb strings.Builder
+	fmt.Fprintf(&sbb, "program with %d inputs, %d outputs\n", p.NbInputs, p.NbOutputs)
+	nbValues := p.NbInputs
+	name := func(v int) string {
+		if v < p.NbInputs {
+			return fmt.Sprintf("x%d", v)
+		}
+		return fmt.Sprintf("v%d", v)
+	}
+	for _, ins := range p.Instructions {
+		args := make([]string, 0, len(ins.Args)+1)
+		for j, a := range ins.Args {
+			if j == len(ins.Args)-1 && (ins.Op == OpAssertIsEqual || ins.Op == OpAssertIsDifferent) {
+				args = append(args, fmt.Sprintf("y%d", a))
+			} else {
+				args = append(args, name(a))
+			}
+		}
+		switch ins.Op {
+		case OpAddConst, OpMulConst, OpBinary:
+			args = append(args, fmt.Sprint(ins.Const))
+		case OpAssertIsLessOrEqual:
+			if len(ins.Args) == 1 {
+				args = append(args, fmt.Sprint(ins.Const))
+			}
+		}
+		call := fmt.Sprintf("%s(%s)", ins.Op, strings.Join(args, ", "))
+		if ins.Op.isAssertion() {
+			fmt.Fprintf(&sbb, "\t%s\n", call)
+		} else {
+			fmt.Fprintf(&sbb, "\t%s = %s\n", name(nbValues), call)
+			nbValues++
+		}
+	}
+	return sbb.String()
+}
+
+// without returns a copy of the program without instruction i. The values produced by the
+// instruction are replaced by the first input in the instructions reading them.
+func (p *Program) without(i int) *Program {
+	res := &Program{NbInputs: p.NbInputs, NbOutputs: p.NbOutputs}
+	removed := -1
+	if !p.Instructions[i].Op.isAssertion() {
+		removed = p.NbInputs
+		for _, ins := range p.Instructions[:i] {
+			if !ins.Op.isAssertion() {
+				removed++
+			}
+		}
+	}
+	for j, ins := range p.Instructions {
+		if j == i {
+			continue
+		}
+		c := Instruction{Op: ins.Op, Const: ins.Const, Args: make([]int, len(ins.Args))}
+		for k, a := range ins.Args {
+			isOutput := k == len(ins.Args)-1 && (ins.Op == OpAssertIsEqual || ins.Op == OpAssertIsDifferent)
+			switch {
+			case isOutput || removed < 0 || a < removed:
+				c.Args[k] = a
+			case a == removed:
+				c.Args[k] = 0
+			default:
+				c.Args[k] = a - 1
+			}
+		}
+		res.Instructions = append(res.Instructions, c)
+	}
+	return res
+}
+
+// programCircuit runs a program on its inputs.
+type programCircuit struct {
+	X []frontend.Variable
+	Y []frontend.Variable `gnark:",public"`
+
+	program *Program
+
+	// if set, the assertions are skipped and the values compared to the outputs are recorded
+	// in it, which is only possible with the test engine
+	probe *[]*big.Int
+}
+
+func newProgramCircuit(p *Program) *programCircuit {
+	return &programCircuit{X: make([]frontend.Variable, p.NbInputs), Y: make([]frontend.Variable, p.NbOutputs), program: p}
+}
+
+func (c *programCircuit) Define(api frontend.API) error {
+	values := append([]frontend.Variable{}, c.X...)
+	for _, ins := range c.program.Instructions {
+		a := make([]frontend.Variable, ins.Op.arity())
+		for j := range a {
+			a[j] = values[ins.Args[j]]
+		}
+
+		if ins.Op.isAssertion() {
+			if c.probe != nil {
+				if ins.Op == OpAssertIsEqual || ins.Op == OpAssertIsDifferent {
+					v, _ := api.Compiler().ConstantValue(a[0])
+					*c.probe = append(*c.probe, new(big.Int).Set(v))
+				}
+				continue
+			}
+			switch ins.Op {
+			case OpAssertIsEqual:
+				api.AssertIsEqual(a[0], c.Y[ins.Args[1]])
+			case OpAssertIsDifferent:
+				api.AssertIsDifferent(a[0], c.Y[ins.Args[1]])
+			case OpAssertIsBoolean:
+				api.AssertIsBoolean(a[0])
+			case OpAssertIsLessOrEqual:
+				if len(ins.Args) == 1 {
+					api.AssertIsLessOrEqual(a[0], ins.Const)
+				} else {
+					api.AssertIsLessOrEqual(a[0], a[1])
+				}
+			}
+			continue
+		}
+
+		var r frontend.Variable
+		switch ins.Op {
+		case OpAdd:
+			r = api.Add(a[0], a[1])
+		case OpSub:
+			r = api.Sub(a[0], a[1])
+		case OpMul:
+			r = api.Mul(a[0], a[1])
+		case OpNeg:
+			r = api.Neg(a[0])
+		case OpDiv:
+			r = api.Div(a[0], a[1])
+		case OpInverse:
+			r = api.Inverse(a[0])
+		case OpAddConst:
+			r = api.Add(a[0], ins.Const)
+		case OpMulConst:
+			r = api.Mul(a[0], ins.Const)
+		case OpIsZero:
+			r = api.IsZero(a[0])
+		case OpSelect:
+			r = api.Select(a[0], a[1], a[2])
+		case OpLookup2:
+			r = api.Lookup2(a[0], a[1], a[2], a[3], a[4], a[5])
+		case OpXor:
+			r = api.Xor(a[0], a[1])
+		case OpOr:
+			r = api.Or(a[0], a[1])
+		case OpAnd:
+			r = api.And(a[0], a[1])
+		case OpCmp:
+			r = api.Cmp(a[0], a[1])
+		case OpBinary:
+			r = api.FromBinary(api.ToBinary(a[0], int(ins.Const))...)
+		default:
+			return fmt.Errorf("unknown operation %s", ins.Op)
+		}
+		values = append(values, r)
+	}
+	return nil
+}
+
+// Differential generates nbPrograms random programs of nbInstructions frontend.API calls, and
+// checks for a few assignments of each that the test engine, the constraint systems of the
+// r1cs and scs builders and the provers of the backends all agree on their satisfiability.
+// A disagreement fails the test with a minimized program and its assignment.
+//
+// The assignments are random small values, bits and field elements; the public outputs are
+// set to the values computed by the test engine, or to wrong ones, so that both satisfiable
+// and unsatisfiable assignments are tried.
+func (assert *Assert) Differential(nbPrograms, nbInstructions int, opts ...TestingOption) {
+	opt := assert.options(opts...)
+
+	// the distributed backends need the same programs on all the parties of a cluster
+	seed := time.Now().UnixNano()
+	if hasDistributed(opt.backends) && opt.cluster != nil {
+		seed = 1
+		assert.NoError(opt.cluster.Init(), "setting up the cluster")
+	}
+	assert.Log("differential testing seed", seed)
+	rng := mrand.New(mrand.NewSource(seed)) //#nosec G404 weak rng is fine here
+
+	const nbInputs, nbAssignments = 4, 4
+	for i := 0; i < nbPrograms; i++ {
+		p := NewProgram(rng, nbInputs, nbInstructions)
+		for _, curve := range opt.curves {
+			for j := 0; j < nbAssignments; j++ {
+				assignment := newAssignment(rng, p, curve)
+				verdicts := assert.checkProgram(p, assignment, curve, &opt)
+				if assert.agreed(verdicts, &opt) {
+					continue
+				}
+				p, verdicts = assert.minimize(p, assignment, curve, &opt)
+				if opt.cluster != nil && mpi.SelfRank != 0 {
+					assert.FailNow(fmt.Sprintf("%s: the master found a disagreement on the satisfiability:\n%s\nx = %v\ny = %v",
+						curve, p, assignment.X, assignment.Y))
+				}
+				assert.FailNow(fmt.Sprintf("%s: disagreement on the satisfiability: %s\n%s\nx = %v\ny = %v",
+					curve, formatVerdicts(verdicts), p, assignment.X, assignment.Y))
+			}
+		}
+	}
+}
+
+// newAssignment returns a random assignment of the program. The outputs are probed with the
+// test engine, and set to a wrong value with probability 1/4 each.
+func newAssignment(rng *mrand.Rand, p *Program, curve ecc.ID) *programCircuit {
+	modulus := curve.ScalarField()
+	res := newProgramCircuit(p)
+	for i := range res.X {
+		switch rng.Intn(4) {
+		case 0:
+			res.X[i] = rng.Intn(2)
+		case 1:
+			res.X[i] = rng.Intn(1 << 10)
+		case 2:
+			res.X[i] = new(big.Int).Sub(modulus, big.NewInt(int64(1+rng.Intn(2))))
+		default:
+			res.X[i] = new(big.Int).Rand(rng, modulus)
+		}
+	}
+
+	// the engine stops on the first invalid operation: the outputs after it are left random
+	var probed []*big.Int
+	probe := newProgramCircuit(p)
+	probe.probe = &probed
+	copy(probe.X, res.X)
+	for i := range probe.Y {
+		probe.Y[i] = 0
+	}
+	_ = IsSolved(probe, probe, curve, backend.UNKNOWN)
+	for i := range res.Y {
+		if i < len(probed) && rng.Intn(4) != 0 {
+			res.Y[i] = probed[i]
+		} else {
+			res.Y[i] = rng.Intn(1 << 10)
+		}
+	}
+	return res
+}
+
+// checkProgram returns whether each of the test engine, the constraint systems and the provers
+// accepts the assignment of p.
+func (assert *Assert) checkProgram(p *Program, assignment *programCircuit, curve ecc.ID, opt *testingConfig) map[string]bool {
+	verdicts := map[string]bool{"engine": IsSolved(newProgramCircuit(p), assignment, curve, backend.UNKNOWN) == nil}
+
+	w, err := frontend.NewWitness(assignment, curve)
+	assert.NoError(err)
+	public, err := frontend.NewWitness(assignment, curve, frontend.PublicOnly())
+	assert.NoError(err)
+	popts := append(opt.proverOpts, backend.IgnoreSolverError())
+
+	// a builder failing to compile the program rejects all the assignments
+	compiled := make(map[string]frontend.CompiledConstraintSystem)
+	for _, b := range []struct {
+		name    string
+		builder frontend.NewBuilder
+	}{{"r1cs", r1cs.NewBuilder}, {"scs", scs.NewBuilder}} {
+		ccs, err := compile(curve, b.builder, newProgramCircuit(p), opt.compileOpts...)
+		verdicts[b.name] = err == nil && ccs.IsSolved(w, opt.proverOpts...) == nil
+		if err == nil {
+			compiled[b.name] = ccs
+		}
+	}
+
+	for _, b := range opt.backends {
+		if isDistributed(b) && !isDistributedCurve(curve) {
+			continue
+		}
+		ccs := compiled["scs"]
+		if b == backend.GROTH16 {
+			ccs = compiled["r1cs"]
+		}
+		if ccs == nil {
+			verdicts[b.String()] = false
+			continue
+		}
+
+		var err error
+		switch b {
+		case backend.GROTH16:
+			pk, vk, setupErr := groth16.Setup(ccs)
+			assert.NoError(setupErr)
+			proof, _ := groth16.Prove(ccs, pk, w, popts...)
+			err = groth16.Verify(proof, vk, public)
+		case backend.PLONK:
+			srs, setupErr := NewKZGSRS(ccs)
+			assert.NoError(setupErr)
+			pk, vk, setupErr := plonk.Setup(ccs, srs)
+			assert.NoError(setupErr)
+			proof, _ := plonk.Prove(ccs, pk, w, popts...)
+			err = plonk.Verify(proof, vk, public)
+		case backend.PIANO, backend.GPIANO:
+			var setupErr error
+			setupErr, err = opt.proveDistributed(b, ccs, w, public, popts...)
+			assert.NoError(setupErr)
+		}
+		// on a cluster, only the master party of the distributed backends verifies the proof
+		if !isDistributed(b) || opt.cluster == nil || mpi.SelfRank == 0 {
+			verdicts[b.String()] = err == nil
+		}
+	}
+	return verdicts
+}
+
+// minimize removes instructions from p as long as the checks still disagree on the assignment.
+func (assert *Assert) minimize(p *Program, assignment *programCircuit, curve ecc.ID, opt *testingConfig) (*Program, map[string]bool) {
+	verdicts := assert.checkProgram(p, assignment, curve, opt)
+	for reduced := true; reduced; {
+		reduced = false
+		for i := len(p.Instructions) - 1; i >= 0; i-- {
+			candidate := p.without(i)
+			a := &programCircuit{X: assignment.X, Y: assignment.Y, program: candidate}
+			if v := assert.checkProgram(candidate, a, curve, opt); !assert.agreed(v, opt) {
+				p, verdicts, reduced = candidate, v, true
+			}
+		}
+	}
+	return p, verdicts
+}
+
+func compile(curve ecc.ID, builder frontend.NewBuilder, circuit frontend.Circuit, opts ...frontend.CompileOption) (ccs frontend.CompiledConstraintSystem, err error) {
+	// the builders may panic on operations they consider invalid
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return frontend.Compile(curve, builder, circuit, opts...)
+}
+
+// agreed reports whether the checks agree on the satisfiability. On a cluster, the verdict of the
+// master, which alone verifies the proofs of the distributed backends, is sent to the other
+// parties: they all go on, minimize or fail together, rather than wait for a master which stopped.
+func (assert *Assert) agreed(verdicts map[string]bool, opt *testingConfig) bool {
+	agreed := agree(verdicts)
+	if opt.cluster == nil || !hasDistributed(opt.backends) {
+		return agreed
+	}
+	agreed, err := shareVerdict(cluster.MPI(), agreed)
+	assert.NoError(err, "sharing the verdict of the master")
+	return agreed
+}
+
+// shareVerdict sends the verdict of the master to the other parties, and returns it.
+func shareVerdict(tr cluster.Transport, agreed bool) (bool, error) {
+	if tr.Rank() != 0 {
+		buf, err := tr.ReceiveBytes(1, 0)
+		if err != nil {
+			return false, err
+		}
+		return buf[0] == 1, nil
+	}
+	var buf [1]byte
+	if agreed {
+		buf[0] = 1
+	}
+	for i := uint64(1); i < tr.Size(); i++ {
+		if err := tr.SendBytes(buf[:], i); err != nil {
+			return false, err
+		}
+	}
+	return agreed, nil
+}
+
+func agree(verdicts map[string]bool) bool {
+	for _, v := range verdicts {
+		if v != verdicts["engine"] {
+			return false
+		}
+	}
+	return true
+}
+
+func formatVerdicts(verdicts map[string]bool) string {
+	names := make([]string, 0, len(verdicts))
+	for name := range verdicts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	res := make([]string, len(names))
+	for i, name := range names {
+		if verdicts[name] {
+			res[i] = name + ": accepted"
+		} else {
+			res[i] = name + ": rejected"
+		}
+	}
+	return strings.Join(res, ", ")
+}
@@ -0,0 +1,68 @@
+package test
+
+import (
+	mrand "math/rand"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/internal/mpisim"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDifferential runs the single-process backends only: the PIANO and GPIANO branches of
+// checkProgram are not covered by it, but by TestDifferentialDistributed.
+func TestDifferential(t *testing.T) {
+	assert := NewAssert(t)
+	assert.Differential(10, 12, WithCurves(ecc.BN254), WithBackends(backend.GROTH16, backend.PLONK))
+}
+
+// TestDifferentialDistributed runs the distributed backends on in-process worlds of 2 and 4
+// parties, or on the cluster of PIANIST_CLUSTER if set, every party running the test.
+func TestDifferentialDistributed(t *testing.T) {
+	assert := NewAssert(t)
+	assert.Differential(2, 12, WithCurves(ecc.BN254), WithBackends(backend.PIANO, backend.GPIANO))
+}
+
+// TestShareVerdict checks that the parties of a cluster get the verdict of the master.
+func TestShareVerdict(t *testing.T) {
+	for _, verdict := range []bool{true, false} {
+		world, err := mpisim.NewWorld(3)
+		require.NoError(t, err)
+		got := make([]bool, 3)
+		errs := world.Run(func(p *mpisim.Party) error {
+			// the other parties don't know the verdict
+			var err error
+			got[p.Rank()], err = shareVerdict(p, verdict && p.Rank() == 0)
+			return err
+		})
+		for i := range errs {
+			require.NoError(t, errs[i])
+			require.Equal(t, verdict, got[i], "party %d", i)
+		}
+	}
+}
+
+func TestProgramWithout(t *testing.T) {
+	assert := NewAssert(t)
+
+	p := &Program{NbInputs: 2, NbOutputs: 1, Instructions: []Instruction{
+		{Op: OpMul, Args: []int{0, 1}},
+		{Op: OpAddConst, Args: []int{2}, Const: 3},
+		{Op: OpAssertIsEqual, Args: []int{3, 0}},
+	}}
+	assert.Equal("program with 2 inputs, 1 outputs\n\tv2 = Mul(x0, x1)\n\tv3 = AddConst(v2, 3)\n\tAssertIsEqual(v3, y0)\n", p.String())
+
+	// the reads of the removed value are replaced by the first input
+	assert.Equal([]Instruction{
+		{Op: OpAddConst, Args: []int{0}, Const: 3},
+		{Op: OpAssertIsEqual, Args: []int{2, 0}},
+	}, p.without(0).Instructions)
+
+	rng := mrand.New(mrand.NewSource(1)) //#nosec G404 weak rng is fine here
+	p = NewProgram(rng, 3, 20)
+	assert.Equal(OpAssertIsEqual, p.Instructions[len(p.Instructions)-1].Op)
+	for i := range p.Instructions {
+		assert.NotPanics(func() { _ = p.without(i).String() })
+	}
+}
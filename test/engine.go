@@ -394,6 +394,11 @@ func (e *engine) AddCounter(from, to frontend.Tag) {
 	// do nothing, we don't measure constraints with the test engine
 }
 
+func (e *engine) OnParty(i int, f func(api frontend.API)) {
+	// the test engine has no parties
+	f(e)
+}
+
 func (e *engine) toBigInt(i1 frontend.Variable) big.Int {
 	b := utils.FromInterface(i1)
 	b.Mod(&b, e.modulus())
@@ -19,6 +19,7 @@ package test
 import (
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/cluster"
 	"github.com/consensys/gnark/frontend"
 )
 
@@ -33,6 +34,8 @@ type testingConfig struct {
 	witnessSerialization bool
 	proverOpts           []backend.ProverOption
 	compileOpts          []frontend.CompileOption
+	cluster              *cluster.Config
+	parties              []uint64
 }
 
 // WithBackends is testing option which restricts the backends the assertions are
@@ -82,3 +85,24 @@ func WithCompileOpts(compileOpts ...frontend.CompileOption) TestingOption {
 		return nil
 	}
 }
+
+// WithCluster is a testing option which runs the distributed backends (piano, gpiano) on the
+// cluster c. When not given, uses cluster.FromEnv, and runs them on in-process worlds (see
+// WithParties) if PIANIST_CLUSTER is not set.
+func WithCluster(c *cluster.Config) TestingOption {
+	return func(opt *testingConfig) error {
+		opt.cluster = c
+		return nil
+	}
+}
+
+// WithParties is a testing option which restricts the numbers of parties of the in-process
+// worlds the distributed backends (piano, gpiano) run on, without a cluster. When not given,
+// runs with 2 and 4 parties.
+func WithParties(n uint64, ns ...uint64) TestingOption {
+	return func(opt *testingConfig) error {
+		opt.parties = []uint64{n}
+		opt.parties = append(opt.parties, ns...)
+		return nil
+	}
+}